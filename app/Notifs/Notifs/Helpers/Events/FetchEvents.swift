@@ -14,7 +14,7 @@ class EventFetcher: ObservableObject {
         error = nil
         
         // Try to fetch remote data first
-        guard let url = URL(string: "\(baseURL)/api/collections/events/records?perPage=200") else {
+        guard let url = URL(string: "\(baseURL)/api/collections/events/records?perPage=200&expand=event_updates_via_event") else {
             self.error = "Invalid URL"
             return
         }
@@ -43,7 +43,7 @@ class EventFetcher: ObservableObject {
         isLoading = true
         error = nil
 
-        guard let url = URL(string: "\(baseURL)/api/collections/events/records?filter=(vid_urls!=''||updates!='')") else {
+        guard let url = URL(string: "\(baseURL)/api/collections/events/records?filter=(vid_urls!=''||updates!=''||event_updates_via_event.id!='')&expand=event_updates_via_event") else {
             self.error = "Invalid URL"
             return
         }
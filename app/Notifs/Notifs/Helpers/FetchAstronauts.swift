@@ -11,7 +11,7 @@ class AstronautService {
     static let shared = AstronautService()
     
     func fetchAstronauts(completion: @escaping (Result<[Astronaut], Error>) -> Void) {
-        guard let url = URL(string: "http://localhost:8080/api/collections/astronauts/records?perPage=100") else {
+        guard let url = URL(string: "http://localhost:8080/api/collections/astronauts/records?perPage=100&sort=priority,name") else {
             completion(.failure(URLError(.badURL)))
             return
         }
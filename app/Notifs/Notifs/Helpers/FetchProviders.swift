@@ -44,7 +44,7 @@ class LaunchProviderFetcher: ObservableObject {
 
         do {
             // Fetch providers
-            let providerURL = URL(string: "http://localhost:8080/api/collections/launch_providers/records?perPage=200")!
+            let providerURL = URL(string: "http://localhost:8080/api/collections/agencies/records?perPage=200&filter=(launch_provider=true)")!
             let (providerData, _) = try await URLSession.shared.data(from: providerURL)
             let providerResult = try JSONDecoder().decode(LaunchProviderResult.self, from: providerData)
             self.providers = providerResult.items
@@ -19,6 +19,30 @@ struct LaunchEvent: Codable, Identifiable {
 
     struct LaunchEventExpand: Codable {
         let mission: Mission?
+        let event_updates_via_event: [LaunchUpdate]?
+    }
+
+    // A Launch Library update from the event_updates collection
+    struct LaunchUpdate: Codable {
+        let id: String
+        let comment: String
+        let info_url: String?
+        let created_on: String
+    }
+
+    // Updates from event_updates, falling back to the updates older events stored inline
+    var allUpdates: [UpdateEntry] {
+        guard let launchUpdates = expand?.event_updates_via_event, !launchUpdates.isEmpty else {
+            return updates ?? []
+        }
+        return launchUpdates.map {
+            UpdateEntry(
+                id: $0.id,
+                title: $0.comment,
+                description: $0.info_url ?? "",
+                created_at: $0.created_on.replacingOccurrences(of: " ", with: "T")
+            )
+        }
     }
 
     struct UpdateEntry: Codable, Identifiable {
@@ -45,6 +69,10 @@ struct LaunchEvent: Codable, Identifiable {
         let title: String
         let url: String
         let priority: Int?
+        let platform: String?
+        let video_id: String?
+        let language: String?
+        let live: Bool?
 
         var id: String { url }
     }
@@ -13,7 +13,7 @@ struct LaunchProvider: Codable, Identifiable {
     let abbrev: String?
     let country_code: String?
     let type: String?
-    let founded: String?
+    let founded: Int?
     let logo_url: String?
     let image_url: String?
     let wiki_url: String?
@@ -22,6 +22,14 @@ struct LaunchProvider: Codable, Identifiable {
     
     let description: String?
 
+    // Providers are read from the canonical agencies collection
+    enum CodingKeys: String, CodingKey {
+        case id, name, abbrev, country_code, logo_url, image_url, wiki_url, info_url, description
+        case type = "type_name"
+        case founded = "founding_year"
+        case spacedevs_id = "api_id"
+    }
+
     // Computed properties for grouping/display
     var nameInitial: String {
         return String(name.prefix(1)).uppercased()
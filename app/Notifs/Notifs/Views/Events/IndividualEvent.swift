@@ -114,7 +114,7 @@ struct LaunchEventDetailView: View {
     }
 
     private var updatesSection: some View {
-        let updates = event.updates ?? []
+        let updates = event.allUpdates
         let updateEntries = updates.map {
             UpdateEntry(id: $0.id, title: $0.title, description: $0.description, created_at: $0.created_at)
         }
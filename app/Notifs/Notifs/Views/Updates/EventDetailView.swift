@@ -45,7 +45,7 @@ struct EventDetailView: View {
         var allUpdates: [AnyUpdate] = []
 
         for event in events {
-            let updates = (event.updates ?? []).compactMap { update -> AnyUpdate? in
+            let updates = event.allUpdates.compactMap { update -> AnyUpdate? in
                 guard let date = parseISODate(update.created_at) else { return nil }
                 return AnyUpdate(
                     id: update.id,
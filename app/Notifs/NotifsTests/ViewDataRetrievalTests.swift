@@ -67,6 +67,16 @@ class ViewDataRetrievalTests: XCTestCase {
         XCTAssertNotNil(view.body, "View should present providers")
     }
 
+    func testLaunchProvider_decodesAgencyRecord() throws {
+        let json = """
+        {"id": "abc123", "name": "SpaceX", "abbrev": "SpX", "country_code": "USA", "type_name": "Commercial", "founding_year": 2002, "api_id": 121}
+        """.data(using: .utf8)!
+        let provider = try JSONDecoder().decode(LaunchProvider.self, from: json)
+        XCTAssertEqual(provider.founded, 2002, "Founding year should come from the agencies collection")
+        XCTAssertEqual(provider.type, "Commercial")
+        XCTAssertEqual(provider.spacedevs_id, 121)
+    }
+
     // MARK: - PadsGlobeView
     func testPadsGlobeView_retrievesDataFromCacheAndAPI_andPresentsCorrectly() async {
         let view = PadsGlobeView()
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/signal-k/notifs/internal/apns"
+)
+
+func main() {
+	var (
+		addr         = flag.String("addr", "127.0.0.1:2197", "Address to listen on")
+		keyPath      = flag.String("key", "", "Verify provider tokens against this .p8 key")
+		topic        = flag.String("topic", "", "Only accept pushes for this bundle ID")
+		unregistered = flag.String("unregistered", "", "Comma separated device tokens to answer with 410 Unregistered")
+	)
+	flag.Parse()
+
+	mock := apns.NewMock()
+	mock.Topic = *topic
+	mock.OnPush = func(p apns.MockPush) {
+		log.Printf("📲 %s collapse=%q %s", p.DeviceToken, p.CollapseID, p.Payload)
+	}
+
+	if *keyPath != "" {
+		data, err := os.ReadFile(*keyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		key, err := apns.ParsePrivateKey(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mock.Key = &key.PublicKey
+	}
+
+	for _, token := range strings.Split(*unregistered, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			mock.Unregister(token)
+		}
+	}
+
+	log.Printf("🍎 Mock APNs listening on http://%s (unencrypted HTTP/2)", *addr)
+	log.Fatal(mock.ListenAndServe(*addr))
+}
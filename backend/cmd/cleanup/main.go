@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/config"
 	"github.com/signal-k/notifs/internal/pbclient"
 	"github.com/signal-k/notifs/internal/utils"
@@ -17,6 +18,7 @@ func main() {
 		cleanupEvents = flag.Bool("cleanup-events", false, "Remove duplicate events from the database")
 		help          = flag.Bool("help", false, "Show help message")
 	)
+	opts := cli.Flags()
 	flag.Parse()
 
 	if *help {
@@ -30,6 +32,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	// Load configuration using the same config package as main app
 	cfg := config.Load()
 
@@ -50,14 +57,14 @@ func main() {
 		time.Sleep(2 * time.Second)
 	}
 	if err != nil {
-		log.Fatalf("Admin login failed after retries: %v", err)
+		opts.Fatalf("Admin login failed after retries: %v", err)
 	}
 
 	// Execute the requested action
 	if *cleanupEvents {
 		log.Println("Starting duplicate events cleanup...")
 		if err := utils.RemoveDuplicateEvents(client); err != nil {
-			log.Fatalf("Cleanup failed: %v", err)
+			opts.Fatalf("Cleanup failed: %v", err)
 		}
 		log.Println("✅ Cleanup completed successfully!")
 	}
@@ -70,6 +77,8 @@ func printHelp() {
 	log.Println("")
 	log.Println("Available flags:")
 	log.Println("  -cleanup-events    Remove duplicate events from the database")
+	log.Println("  -dry-run          Print the duplicates that would be deleted without deleting them")
+	log.Println("  -plan-format      Dry run output: text (default) or json")
 	log.Println("  -help             Show this help message")
 	log.Println("")
 	log.Println("Environment variables required:")
@@ -79,5 +88,6 @@ func printHelp() {
 	log.Println("")
 	log.Println("Examples:")
 	log.Println("  go run cmd/utils-main.go -cleanup-events")
+	log.Println("  go run cmd/utils-main.go -cleanup-events -dry-run")
 	log.Println("  go run cmd/utils-main.go -help")
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/signal-k/notifs/internal/cli"
+	"github.com/signal-k/notifs/internal/config"
+	"github.com/signal-k/notifs/internal/digest"
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// runInterval is how often recipients are checked for a due digest
+const runInterval = time.Hour
+
+func main() {
+	var (
+		once    = flag.Bool("once", false, "Send every digest due this week now and exit, ignoring the Monday 08:00 schedule")
+		preview = flag.Bool("preview", false, "Print this week's digests instead of sending them")
+		listen  = flag.String("listen", ":8091", "Address for the unsubscribe handler; empty disables it")
+		dir     = flag.String("templates", config.TemplatesDir(), "Directory of templates and locale bundles overriding the built in ones")
+	)
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
+	cfg := config.Load()
+	client := pbclient.NewClient(cfg.PocketbaseURL)
+
+	// Retry admin login until PocketBase is ready
+	var err error
+	for i := 0; i < 30; i++ {
+		err = client.Login(cfg.PocketbaseAdmin, cfg.PocketbasePassword)
+		if err == nil {
+			break
+		}
+		log.Printf("Waiting for PocketBase to be ready (%d/30): %v", i+1, err)
+		time.Sleep(2 * time.Second)
+	}
+	if err != nil {
+		opts.Fatalf("Admin login failed after retries: %v", err)
+	}
+
+	smtpCfg, ok := config.LoadSMTP()
+	var mailer *digest.Mailer
+	if ok {
+		from, err := mail.ParseAddress(smtpCfg.From)
+		if err != nil {
+			opts.Fatalf("SMTP_FROM: %v", err)
+		}
+		mailer = &digest.Mailer{
+			Addr:     net.JoinHostPort(smtpCfg.Host, smtpCfg.Port),
+			Username: smtpCfg.Username,
+			Password: smtpCfg.Password,
+			From:     *from,
+			TLS:      smtpCfg.TLS,
+		}
+	}
+
+	generator := digest.NewGenerator(client, mailer, smtpCfg.UnsubscribeURL)
+	if generator.Templates, err = templates.NewSet(*dir); err != nil {
+		opts.Fatalf("Templates: %v", err)
+	}
+	if err := generator.Templates.LoadPocketBase(client); err != nil {
+		log.Printf("⚠️ Using the built in templates: %v", err)
+	}
+
+	// Emails can't be planned, so previews and dry runs render a single pass
+	if *preview || opts.DryRun.Enabled {
+		generator.Mailer = nil
+		generator.IgnoreSchedule = true
+		generator.OnRender = func(d digest.Digest, email digest.Email) {
+			fmt.Printf("To: %s\nSubject: %s\n\n%s\n", email.To, email.Subject, email.Text)
+		}
+		if _, err := generator.Run(); err != nil {
+			opts.Fatal(err)
+		}
+		return
+	}
+	if mailer == nil {
+		opts.Fatal("SMTP_HOST and SMTP_FROM are required to send digests")
+	}
+
+	if *once {
+		generator.IgnoreSchedule = true
+		sent, err := generator.Run()
+		if err != nil {
+			opts.Fatal(err)
+		}
+		log.Printf("📧 Sent %d digests", sent)
+		return
+	}
+
+	if *listen != "" {
+		http.Handle("/unsubscribe", digest.UnsubscribeHandler(client))
+		go func() {
+			log.Printf("📭 Unsubscribe handler listening on %s", *listen)
+			opts.Fatal(http.ListenAndServe(*listen, nil))
+		}()
+	}
+
+	for {
+		if err := generator.Templates.LoadPocketBase(client); err != nil {
+			log.Printf("⚠️ Using the built in templates: %v", err)
+		}
+		if sent, err := generator.Run(); err != nil {
+			log.Printf("❌ Digest pass failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("📧 Sent %d digests", sent)
+		}
+		time.Sleep(runInterval)
+	}
+}
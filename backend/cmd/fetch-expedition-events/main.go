@@ -1,13 +1,23 @@
 package main
 
 import (
+	"flag"
 	"log"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
+	log.Println("🔗 Linking expeditions to their launches...")
 	if err := sync.MatchExpeditionLaunches(); err != nil {
-		log.Fatalf("❌ Match failed: %v", err)
+		opts.Fatalf("❌ Match failed: %v", err)
 	}
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/signal-k/notifs/internal/apns"
+	"github.com/signal-k/notifs/internal/cli"
+	"github.com/signal-k/notifs/internal/config"
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/templates"
+	"github.com/signal-k/notifs/internal/webhooks"
+	"github.com/signal-k/notifs/internal/webpush"
+)
+
+// deliverBatch is how many jobs are queued and deliveries sent per pass
+const deliverBatch = 100
+
+// evaluateInterval is how often subscriptions are evaluated. It needs to stay well
+// under the tightest reminder, T-10m.
+const evaluateInterval = time.Minute
+
+func main() {
+	var (
+		templatesDir = flag.String("templates", config.TemplatesDir(), "Directory of templates and locale bundles overriding the built in ones")
+		tleSource    = flag.String("tle", config.TLESource(), "File or URL of the element sets for visible pass alerts")
+	)
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
+	cfg := config.Load()
+	client := pbclient.NewClient(cfg.PocketbaseURL)
+
+	// Retry admin login until PocketBase is ready
+	var err error
+	for i := 0; i < 30; i++ {
+		err = client.Login(cfg.PocketbaseAdmin, cfg.PocketbasePassword)
+		if err == nil {
+			break
+		}
+		log.Printf("Waiting for PocketBase to be ready (%d/30): %v", i+1, err)
+		time.Sleep(2 * time.Second)
+	}
+	if err != nil {
+		opts.Fatalf("Admin login failed after retries: %v", err)
+	}
+
+	wording, err := templates.NewSet(*templatesDir)
+	if err != nil {
+		opts.Fatalf("Templates: %v", err)
+	}
+
+	engine := notify.NewEngine(client)
+	engine.Listeners = append(engine.Listeners, &webhooks.Queue{Client: client, Templates: wording})
+	hooks := webhooks.NewWorker(client)
+	outbox := notify.NewOutbox(client, channels(opts, client))
+	outbox.Templates = wording
+	passAlerts := notify.NewPassAlerts(client, *tleSource)
+	for {
+		// Reloaded every pass so template edits in PocketBase apply without a restart
+		if err := wording.LoadPocketBase(client); err != nil {
+			log.Printf("⚠️ Using the built in templates: %v", err)
+		}
+
+		jobs, err := engine.Evaluate()
+		if err != nil {
+			log.Printf("❌ Notification pass failed: %v", err)
+		} else if len(jobs) > 0 {
+			log.Printf("🔔 Queued %d notification jobs", len(jobs))
+		}
+		if jobs, err := passAlerts.Evaluate(); err != nil {
+			log.Printf("❌ Visible pass alerts failed: %v", err)
+		} else if len(jobs) > 0 {
+			log.Printf("🛰️ Queued %d visible pass alerts", len(jobs))
+		}
+
+		// Sends can't be planned, so a dry run stops at queueing
+		if opts.DryRun.Enabled {
+			return
+		}
+		if _, err := outbox.Enqueue(deliverBatch); err != nil {
+			log.Printf("❌ Queueing notification deliveries failed: %v", err)
+		}
+		if sent, err := outbox.DeliverDue(deliverBatch); err != nil {
+			log.Printf("❌ Notification delivery failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("📬 Delivered %d notifications", sent)
+		}
+		if sent, err := hooks.DeliverDue(deliverBatch); err != nil {
+			log.Printf("❌ Webhook delivery failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("🪝 Delivered %d webhooks", sent)
+		}
+		time.Sleep(evaluateInterval)
+	}
+}
+
+// channels returns the delivery channels configured in the environment
+func channels(opts *cli.Options, client *pbclient.Client) []notify.Channel {
+	var configured []notify.Channel
+
+	if cfg, ok := config.LoadAPNs(); ok {
+		signer, err := apns.NewTokenSigner(cfg.KeyPath, cfg.KeyID, cfg.TeamID)
+		if err != nil {
+			opts.Fatalf("APNs key: %v", err)
+		}
+		configured = append(configured, &apns.Channel{
+			APNs:        apns.NewClient(cfg.Host, cfg.Topic, signer),
+			PB:          client,
+			Environment: cfg.Environment,
+		})
+		log.Printf("🍎 APNs delivery enabled (%s, %s)", cfg.Environment, cfg.Host)
+	}
+
+	if cfg, ok := config.LoadWebPush(); ok {
+		vapid, err := webpush.NewVAPID(cfg.Subject, cfg.PublicKey, cfg.PrivateKey)
+		if err != nil {
+			opts.Fatalf("VAPID keys: %v", err)
+		}
+		configured = append(configured, &webpush.Channel{Sender: webpush.NewSender(vapid), PB: client})
+		log.Println("🌐 Web Push delivery enabled")
+	}
+
+	if len(configured) == 0 {
+		log.Println("⚠️ No delivery channels configured; jobs stay pending")
+	}
+	return configured
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/signal-k/notifs/internal/cli"
+	"github.com/signal-k/notifs/internal/config"
+	"github.com/signal-k/notifs/internal/passes"
+)
+
+func main() {
+	var (
+		source    = flag.String("tle", config.TLESource(), "File or URL to read element sets from")
+		satellite = flag.String("sat", "25544", "NORAD catalog number or name of the satellite")
+		lat       = flag.Float64("lat", 0, "Observer latitude in degrees, north positive")
+		lon       = flag.Float64("lon", 0, "Observer longitude in degrees, east positive")
+		alt       = flag.Float64("alt", 0, "Observer altitude in meters")
+		days      = flag.Int("days", 3, "How many days ahead to predict")
+		minEl     = flag.Float64("min-elevation", passes.DefaultOptions.MinElevation, "Lowest peak elevation in degrees worth listing")
+		all       = flag.Bool("all", false, "Also list passes that can't be seen, in daylight or the earth's shadow")
+		asJSON    = flag.Bool("json", false, "Print the passes as JSON")
+		tz        = flag.String("tz", "Local", "Timezone to print times in")
+		from      = flag.String("from", "", "Predict from this RFC 3339 time instead of now")
+	)
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
+	if *lat == 0 && *lon == 0 {
+		opts.Fatal("Set the observer's location with -lat and -lon")
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		opts.Fatalf("Timezone: %v", err)
+	}
+
+	tles, err := passes.LoadTLEs(*source)
+	if err != nil {
+		opts.Fatal(err)
+	}
+	tle, ok := passes.Find(tles, *satellite)
+	if !ok {
+		opts.Fatalf("No element set for %q in %s", *satellite, *source)
+	}
+	sat, err := passes.NewSatellite(tle)
+	if err != nil {
+		opts.Fatal(err)
+	}
+
+	options := passes.DefaultOptions
+	options.MinElevation = *minEl
+	observer := passes.Observer{Latitude: *lat, Longitude: *lon, Altitude: *alt}
+	now := time.Now()
+	if *from != "" {
+		if now, err = time.Parse(time.RFC3339, *from); err != nil {
+			opts.Fatalf("-from: %v", err)
+		}
+	}
+	predicted, err := passes.Predict(sat, observer, now, now.AddDate(0, 0, *days), options)
+	if err != nil {
+		opts.Fatal(err)
+	}
+
+	shown := predicted[:0]
+	for _, pass := range predicted {
+		if pass.Visible || *all {
+			shown = append(shown, pass)
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(shown); err != nil {
+			opts.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Printf("🛰️ %s (%d) from %.4f, %.4f, elements of %s\n", tle.Name, tle.Norad, *lat, *lon, tle.Epoch.Format("2006-01-02 15:04 UTC"))
+	if now.Sub(tle.Epoch).Abs() > 7*24*time.Hour {
+		log.Printf("⚠️ The element set is over a week from the predictions; they may be minutes off")
+	}
+	if len(shown) == 0 {
+		fmt.Printf("No visible passes in the next %d days\n", *days)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Date\tStart\tHighest\tEnd\tMag\t")
+	for _, pass := range shown {
+		start, end, mag := pass.Rise, pass.Set, notVisible(pass)
+		if pass.Visible {
+			start, end, mag = pass.VisibleFrom, pass.VisibleTo, "?"
+			if pass.Magnitude != nil {
+				mag = fmt.Sprintf("%.1f", *pass.Magnitude)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n",
+			start.Time.In(loc).Format("Mon 02 Jan"), point(start, loc), point(pass.Culmination, loc), point(end, loc), mag)
+	}
+	w.Flush()
+}
+
+// point formats a moment of a pass, e.g. "18:42:10 WSW 12°". Rise and set are found to
+// within a second, so their elevation may be a hair below the horizon.
+func point(p passes.Point, loc *time.Location) string {
+	return fmt.Sprintf("%s %-3s %2.0f°", p.Time.In(loc).Format("15:04:05"), compass(p.Azimuth), math.Max(p.Elevation, 0))
+}
+
+// notVisible says why a pass can't be seen
+func notVisible(pass passes.Pass) string {
+	if !pass.Dark {
+		return "daylight"
+	}
+	return "in shadow"
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+func compass(azimuth float64) string {
+	return compassPoints[int(math.Round(azimuth/22.5))%16]
+}
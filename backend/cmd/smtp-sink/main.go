@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/signal-k/notifs/internal/digest"
+)
+
+func main() {
+	var (
+		addr = flag.String("addr", "127.0.0.1:2525", "Address to listen on")
+		dir  = flag.String("dir", "", "Save every message as an .eml file in this directory")
+	)
+	flag.Parse()
+
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	sink := digest.NewSink()
+	sink.OnMessage = func(m digest.SinkMessage) {
+		subject := ""
+		if msg, err := mail.ReadMessage(bytes.NewReader(m.Data)); err == nil {
+			subject, _ = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		}
+		log.Printf("📨 %s → %v %q (%d bytes)", m.From, m.To, subject, len(m.Data))
+
+		if *dir != "" {
+			name := filepath.Join(*dir, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+			if err := os.WriteFile(name, m.Data, 0o644); err != nil {
+				log.Printf("❌ Failed to save message: %v", err)
+			}
+		}
+	}
+
+	log.Printf("📮 SMTP sink listening on %s", *addr)
+	log.Fatal(sink.ListenAndServe(*addr))
+}
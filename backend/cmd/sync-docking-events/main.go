@@ -1,13 +1,22 @@
 package main
 
 import (
+	"flag"
 	"log"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	if err := sync.SyncDockingEvents(); err != nil {
-		log.Fatalf("🚨 Sync failed: %v", err)
+		opts.Fatalf("🚨 Sync failed: %v", err)
 	}
 }
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"flag"
 	"log"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	log.Println("🛰️ Fetching docking locations...")
 
 	if err := sync.SyncDockingLocations(); err != nil {
-		log.Fatalf("Sync failed: %v", err)
+		opts.Fatalf("Sync failed: %v", err)
 	}
 
 	log.Println("✅ Docking location sync completed.")
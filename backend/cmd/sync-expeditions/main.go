@@ -1,14 +1,23 @@
 package main
 
 import (
+	"flag"
 	"log"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	log.Println("🧭 Fetching space expeditions...")
 	if err := sync.SyncExpeditions(); err != nil {
-log.Fatalf("Sync failed: failed to parse expedition JSON: %v", err)
+opts.Fatalf("Sync failed: failed to parse expedition JSON: %v", err)
 	}
 }
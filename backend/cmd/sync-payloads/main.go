@@ -1,13 +1,25 @@
 package main
 
 import (
+	"flag"
 	"log"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	if err := sync.SyncPayloads(); err != nil {
-		log.Fatalf("sync failed: %v", err)
+		opts.Fatalf("sync failed: %v", err)
+	}
+	if err := sync.SyncPayloadFlights(); err != nil {
+		opts.Fatalf("payload flight sync failed: %v", err)
 	}
 }
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	if err := sync.SyncPrograms(); err != nil {
 		log.Printf("❌ Failed to sync programs: %v", err)
 		os.Exit(1)
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"flag"
 	"log"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	if err := sync.SyncSpacewalks(); err != nil {
-		log.Fatalf("❌ Spacewalk sync failed: %v", err)
+		opts.Fatalf("❌ Spacewalk sync failed: %v", err)
 	}
 }
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"flag"
 	"log"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	log.Println("🛰️ Fetching space stations...")
 
 	if err := sync.SyncStations(); err != nil {
-		log.Fatalf("Sync failed: %v", err)
+		opts.Fatalf("Sync failed: %v", err)
 	}
 
 	log.Println("✅ Sync completed successfully.")
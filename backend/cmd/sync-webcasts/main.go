@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/signal-k/notifs/internal/cli"
+	"github.com/signal-k/notifs/internal/sync"
+)
+
+// webcastWindow is how far ahead launches get their webcasts refreshed
+const webcastWindow = 7 * 24 * time.Hour
+
+func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
+	for {
+		untilLaunch, err := sync.SyncUpcomingWebcasts(webcastWindow)
+		if err != nil {
+			log.Printf("❌ Webcast refresh failed: %v", err)
+		}
+		if opts.DryRun.Enabled {
+			return
+		}
+
+		wait := sync.WebcastRefreshInterval(untilLaunch)
+		log.Printf("⏳ Next webcast refresh in %s", wait)
+		time.Sleep(wait)
+	}
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"time"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/config"
 	"github.com/signal-k/notifs/internal/pbclient"
 	"github.com/signal-k/notifs/internal/utils"
+	"github.com/signal-k/notifs/internal/webpush"
 )
 
 func main() {
@@ -16,8 +19,16 @@ func main() {
 	var (
 		cleanupEvents = flag.Bool("cleanup-events", false, "Remove duplicate events from the database")
 		listVidURLs   = flag.Bool("list-vidurls", false, "List launches with video URLs")
+		occupancy     = flag.String("station-occupancy", "", "Show which vehicles are docked at a station (name or record ID)")
+		occupancyAt   = flag.String("at", "", "RFC 3339 time for -station-occupancy (defaults to now)")
+		latestUpdates = flag.Int("latest-updates", 0, "Show the N most recent launch updates across all events")
+		vapidKeys     = flag.Bool("vapid-keys", false, "Generate a VAPID key pair for Web Push")
+		outbox        = flag.String("outbox", "", "List notification deliveries with these comma separated statuses, or all")
+		outboxLimit   = flag.Int("limit", 50, "How many deliveries -outbox lists")
+		requeue       = flag.String("requeue", "", "Requeue a notification delivery by ID, or every failed or dead one")
 		help          = flag.Bool("help", false, "Show help message")
 	)
+	opts := cli.Flags()
 	flag.Parse()
 
 	if *help {
@@ -25,12 +36,27 @@ func main() {
 		return
 	}
 
+	// Key generation needs neither PocketBase nor the network
+	if *vapidKeys {
+		public, private, err := webpush.GenerateVAPIDKeys()
+		if err != nil {
+			log.Fatalf("Generating VAPID keys failed: %v", err)
+		}
+		fmt.Printf("VAPID_PUBLIC_KEY=%s\nVAPID_PRIVATE_KEY=%s\n", public, private)
+		return
+	}
+
 	// Check if any action flag was provided
-	if !*cleanupEvents && !*listVidURLs {
+	if !*cleanupEvents && !*listVidURLs && *occupancy == "" && *latestUpdates <= 0 && *outbox == "" && *requeue == "" {
 		log.Println("No action specified. Use -help to see available options.")
 		os.Exit(1)
 	}
 
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+	defer opts.Report()
+
 	// Load configuration using the same config package as main app
 	cfg := config.Load()
 
@@ -40,8 +66,8 @@ func main() {
 	log.Println("🔧 Utility starting...")
 	time.Sleep(1 * time.Second)
 
-	// For list-vidurls, we might not need admin login, but for cleanup we do
-	if *cleanupEvents {
+	// For list-vidurls, we might not need admin login, but for the PocketBase queries we do
+	if *cleanupEvents || *occupancy != "" || *latestUpdates > 0 || *outbox != "" || *requeue != "" {
 		// Retry admin login until PocketBase is ready
 		var err error
 		for i := 0; i < 10; i++ {
@@ -73,6 +99,40 @@ func main() {
 		}
 		log.Println("✅ Video URL listing completed!")
 	}
+
+	if *occupancy != "" {
+		at := time.Now()
+		if *occupancyAt != "" {
+			parsed, err := time.Parse(time.RFC3339, *occupancyAt)
+			if err != nil {
+				log.Fatalf("Invalid -at time %q: %v", *occupancyAt, err)
+			}
+			at = parsed
+		}
+		if err := utils.PrintStationOccupancy(client, *occupancy, at); err != nil {
+			log.Fatalf("Station occupancy failed: %v", err)
+		}
+	}
+
+	if *latestUpdates > 0 {
+		if err := utils.PrintLatestLaunchUpdates(client, *latestUpdates); err != nil {
+			log.Fatalf("Listing launch updates failed: %v", err)
+		}
+	}
+
+	if *outbox != "" {
+		if err := utils.PrintOutbox(client, *outbox, *outboxLimit); err != nil {
+			log.Fatalf("Listing deliveries failed: %v", err)
+		}
+	}
+
+	if *requeue != "" {
+		n, err := utils.RequeueOutbox(client, *requeue)
+		if err != nil {
+			log.Fatalf("Requeue failed: %v", err)
+		}
+		log.Printf("✅ Requeued %d deliveries", n)
+	}
 }
 
 func printHelp() {
@@ -83,6 +143,19 @@ func printHelp() {
 	log.Println("Available flags:")
 	log.Println("  -cleanup-events    Remove duplicate events from the database")
 	log.Println("  -list-vidurls      List launches with video URLs")
+	log.Println("  -station-occupancy <station>")
+	log.Println("                     Show occupied docking ports of a station")
+	log.Println("  -at <time>         RFC 3339 time for -station-occupancy (defaults to now)")
+	log.Println("  -latest-updates <n>")
+	log.Println("                     Show the n most recent launch updates across all events")
+	log.Println("  -vapid-keys        Generate a VAPID key pair for Web Push")
+	log.Println("  -outbox <statuses> List notification deliveries, e.g. failed,dead or all")
+	log.Println("  -limit <n>         How many deliveries -outbox lists (default 50)")
+	log.Println("  -requeue <target>  Requeue a delivery by ID, or every failed or dead one")
+	log.Println("  -record <dir>      Record SpaceDevs responses as fixtures into dir")
+	log.Println("  -replay <dir>      Replay SpaceDevs fixtures from dir against an in-memory PocketBase")
+	log.Println("  -dry-run           Print the PocketBase changes instead of writing them")
+	log.Println("  -plan-format <fmt> Dry run output: text (default) or json")
 	log.Println("  -help             Show this help message")
 	log.Println("")
 	log.Println("Environment variables required:")
@@ -92,6 +165,12 @@ func printHelp() {
 	log.Println("")
 	log.Println("Examples:")
 	log.Println("  ./utils -cleanup-events")
+	log.Println("  ./utils -cleanup-events -dry-run -plan-format json")
 	log.Println("  ./utils -list-vidurls")
+	log.Println("  ./utils -station-occupancy \"International Space Station\" -at 2025-03-01T00:00:00Z")
+	log.Println("  ./utils -latest-updates 20")
+	log.Println("  ./utils -vapid-keys")
+	log.Println("  ./utils -outbox failed,dead")
+	log.Println("  ./utils -requeue dead")
 	log.Println("  ./utils -help")
 }
@@ -0,0 +1,91 @@
+package apns
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// Channel delivers notification jobs to every device a user registered
+type Channel struct {
+	APNs        *Client
+	PB          *pbclient.Client
+	Environment string
+}
+
+func (c *Channel) Name() string {
+	return "apns"
+}
+
+// Deliver pushes the job to the user's devices, pruning tokens APNs reports as gone.
+// It succeeds if at least one device accepted the push.
+func (c *Channel) Deliver(job notify.Job) error {
+	devices, err := UserDevices(c.PB, job.User, c.Environment)
+	if err != nil {
+		return err
+	}
+
+	n := JobNotification(job)
+	delivered := 0
+	var lastErr error
+	for _, device := range devices {
+		n.DeviceToken = device.Token
+		resp, err := c.APNs.Send(n)
+		switch {
+		case err == nil:
+			delivered++
+		case errors.Is(err, ErrUnregistered), errors.Is(err, ErrBadDeviceToken):
+			log.Printf("🧹 Pruning device token for user %s (%s)", job.User, resp.Reason)
+			if err := RemoveDevice(c.PB, device.ID); err != nil {
+				log.Printf("❌ Failed to prune device token %s: %v", device.ID, err)
+			}
+		default:
+			lastErr = err
+		}
+	}
+
+	if delivered > 0 {
+		return nil
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return notify.ErrNoRecipient
+}
+
+// JobNotification builds the push for a job. Every alert in a thread shares a collapse
+// ID, so a slipping launch replaces its earlier countdown on the lock screen.
+func JobNotification(job notify.Job) Notification {
+	title, body := job.Message()
+
+	aps := map[string]any{
+		"alert":     map[string]string{"title": title, "body": body},
+		"sound":     "default",
+		"thread-id": job.Thread(),
+	}
+	if job.TimeSensitive() {
+		aps["interruption-level"] = "time-sensitive"
+	}
+
+	n := Notification{
+		Payload: map[string]any{
+			"aps":     aps,
+			"event":   job.Event,
+			"trigger": job.Trigger,
+		},
+		CollapseID: job.Thread(),
+		Priority:   10,
+	}
+
+	// A countdown reminder is pointless once the launch time has passed, as is a pass
+	// alert once the station is in view
+	if net, ok := job.Detail["net"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, net); err == nil {
+			n.Expiration = t
+		}
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package apns
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/pbfake"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+func TestDeliverPrunesDeadTokens(t *testing.T) {
+	fake := pbfake.New()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	pb := pbclient.NewClient(srv.URL)
+
+	for _, token := range []string{"a1b2c3", "0ff1ce", "bad", "f00d"} {
+		if _, err := pb.CreateRecord("device_tokens", map[string]interface{}{
+			"id":          "device-" + token,
+			"user":        "u1",
+			"token":       token,
+			"environment": EnvironmentProduction,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, _, requests := apnsServer(t, func(w http.ResponseWriter, token string) {
+		switch token {
+		case "0ff1ce":
+			reject(w, http.StatusGone, "Unregistered", 1740830400000)
+		case "bad":
+			reject(w, http.StatusBadRequest, "BadDeviceToken", 0)
+		case "f00d":
+			reject(w, http.StatusServiceUnavailable, "ServiceUnavailable", 0)
+		}
+	})
+	channel := &Channel{APNs: client, PB: pb, Environment: EnvironmentProduction}
+
+	job := notify.Job{
+		User:    "u1",
+		Event:   "evt1",
+		Trigger: notify.TriggerT10m,
+		Detail:  map[string]any{"net": "2025-03-14T23:03:00Z"},
+		Text:    &templates.Text{Title: "Crew-10", Body: "Liftoff in 10 minutes"},
+	}
+	if err := channel.Deliver(job); err != nil {
+		t.Fatalf("Deliver = %v, want success for the device that accepted", err)
+	}
+
+	if len(*requests) != 4 {
+		t.Fatalf("sent %d pushes, want 4", len(*requests))
+	}
+	for _, req := range *requests {
+		if got := req.Header.Get("apns-collapse-id"); got != "event-evt1" {
+			t.Errorf("apns-collapse-id = %q, want the event thread", got)
+		}
+		if got := req.Header.Get("apns-expiration"); got != "1741993380" {
+			t.Errorf("apns-expiration = %q, want the launch time", got)
+		}
+	}
+
+	// Unregistered and bad tokens are pruned; a service error leaves the token alone
+	remaining := map[string]bool{}
+	for _, record := range fake.Records("device_tokens") {
+		remaining[record["token"].(string)] = true
+	}
+	for token, want := range map[string]bool{"a1b2c3": true, "0ff1ce": false, "bad": false, "f00d": true} {
+		if remaining[token] != want {
+			t.Errorf("token %s kept = %v, want %v", token, remaining[token], want)
+		}
+	}
+}
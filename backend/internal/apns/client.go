@@ -0,0 +1,147 @@
+package apns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APNs hosts
+const (
+	HostProduction  = "https://api.push.apple.com"
+	HostDevelopment = "https://api.sandbox.push.apple.com"
+)
+
+// maxCollapseID is the longest apns-collapse-id APNs accepts, in bytes
+const maxCollapseID = 64
+
+// ErrUnregistered means the device token is no longer valid for the topic; APNs
+// answers 410 and the token should be removed
+var ErrUnregistered = errors.New("device token is unregistered")
+
+// ErrBadDeviceToken means APNs can't parse the token or it belongs to the other
+// environment; APNs answers 400 BadDeviceToken and the token should be removed
+var ErrBadDeviceToken = errors.New("bad device token")
+
+// Notification is one push to one device
+type Notification struct {
+	DeviceToken string
+	Payload     any
+	// CollapseID makes a later notification replace an earlier one with the same ID
+	CollapseID string
+	// PushType is "alert" or "background"; alert is used when empty
+	PushType string
+	// Priority is 10 to deliver immediately or 5 to let the device batch it
+	Priority   int
+	Expiration time.Time
+}
+
+// Response is APNs' answer to a push
+type Response struct {
+	StatusCode int
+	APNsID     string
+	Reason     string
+	Timestamp  time.Time
+}
+
+// Client sends notifications for one app (topic)
+type Client struct {
+	Host   string
+	Topic  string
+	Signer *TokenSigner
+	HTTP   *http.Client
+}
+
+// NewClient returns a client speaking HTTP/2 to host. An http:// host, such as a
+// local mock, is reached over unencrypted HTTP/2.
+func NewClient(host, topic string, signer *TokenSigner) *Client {
+	transport := &http.Transport{
+		ForceAttemptHTTP2:   true,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     5 * time.Minute,
+	}
+	if strings.HasPrefix(host, "http://") {
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return &Client{
+		Host:   strings.TrimSuffix(host, "/"),
+		Topic:  topic,
+		Signer: signer,
+		HTTP:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+}
+
+// Send pushes a notification. It returns ErrUnregistered or ErrBadDeviceToken, along
+// with the response, when APNs reports the device token is gone or invalid.
+func (c *Client) Send(n Notification) (*Response, error) {
+	body, err := json.Marshal(n.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.Host+"/3/device/"+n.DeviceToken, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.Signer.Token()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("authorization", "bearer "+token)
+	req.Header.Set("apns-topic", c.Topic)
+	req.Header.Set("content-type", "application/json")
+
+	pushType := n.PushType
+	if pushType == "" {
+		pushType = "alert"
+	}
+	req.Header.Set("apns-push-type", pushType)
+	if n.Priority != 0 {
+		req.Header.Set("apns-priority", strconv.Itoa(n.Priority))
+	}
+	if !n.Expiration.IsZero() {
+		req.Header.Set("apns-expiration", strconv.FormatInt(n.Expiration.Unix(), 10))
+	}
+	if n.CollapseID != "" {
+		if len(n.CollapseID) > maxCollapseID {
+			return nil, fmt.Errorf("collapse ID %q is longer than %d bytes", n.CollapseID, maxCollapseID)
+		}
+		req.Header.Set("apns-collapse-id", n.CollapseID)
+	}
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send to APNs: %w", err)
+	}
+	defer res.Body.Close()
+
+	resp := &Response{StatusCode: res.StatusCode, APNsID: res.Header.Get("apns-id")}
+	if res.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+
+	var failure struct {
+		Reason    string `json:"reason"`
+		Timestamp int64  `json:"timestamp"`
+	}
+	json.NewDecoder(res.Body).Decode(&failure)
+	resp.Reason = failure.Reason
+	if failure.Timestamp != 0 {
+		resp.Timestamp = time.UnixMilli(failure.Timestamp)
+	}
+
+	if res.StatusCode == http.StatusGone {
+		return resp, ErrUnregistered
+	}
+	if res.StatusCode == http.StatusBadRequest && resp.Reason == "BadDeviceToken" {
+		return resp, ErrBadDeviceToken
+	}
+	return resp, fmt.Errorf("APNs rejected push: %d %s", res.StatusCode, resp.Reason)
+}
@@ -0,0 +1,167 @@
+package apns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// apnsServer answers pushes over HTTP/2 with TLS like APNs, through respond, and
+// records the requests it received
+func apnsServer(t *testing.T, respond func(w http.ResponseWriter, token string)) (*Client, *ecdsa.PrivateKey, *[]*http.Request) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []*http.Request
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("push sent over HTTP/%d", r.ProtoMajor)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		r.Header.Set("x-test-body", string(body))
+		requests = append(requests, r)
+		respond(w, strings.TrimPrefix(r.URL.Path, "/3/device/"))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	client := NewClient(srv.URL, "app.station98", &TokenSigner{KeyID: "KEY1234567", TeamID: "TEAM123456", Key: key})
+	client.HTTP = srv.Client()
+	return client, key, &requests
+}
+
+func reject(w http.ResponseWriter, status int, reason string, timestamp int64) {
+	w.Header().Set("apns-id", "5E3F6B3A-0000-0000-0000-000000000000")
+	w.WriteHeader(status)
+	body := map[string]any{"reason": reason}
+	if timestamp != 0 {
+		body["timestamp"] = timestamp
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestSendDelivered(t *testing.T) {
+	client, key, requests := apnsServer(t, func(w http.ResponseWriter, token string) {
+		w.Header().Set("apns-id", "EC1BF194-B3B2-424A-89A9-5A918A6E6B5D")
+	})
+
+	expiration := time.Date(2025, 3, 14, 23, 3, 0, 0, time.UTC)
+	resp, err := client.Send(Notification{
+		DeviceToken: "a1b2c3",
+		Payload:     map[string]any{"aps": map[string]any{"alert": "Liftoff"}},
+		CollapseID:  "event-abc",
+		Priority:    10,
+		Expiration:  expiration,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.APNsID != "EC1BF194-B3B2-424A-89A9-5A918A6E6B5D" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/3/device/a1b2c3" {
+		t.Errorf("request = %s %s", req.Method, req.URL.Path)
+	}
+	headers := map[string]string{
+		"apns-topic":       "app.station98",
+		"apns-push-type":   "alert",
+		"apns-priority":    "10",
+		"apns-expiration":  "1741993380",
+		"apns-collapse-id": "event-abc",
+		"content-type":     "application/json",
+	}
+	for name, want := range headers {
+		if got := req.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("authorization"), "bearer ")
+	if !ok {
+		t.Fatalf("authorization = %q, want a bearer token", req.Header.Get("authorization"))
+	}
+	if keyID, teamID, err := VerifyToken(token, &key.PublicKey); err != nil || keyID != "KEY1234567" || teamID != "TEAM123456" {
+		t.Errorf("provider token = %s %s %v", keyID, teamID, err)
+	}
+	if body := req.Header.Get("x-test-body"); body != `{"aps":{"alert":"Liftoff"}}` {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	gone := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	client, _, _ := apnsServer(t, func(w http.ResponseWriter, token string) {
+		switch token {
+		case "0ff1ce":
+			reject(w, http.StatusGone, "Unregistered", gone.UnixMilli())
+		case "bad":
+			reject(w, http.StatusBadRequest, "BadDeviceToken", 0)
+		case "f00d":
+			reject(w, http.StatusBadRequest, "BadCollapseId", 0)
+		default:
+			reject(w, http.StatusTooManyRequests, "TooManyRequests", 0)
+		}
+	})
+
+	tests := []struct {
+		token   string
+		status  int
+		reason  string
+		wantErr error
+	}{
+		{"0ff1ce", http.StatusGone, "Unregistered", ErrUnregistered},
+		{"bad", http.StatusBadRequest, "BadDeviceToken", ErrBadDeviceToken},
+		{"f00d", http.StatusBadRequest, "BadCollapseId", nil},
+		{"beef", http.StatusTooManyRequests, "TooManyRequests", nil},
+	}
+	for _, tt := range tests {
+		resp, err := client.Send(Notification{DeviceToken: tt.token, Payload: map[string]any{}})
+		if err == nil {
+			t.Errorf("%s: sent, want %d %s", tt.token, tt.status, tt.reason)
+			continue
+		}
+		if resp == nil || resp.StatusCode != tt.status || resp.Reason != tt.reason {
+			t.Errorf("%s: response = %+v, want %d %s", tt.token, resp, tt.status, tt.reason)
+		}
+		prune := errors.Is(err, ErrUnregistered) || errors.Is(err, ErrBadDeviceToken)
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %v", tt.token, err, tt.wantErr)
+		} else if tt.wantErr == nil && prune {
+			t.Errorf("%s: error = %v would prune a valid token", tt.token, err)
+		}
+	}
+
+	resp, _ := client.Send(Notification{DeviceToken: "0ff1ce", Payload: map[string]any{}})
+	if !resp.Timestamp.Equal(gone) {
+		t.Errorf("unregistered since %s, want %s", resp.Timestamp, gone)
+	}
+}
+
+func TestSendRejectsLongCollapseID(t *testing.T) {
+	client, _, requests := apnsServer(t, func(w http.ResponseWriter, token string) {})
+	if _, err := client.Send(Notification{DeviceToken: "a1b2c3", Payload: map[string]any{}, CollapseID: strings.Repeat("x", maxCollapseID+1)}); err == nil {
+		t.Error("sent a collapse ID over 64 bytes")
+	}
+	if len(*requests) != 0 {
+		t.Errorf("sent %d requests, want none", len(*requests))
+	}
+}
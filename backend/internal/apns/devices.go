@@ -0,0 +1,77 @@
+package apns
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// Device environments, matching the aps-environment entitlement of the build
+const (
+	EnvironmentProduction  = "production"
+	EnvironmentDevelopment = "development"
+)
+
+// Device is an iOS device token registered for a user
+type Device struct {
+	ID          string
+	User        string
+	Token       string
+	Environment string
+}
+
+// RegisterDevice stores a device token for a user. Tokens are unique, so a token
+// registered again (after a reinstall or by another account) moves to the new user.
+func RegisterDevice(client *pbclient.Client, user, token, environment, bundleID string) (string, error) {
+	data := map[string]interface{}{
+		"user":            user,
+		"token":           token,
+		"environment":     environment,
+		"bundle_id":       bundleID,
+		"last_registered": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	existing, err := client.QueryRecords("device_tokens", fmt.Sprintf(`token="%s"`, token), "", "", 1)
+	if err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		id, _ := existing[0]["id"].(string)
+		return id, client.UpdateRecord("device_tokens", id, data)
+	}
+
+	created, err := client.CreateRecord("device_tokens", data)
+	if err != nil {
+		return "", err
+	}
+	id, _ := (*created)["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("register device token: %v", (*created)["message"])
+	}
+	return id, nil
+}
+
+// UserDevices returns the user's device tokens for an APNs environment
+func UserDevices(client *pbclient.Client, user, environment string) ([]Device, error) {
+	records, err := client.ListAllRecords("device_tokens", fmt.Sprintf(`user="%s" && environment="%s"`, user, environment), "")
+	if err != nil {
+		return nil, fmt.Errorf("list device tokens: %w", err)
+	}
+
+	devices := make([]Device, 0, len(records))
+	for _, record := range records {
+		device := Device{}
+		device.ID, _ = record["id"].(string)
+		device.User, _ = record["user"].(string)
+		device.Token, _ = record["token"].(string)
+		device.Environment, _ = record["environment"].(string)
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// RemoveDevice deletes a device token APNs no longer accepts
+func RemoveDevice(client *pbclient.Client, id string) error {
+	return client.DeleteRecord("device_tokens", id)
+}
@@ -0,0 +1,171 @@
+package apns
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPayload is the largest notification payload APNs accepts, in bytes
+const maxPayload = 4096
+
+// MockPush is a push the mock accepted
+type MockPush struct {
+	DeviceToken string          `json:"device_token"`
+	Topic       string          `json:"topic"`
+	CollapseID  string          `json:"collapse_id,omitempty"`
+	PushType    string          `json:"push_type"`
+	Priority    string          `json:"priority,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Mock is a local stand-in for APNs. It answers /3/device/{token} over HTTP/2 with the
+// same status codes and reasons as the real service, so the sender can be exercised
+// without Apple credentials.
+type Mock struct {
+	// Key verifies provider tokens when set; otherwise any bearer token is accepted
+	Key *ecdsa.PublicKey
+	// Topic rejects pushes for other apps when set
+	Topic string
+	// OnPush is called for every accepted push
+	OnPush func(MockPush)
+
+	mu           sync.Mutex
+	unregistered map[string]time.Time
+	pushes       []MockPush
+}
+
+// NewMock returns a mock that accepts every well-formed push
+func NewMock() *Mock {
+	return &Mock{unregistered: make(map[string]time.Time)}
+}
+
+// Unregister makes the mock answer 410 Unregistered for a device token
+func (m *Mock) Unregister(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unregistered[token] = time.Now()
+}
+
+// Pushes returns the pushes accepted so far
+func (m *Mock) Pushes() []MockPush {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockPush(nil), m.pushes...)
+}
+
+// ListenAndServe serves the mock on addr over unencrypted HTTP/2
+func (m *Mock) ListenAndServe(addr string) error {
+	server := &http.Server{Addr: addr, Handler: m, Protocols: new(http.Protocols)}
+	server.Protocols.SetUnencryptedHTTP2(true)
+	return server.ListenAndServe()
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		mockError(w, http.StatusHTTPVersionNotSupported, "HTTP2Required")
+		return
+	}
+	if r.Method != http.MethodPost {
+		mockError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+		return
+	}
+	token, ok := strings.CutPrefix(r.URL.Path, "/3/device/")
+	if !ok {
+		mockError(w, http.StatusNotFound, "BadPath")
+		return
+	}
+	if decoded, err := hex.DecodeString(token); err != nil || len(decoded) == 0 {
+		mockError(w, http.StatusBadRequest, "BadDeviceToken")
+		return
+	}
+
+	auth, ok := strings.CutPrefix(r.Header.Get("authorization"), "bearer ")
+	if !ok || auth == "" {
+		mockError(w, http.StatusForbidden, "MissingProviderToken")
+		return
+	}
+	if m.Key != nil {
+		if _, _, err := VerifyToken(auth, m.Key); err != nil {
+			mockError(w, http.StatusForbidden, "InvalidProviderToken")
+			return
+		}
+	}
+
+	topic := r.Header.Get("apns-topic")
+	if topic == "" {
+		mockError(w, http.StatusBadRequest, "MissingTopic")
+		return
+	}
+	if m.Topic != "" && topic != m.Topic {
+		mockError(w, http.StatusBadRequest, "TopicDisallowed")
+		return
+	}
+	if len(r.Header.Get("apns-collapse-id")) > maxCollapseID {
+		mockError(w, http.StatusBadRequest, "BadCollapseId")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayload+1))
+	if err != nil {
+		mockError(w, http.StatusBadRequest, "BadPayload")
+		return
+	}
+	if len(body) > maxPayload {
+		mockError(w, http.StatusRequestEntityTooLarge, "PayloadTooLarge")
+		return
+	}
+	if !json.Valid(body) {
+		mockError(w, http.StatusBadRequest, "PayloadEmpty")
+		return
+	}
+
+	m.mu.Lock()
+	since, gone := m.unregistered[token]
+	push := MockPush{
+		DeviceToken: token,
+		Topic:       topic,
+		CollapseID:  r.Header.Get("apns-collapse-id"),
+		PushType:    r.Header.Get("apns-push-type"),
+		Priority:    r.Header.Get("apns-priority"),
+		Payload:     body,
+	}
+	if !gone {
+		m.pushes = append(m.pushes, push)
+	}
+	m.mu.Unlock()
+
+	if !gone && m.OnPush != nil {
+		m.OnPush(push)
+	}
+
+	w.Header().Set("apns-id", mockID())
+	if gone {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]any{"reason": "Unregistered", "timestamp": since.UnixMilli()})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func mockError(w http.ResponseWriter, status int, reason string) {
+	w.Header().Set("apns-id", mockID())
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"reason": reason})
+}
+
+// mockID returns a random UUID like the apns-id header APNs assigns
+func mockID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,142 @@
+// Package apns sends push notifications to the iOS app through the Apple Push
+// Notification service, using HTTP/2 and token-based (.p8) authentication.
+package apns
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenLifetime is how long a provider token is reused. APNs rejects tokens older
+// than an hour and throttles ones refreshed more often than every 20 minutes.
+const tokenLifetime = 50 * time.Minute
+
+// TokenSigner issues the ES256 provider tokens APNs expects in the authorization header
+type TokenSigner struct {
+	KeyID  string
+	TeamID string
+	Key    *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	token  string
+	issued time.Time
+}
+
+// NewTokenSigner loads the .p8 key downloaded from the Apple developer portal
+func NewTokenSigner(keyPath, keyID, teamID string) (*TokenSigner, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read APNs key: %w", err)
+	}
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenSigner{KeyID: keyID, TeamID: teamID, Key: key}, nil
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS #8 P-256 key, the format of a .p8 file
+func ParsePrivateKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("APNs key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse APNs key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("APNs key is not an ECDSA key")
+	}
+	return key, nil
+}
+
+// Token returns the current provider token, signing a new one when it is due
+func (s *TokenSigner) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.token != "" && now.Sub(s.issued) < tokenLifetime {
+		return s.token, nil
+	}
+
+	token, err := s.sign(now)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.issued = now
+	return token, nil
+}
+
+func (s *TokenSigner) sign(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": s.KeyID})
+	claims, _ := json.Marshal(map[string]any{"iss": s.TeamID, "iat": now.Unix()})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.Key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign APNs token: %w", err)
+	}
+
+	// JWS wants the raw 32 byte r and s, not ASN.1
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyToken checks a provider token's ES256 signature and returns its key and team IDs
+func VerifyToken(token string, key *ecdsa.PublicKey) (string, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("malformed token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return "", "", fmt.Errorf("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	sig := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(key, digest[:], r, sig) {
+		return "", "", fmt.Errorf("invalid signature")
+	}
+
+	var header struct {
+		Kid string `json:"kid"`
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", "", err
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", "", err
+	}
+	return header.Kid, claims.Iss, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("malformed token segment: %w", err)
+	}
+	return json.Unmarshal(data, v)
+}
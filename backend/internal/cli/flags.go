@@ -0,0 +1,53 @@
+// Package cli registers the command line flags shared by every syncer and utility:
+// fixture recording/replay and dry runs.
+package cli
+
+import (
+	"log"
+
+	"github.com/signal-k/notifs/internal/dryrun"
+	"github.com/signal-k/notifs/internal/fixtures"
+)
+
+// Options bundles the shared flags
+type Options struct {
+	Fixtures *fixtures.Options
+	DryRun   *dryrun.Options
+}
+
+// Flags registers the shared flags on the default flag set. Call flag.Parse and
+// then Install before doing any work.
+func Flags() *Options {
+	return &Options{
+		Fixtures: fixtures.Flags(),
+		DryRun:   dryrun.Flags(),
+	}
+}
+
+// Install sets up fixtures first and the dry run on top of them, so a dry run can
+// also replay fixtures
+func (o *Options) Install() error {
+	if err := o.Fixtures.Install(); err != nil {
+		return err
+	}
+	return o.DryRun.Install()
+}
+
+// Report prints the dry run plan and the replay summary, if either was enabled
+func (o *Options) Report() {
+	o.DryRun.Report()
+	o.Fixtures.Report()
+}
+
+// Fatal prints the report and exits like log.Fatal. Deferred calls don't run once
+// log.Fatal exits, so commands stop through Fatal and Fatalf after Install.
+func (o *Options) Fatal(v ...any) {
+	o.Report()
+	log.Fatal(v...)
+}
+
+// Fatalf prints the report and exits like log.Fatalf
+func (o *Options) Fatalf(format string, v ...any) {
+	o.Report()
+	log.Fatalf(format, v...)
+}
@@ -0,0 +1,38 @@
+package config
+
+import "os"
+
+// APNsConfig holds the optional Apple Push Notification service settings
+type APNsConfig struct {
+	KeyPath     string
+	KeyID       string
+	TeamID      string
+	Topic       string
+	Environment string
+	Host        string
+}
+
+// LoadAPNs reads the APNs settings from environment variables. ok is false when push
+// isn't configured, in which case notifications are queued but not sent to iOS.
+func LoadAPNs() (APNsConfig, bool) {
+	cfg := APNsConfig{
+		KeyPath:     os.Getenv("APNS_KEY_PATH"),
+		KeyID:       os.Getenv("APNS_KEY_ID"),
+		TeamID:      os.Getenv("APNS_TEAM_ID"),
+		Topic:       os.Getenv("APNS_TOPIC"),
+		Environment: os.Getenv("APNS_ENVIRONMENT"),
+		Host:        os.Getenv("APNS_HOST"),
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = "production"
+	}
+	if cfg.Host == "" {
+		cfg.Host = "https://api.push.apple.com"
+		if cfg.Environment == "development" {
+			cfg.Host = "https://api.sandbox.push.apple.com"
+		}
+	}
+
+	ok := cfg.KeyPath != "" && cfg.KeyID != "" && cfg.TeamID != "" && cfg.Topic != ""
+	return cfg, ok
+}
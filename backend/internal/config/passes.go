@@ -0,0 +1,16 @@
+package config
+
+import "os"
+
+// DefaultTLESource is CelesTrak's element sets for the space stations and the
+// spacecraft visiting them
+const DefaultTLESource = "https://celestrak.org/NORAD/elements/gp.php?GROUP=stations&FORMAT=tle"
+
+// TLESource is the file or URL visible pass predictions read element sets from, from
+// TLE_SOURCE, defaulting to CelesTrak
+func TLESource() string {
+	if source := os.Getenv("TLE_SOURCE"); source != "" {
+		return source
+	}
+	return DefaultTLESource
+}
@@ -0,0 +1,39 @@
+package config
+
+import "os"
+
+// SMTPConfig holds the outgoing mail settings for email digests
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	TLS      string
+	// UnsubscribeURL is where the digest's unsubscribe handler is reachable publicly
+	UnsubscribeURL string
+}
+
+// LoadSMTP reads the SMTP settings from environment variables. ok is false when no
+// server or sender is configured.
+func LoadSMTP() (SMTPConfig, bool) {
+	cfg := SMTPConfig{
+		Host:           os.Getenv("SMTP_HOST"),
+		Port:           os.Getenv("SMTP_PORT"),
+		Username:       os.Getenv("SMTP_USERNAME"),
+		Password:       os.Getenv("SMTP_PASSWORD"),
+		From:           os.Getenv("SMTP_FROM"),
+		TLS:            os.Getenv("SMTP_TLS"),
+		UnsubscribeURL: os.Getenv("DIGEST_UNSUBSCRIBE_URL"),
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = "starttls"
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+		if cfg.TLS == "tls" {
+			cfg.Port = "465"
+		}
+	}
+	return cfg, cfg.Host != "" && cfg.From != ""
+}
@@ -0,0 +1,9 @@
+package config
+
+import "os"
+
+// TemplatesDir is the directory of notification templates and locale bundles that
+// override the built in ones, from NOTIFY_TEMPLATES_DIR. Empty means none.
+func TemplatesDir() string {
+	return os.Getenv("NOTIFY_TEMPLATES_DIR")
+}
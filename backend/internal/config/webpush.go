@@ -0,0 +1,21 @@
+package config
+
+import "os"
+
+// WebPushConfig holds the optional VAPID key pair for browser push
+type WebPushConfig struct {
+	Subject    string
+	PublicKey  string
+	PrivateKey string
+}
+
+// LoadWebPush reads the VAPID settings from environment variables. ok is false when
+// Web Push isn't configured.
+func LoadWebPush() (WebPushConfig, bool) {
+	cfg := WebPushConfig{
+		Subject:    os.Getenv("VAPID_SUBJECT"),
+		PublicKey:  os.Getenv("VAPID_PUBLIC_KEY"),
+		PrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+	}
+	return cfg, cfg.Subject != "" && cfg.PrivateKey != ""
+}
@@ -0,0 +1,360 @@
+// Package digest emails users a weekly summary of the upcoming launches they follow.
+// Opted in users have an email_digests record; each run collects the launches in the
+// next week matching their notification_subscriptions, renders HTML and plain text
+// versions in the user's timezone and sends them over SMTP. Every send is recorded in
+// digest_sends under a user:week key, so reruns in the same week send nothing.
+package digest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// Send statuses
+const (
+	SendSent   = "sent"
+	SendFailed = "failed"
+)
+
+// Window is how far ahead a digest looks
+const Window = 7 * 24 * time.Hour
+
+// Recipient is a user who opted in to the digest
+type Recipient struct {
+	RecordID         string
+	User             string
+	Email            string
+	Name             string
+	Location         *time.Location
+	Locale           string
+	UnsubscribeToken string
+}
+
+// Launch is one entry in a digest
+type Launch struct {
+	ID          string
+	Title       string
+	Net         time.Time
+	Status      string
+	Provider    string
+	Pad         string
+	PadLocation string
+	PadMapURL   string
+	WebcastURL  string
+
+	event notify.Event
+}
+
+// Digest is everything a digest email is rendered from
+type Digest struct {
+	Recipient      Recipient
+	Period         string
+	From, To       time.Time
+	Launches       []Launch
+	UnsubscribeURL string
+}
+
+// Generator builds and sends digests
+type Generator struct {
+	Client *pbclient.Client
+	// Mailer sends the rendered emails; nil only renders them, for dry runs and previews
+	Mailer *Mailer
+	// UnsubscribeURL is the public address of the unsubscribe handler
+	UnsubscribeURL string
+	// SendAfter is how far into the week, from Monday 00:00 in the recipient's timezone,
+	// the digest goes out
+	SendAfter time.Duration
+	// IgnoreSchedule sends to everyone due this week straight away
+	IgnoreSchedule bool
+	Now            func() time.Time
+	// OnRender is called with every digest rendered, sent or not
+	OnRender func(d Digest, email Email)
+	// Templates word the email in the recipient's language
+	Templates *templates.Set
+}
+
+// NewGenerator returns a generator sending through mailer
+func NewGenerator(client *pbclient.Client, mailer *Mailer, unsubscribeURL string) *Generator {
+	return &Generator{
+		Client:         client,
+		Mailer:         mailer,
+		UnsubscribeURL: unsubscribeURL,
+		SendAfter:      8 * time.Hour,
+		Now:            time.Now,
+		Templates:      templates.Default,
+	}
+}
+
+// Run sends this week's digest to every recipient whose send time has passed and who
+// hasn't had it yet, and returns how many were sent. Recipients with nothing upcoming
+// are skipped.
+func (g *Generator) Run() (int, error) {
+	now := g.Now()
+
+	recipients, err := g.loadRecipients()
+	if err != nil {
+		return 0, err
+	}
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+	subscriptions, err := notify.LoadSubscriptions(g.Client)
+	if err != nil {
+		return 0, err
+	}
+	launches, err := g.loadLaunches(now)
+	if err != nil {
+		return 0, err
+	}
+
+	follows := map[string][]notify.Subscription{}
+	for _, sub := range subscriptions {
+		follows[sub.User] = append(follows[sub.User], sub)
+	}
+
+	sent := 0
+	for _, recipient := range recipients {
+		if !g.IgnoreSchedule && now.Before(weekStart(now, recipient.Location).Add(g.SendAfter)) {
+			continue
+		}
+		d := Digest{
+			Recipient: recipient,
+			Period:    Period(now, recipient.Location),
+			From:      now,
+			To:        now.Add(Window),
+		}
+		for _, launch := range launches {
+			for _, sub := range follows[recipient.User] {
+				if sub.Matches(launch.event) {
+					d.Launches = append(d.Launches, launch)
+					break
+				}
+			}
+		}
+		if len(d.Launches) == 0 {
+			continue
+		}
+
+		key := recipient.User + ":" + d.Period
+		previous, err := g.Client.QueryRecords("digest_sends", fmt.Sprintf(`dedupe_key="%s"`, key), "", "", 1)
+		if err != nil {
+			return sent, fmt.Errorf("check digest sends: %w", err)
+		}
+		if len(previous) > 0 && previous[0]["status"] == SendSent {
+			continue
+		}
+
+		if err := g.ensureToken(&recipient); err != nil {
+			log.Printf("❌ Failed to create unsubscribe token for %s: %v", recipient.Email, err)
+			continue
+		}
+		d.Recipient = recipient
+		d.UnsubscribeURL = g.unsubscribeLink(recipient.UnsubscribeToken)
+
+		email, err := Render(g.Templates, d, now)
+		if err != nil {
+			return sent, err
+		}
+		if g.OnRender != nil {
+			g.OnRender(d, email)
+		}
+		if g.Mailer == nil {
+			continue
+		}
+
+		record := map[string]interface{}{
+			"dedupe_key": key,
+			"user":       recipient.User,
+			"period":     d.Period,
+			"email":      recipient.Email,
+			"subject":    email.Subject,
+			"events":     launchIDs(d.Launches),
+		}
+		messageID, sendErr := g.Mailer.Send(email)
+		if sendErr != nil {
+			log.Printf("❌ Digest to %s failed: %v", recipient.Email, sendErr)
+			record["status"] = SendFailed
+			record["error"] = sendErr.Error()
+		} else {
+			sent++
+			log.Printf("📧 Sent %s digest to %s (%d launches)", d.Period, recipient.Email, len(d.Launches))
+			record["status"] = SendSent
+			record["error"] = ""
+			record["message_id"] = messageID
+			record["sent_at"] = formatTime(time.Now())
+		}
+		if err := g.recordSend(previous, record); err != nil {
+			log.Printf("❌ Failed to record digest to %s: %v", recipient.Email, err)
+		}
+	}
+	return sent, nil
+}
+
+// recordSend stores the outcome, replacing an earlier failed attempt in the same week
+func (g *Generator) recordSend(previous []map[string]interface{}, record map[string]interface{}) error {
+	if len(previous) > 0 {
+		id, _ := previous[0]["id"].(string)
+		return g.Client.UpdateRecord("digest_sends", id, record)
+	}
+	created, err := g.Client.CreateRecord("digest_sends", record)
+	if err != nil {
+		return err
+	}
+	if id, _ := (*created)["id"].(string); id == "" {
+		return fmt.Errorf("create rejected: %v", (*created)["message"])
+	}
+	return nil
+}
+
+// ensureToken gives the recipient an unsubscribe token the first time they are sent
+// a digest
+func (g *Generator) ensureToken(r *Recipient) error {
+	if r.UnsubscribeToken != "" {
+		return nil
+	}
+	token, err := NewToken()
+	if err != nil {
+		return err
+	}
+	if g.Mailer != nil {
+		if err := g.Client.UpdateRecord("email_digests", r.RecordID, map[string]interface{}{"unsubscribe_token": token}); err != nil {
+			return err
+		}
+	}
+	r.UnsubscribeToken = token
+	return nil
+}
+
+func (g *Generator) unsubscribeLink(token string) string {
+	if g.UnsubscribeURL == "" {
+		return ""
+	}
+	return g.UnsubscribeURL + "?token=" + token
+}
+
+func (g *Generator) loadRecipients() ([]Recipient, error) {
+	records, err := g.Client.ListAllRecords("email_digests", "enabled=true", "user")
+	if err != nil {
+		return nil, fmt.Errorf("list email digests: %w", err)
+	}
+	prefs, err := notify.LoadPreferences(g.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]Recipient, 0, len(records))
+	for _, record := range records {
+		r := Recipient{Location: time.UTC, Locale: templates.DefaultLocale}
+		r.RecordID, _ = record["id"].(string)
+		r.User, _ = record["user"].(string)
+		r.UnsubscribeToken, _ = record["unsubscribe_token"].(string)
+		if user, ok := expanded(record, "user"); ok {
+			r.Email, _ = user["email"].(string)
+			r.Name, _ = user["name"].(string)
+		}
+		if r.Email == "" {
+			continue
+		}
+		// The digest's own timezone wins, then the one in the notification preferences
+		if pref, ok := prefs[r.User]; ok {
+			r.Location = pref.Location
+			r.Locale = pref.Locale
+		}
+		if tz, _ := record["timezone"].(string); tz != "" {
+			if loc, err := time.LoadLocation(tz); err == nil {
+				r.Location = loc
+			} else {
+				log.Printf("⚠️ Unknown timezone %q for %s, using UTC", tz, r.Email)
+			}
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// loadLaunches returns the events in the coming week in NET order
+func (g *Generator) loadLaunches(now time.Time) ([]Launch, error) {
+	filter := fmt.Sprintf(`datetime>="%s" && datetime<="%s"`, formatTime(now), formatTime(now.Add(Window)))
+	records, err := g.Client.ListAllRecords("events", filter, "provider,pad_id")
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	launches := make([]Launch, 0, len(records))
+	for _, record := range records {
+		e := notify.EventFromRecord(record)
+		l := Launch{
+			ID:         e.ID,
+			Title:      e.Title,
+			Net:        e.Net,
+			Status:     e.Status,
+			Pad:        e.Location,
+			WebcastURL: e.WebcastURL,
+			event:      e,
+		}
+		if provider, ok := expanded(record, "provider"); ok {
+			l.Provider, _ = provider["name"].(string)
+		}
+		if pad, ok := expanded(record, "pad_id"); ok {
+			if name, _ := pad["name"].(string); name != "" {
+				l.Pad = name
+			}
+			l.PadLocation, _ = pad["location_name"].(string)
+			l.PadMapURL, _ = pad["map_url"].(string)
+		}
+		launches = append(launches, l)
+	}
+	sort.SliceStable(launches, func(i, j int) bool { return launches[i].Net.Before(launches[j].Net) })
+	return launches, nil
+}
+
+// Period names the ISO week a digest covers in the recipient's timezone, e.g. 2026-W43
+func Period(t time.Time, loc *time.Location) string {
+	year, week := t.In(loc).ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// weekStart is Monday 00:00 of t's week in loc
+func weekStart(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	days := (int(local.Weekday()) + 6) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-days, 0, 0, 0, 0, loc)
+}
+
+// NewToken returns a random unsubscribe token
+func NewToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func launchIDs(launches []Launch) []string {
+	ids := make([]string, 0, len(launches))
+	for _, l := range launches {
+		ids = append(ids, l.ID)
+	}
+	return ids
+}
+
+func expanded(record map[string]interface{}, field string) (map[string]interface{}, bool) {
+	expand, ok := record["expand"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := expand[field].(map[string]interface{})
+	return value, ok
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000Z")
+}
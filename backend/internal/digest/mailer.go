@@ -0,0 +1,172 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// TLS modes for the SMTP connection
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+// Mailer sends emails through an SMTP server
+type Mailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     mail.Address
+	// TLS is starttls (upgrade when the server offers it), tls (port 465) or none
+	TLS     string
+	Timeout time.Duration
+}
+
+// Send delivers the email and returns its Message-ID
+func (m *Mailer) Send(email Email) (string, error) {
+	messageID, msg, err := m.compose(email)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := m.dial()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	if m.TLS != TLSImplicit && m.TLS != TLSNone {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.host()}); err != nil {
+				return "", fmt.Errorf("starttls: %w", err)
+			}
+		}
+	}
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.host())); err != nil {
+			return "", fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.From.Address); err != nil {
+		return "", err
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return "", err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return messageID, client.Quit()
+}
+
+func (m *Mailer) dial() (*smtp.Client, error) {
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if m.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", m.Addr, &tls.Config{ServerName: m.host()})
+	} else {
+		conn, err = dialer.Dial("tcp", m.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", m.Addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, m.host())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (m *Mailer) host() string {
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return m.Addr
+	}
+	return host
+}
+
+// compose builds a multipart/alternative message with the plain text part first, so
+// clients that can show HTML pick the last part
+func (m *Mailer) compose(email Email) (string, []byte, error) {
+	token, err := NewToken()
+	if err != nil {
+		return "", nil, err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(m.From.Address, "@"); at >= 0 {
+		domain = m.From.Address[at+1:]
+	}
+	messageID := fmt.Sprintf("<%s@%s>", token[:32], domain)
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return "", nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return "", nil, err
+	}
+
+	to := mail.Address{Name: email.ToName, Address: email.To}
+	var msg bytes.Buffer
+	header := func(name, value string) { fmt.Fprintf(&msg, "%s: %s\r\n", name, value) }
+	header("From", m.From.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	if email.UnsubscribeURL != "" {
+		// One click unsubscribe (RFC 8058): mail clients POST to the link directly
+		header("List-Unsubscribe", "<"+email.UnsubscribeURL+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return messageID, msg.Bytes(), nil
+}
@@ -0,0 +1,53 @@
+package digest
+
+import (
+	"time"
+
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// templateChannel and templateTrigger name the digest template: the title is the
+// subject, the body the plain text part and the html part the HTML one
+const (
+	templateChannel = "email"
+	templateTrigger = "digest"
+)
+
+// Email is a rendered digest ready to send
+type Email struct {
+	To             string
+	ToName         string
+	Subject        string
+	Text           string
+	HTML           string
+	UnsubscribeURL string
+}
+
+// Render renders a digest in the recipient's language and timezone as of now. A nil
+// set uses the built in templates.
+func Render(set *templates.Set, d Digest, now time.Time) (Email, error) {
+	if set == nil {
+		set = templates.Default
+	}
+	if d.Recipient.Location == nil {
+		d.Recipient.Location = time.UTC
+	}
+
+	text, err := set.Render(templateChannel, templateTrigger, templates.Context{
+		Locale:   d.Recipient.Locale,
+		Location: d.Recipient.Location,
+		Now:      now,
+	}, d)
+	if err != nil {
+		return Email{}, err
+	}
+
+	return Email{
+		To:             d.Recipient.Email,
+		ToName:         d.Recipient.Name,
+		Subject:        text.Title,
+		Text:           text.Body + "\n",
+		HTML:           text.HTML,
+		UnsubscribeURL: d.UnsubscribeURL,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package digest
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// SinkMessage is an email accepted by the sink
+type SinkMessage struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// Sink is a local SMTP server that accepts every message without relaying it, for
+// trying the digest without a real mail server. It speaks plain SMTP with no TLS or
+// authentication, so point a Mailer at it with TLS none and no username.
+type Sink struct {
+	// OnMessage is called for every message accepted
+	OnMessage func(SinkMessage)
+
+	mu       sync.Mutex
+	messages []SinkMessage
+}
+
+// NewSink returns an empty sink
+func NewSink() *Sink {
+	return &Sink{}
+}
+
+// Messages returns the messages accepted so far
+func (s *Sink) Messages() []SinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SinkMessage(nil), s.messages...)
+}
+
+// ListenAndServe accepts SMTP connections on addr
+func (s *Sink) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts SMTP connections on listener
+func (s *Sink) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Sink) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(format string, args ...any) bool {
+		return text.PrintfLine(format, args...) == nil
+	}
+
+	if !reply("220 localhost station98 SMTP sink") {
+		return
+	}
+	var msg SinkMessage
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250-8BITMIME")
+			reply("250 SMTPUTF8")
+		case "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			msg = SinkMessage{From: address(arg)}
+			reply("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			reply("250 OK")
+		case "DATA":
+			if len(msg.To) == 0 {
+				reply("503 RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			if s.OnMessage != nil {
+				s.OnMessage(msg)
+			}
+			msg = SinkMessage{}
+			reply("250 OK queued")
+		case "RSET":
+			msg = SinkMessage{}
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// address pulls the mailbox out of a "FROM:<a@b> SIZE=1" style argument
+func address(arg string) string {
+	_, value, _ := strings.Cut(arg, ":")
+	value, _, _ = strings.Cut(strings.TrimSpace(value), " ")
+	return strings.Trim(value, "<>")
+}
@@ -0,0 +1,70 @@
+package digest
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+var tokenPattern = regexp.MustCompile(`^[0-9a-f]{48}$`)
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Weekly digest</title></head>
+<body style="font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;max-width:480px;margin:48px auto;padding:0 16px;">
+{{if .Done}}<p>You won't get the weekly launch digest any more. You can turn it back on in your notification settings.</p>
+{{else}}<p>Stop sending me the weekly launch digest?</p>
+<form method="post"><input type="hidden" name="token" value="{{.Token}}"><button type="submit">Unsubscribe</button></form>
+{{end}}</body></html>`))
+
+// UnsubscribeHandler turns off the digest for the token in the query string. GET asks
+// for confirmation, so link scanners opening the URL don't unsubscribe anyone; POST,
+// from the confirmation form or a mail client's one click unsubscribe, does it.
+func UnsubscribeHandler(client *pbclient.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" && r.Method == http.MethodPost {
+			token = r.PostFormValue("token")
+		}
+		if !tokenPattern.MatchString(token) {
+			http.Error(w, "invalid unsubscribe link", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			unsubscribePage.Execute(w, map[string]any{"Token": token})
+		case http.MethodPost:
+			if err := Unsubscribe(client, token); err != nil {
+				log.Printf("❌ Unsubscribe failed: %v", err)
+				http.Error(w, "could not unsubscribe, please try again later", http.StatusInternalServerError)
+				return
+			}
+			unsubscribePage.Execute(w, map[string]any{"Done": true})
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// Unsubscribe disables the digest holding token. An unknown token is not an error, so
+// repeating an unsubscribe is harmless.
+func Unsubscribe(client *pbclient.Client, token string) error {
+	records, err := client.QueryRecords("email_digests", fmt.Sprintf(`unsubscribe_token="%s"`, token), "", "", 1)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	id, _ := records[0]["id"].(string)
+	if err := client.UpdateRecord("email_digests", id, map[string]interface{}{"enabled": false}); err != nil {
+		return err
+	}
+	log.Printf("📭 Unsubscribed digest %s", id)
+	return nil
+}
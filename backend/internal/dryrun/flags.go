@@ -0,0 +1,60 @@
+package dryrun
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Options holds the -dry-run and -plan-format command line flags
+type Options struct {
+	Enabled bool
+	Format  string
+
+	plan *Plan
+}
+
+// Flags registers -dry-run and -plan-format on the default flag set
+func Flags() *Options {
+	o := &Options{}
+	flag.BoolVar(&o.Enabled, "dry-run", false, "Compute the PocketBase changes without writing them")
+	flag.StringVar(&o.Format, "plan-format", "text", "Dry run plan output: text or json")
+	return o
+}
+
+// Install routes the default HTTP transport through a planning Transport when
+// -dry-run is set. Install it after any other transport so it sees the final writes.
+func (o *Options) Install() error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.Format != "text" && o.Format != "json" {
+		return fmt.Errorf("unknown -plan-format %q, expected text or json", o.Format)
+	}
+
+	o.plan = &Plan{}
+	http.DefaultTransport = &Transport{Plan: o.plan, Next: http.DefaultTransport}
+
+	activeMu.Lock()
+	active = o.plan
+	activeMu.Unlock()
+
+	log.Println("📝 Dry run: PocketBase writes will be planned, not applied")
+	return nil
+}
+
+// Report prints the plan to stdout in the chosen format
+func (o *Options) Report() {
+	if o.plan == nil {
+		return
+	}
+	if o.Format == "json" {
+		if err := o.plan.WriteJSON(os.Stdout); err != nil {
+			log.Printf("❌ Failed to write plan: %v", err)
+		}
+		return
+	}
+	o.plan.WriteText(os.Stdout)
+}
@@ -0,0 +1,191 @@
+// Package dryrun lets any syncer or utility run without writing to PocketBase. Reads
+// go through as usual; creates, updates and deletes are collected into a Plan that is
+// printed at the end instead of being applied.
+package dryrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Change actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// FieldDiff is the old and new value of one field in a planned update
+type FieldDiff struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Change is one planned write
+type Change struct {
+	Action     string               `json:"action"`
+	Collection string               `json:"collection"`
+	RecordID   string               `json:"record_id"`
+	Label      string               `json:"label,omitempty"`
+	Fields     map[string]any       `json:"fields,omitempty"`
+	Diff       map[string]FieldDiff `json:"diff,omitempty"`
+}
+
+// Unresolved is a relation a syncer wanted to set but couldn't, usually because the
+// referenced record hasn't been synced yet
+type Unresolved struct {
+	Collection string `json:"collection"`
+	Field      string `json:"field"`
+	Reference  string `json:"reference"`
+	Reason     string `json:"reason"`
+}
+
+// Plan collects the writes a dry run would have made
+type Plan struct {
+	mu         sync.Mutex
+	Changes    []Change     `json:"changes"`
+	Unresolved []Unresolved `json:"unresolved"`
+	// Unchanged counts updates whose fields already matched the stored record
+	Unchanged int `json:"unchanged"`
+}
+
+func (p *Plan) add(change Change) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Changes = append(p.Changes, change)
+}
+
+// amendCreate merges fields into the planned create of a record
+func (p *Plan) amendCreate(collection, id string, fields map[string]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.Changes {
+		c := &p.Changes[i]
+		if c.Action == ActionCreate && c.Collection == collection && c.RecordID == id {
+			for key, value := range fields {
+				c.Fields[key] = value
+			}
+			return
+		}
+	}
+}
+
+func (p *Plan) addUnchanged() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Unchanged++
+}
+
+func (p *Plan) addUnresolved(u Unresolved) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Unresolved = append(p.Unresolved, u)
+}
+
+// Counts returns the number of planned creates, updates and deletes
+func (p *Plan) Counts() (creates, updates, deletes int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			creates++
+		case ActionUpdate:
+			updates++
+		case ActionDelete:
+			deletes++
+		}
+	}
+	return creates, updates, deletes
+}
+
+// WriteJSON writes the plan as indented JSON
+func (p *Plan) WriteJSON(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteText writes a human readable summary of the plan
+func (p *Plan) WriteText(w io.Writer) {
+	creates, updates, deletes := p.Counts()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintf(w, "📝 Dry run: %d creates, %d updates, %d deletes, %d unresolved relations (%d updates already up to date)\n",
+		creates, updates, deletes, len(p.Unresolved), p.Unchanged)
+
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(w, "  + create %s %s (%d fields)\n", c.Collection, c.Label, len(c.Fields))
+		case ActionUpdate:
+			fmt.Fprintf(w, "  ~ update %s %s %s\n", c.Collection, c.RecordID, c.Label)
+			for _, field := range sortedKeys(c.Diff) {
+				d := c.Diff[field]
+				fmt.Fprintf(w, "      %s: %s → %s\n", field, formatValue(d.Old), formatValue(d.New))
+			}
+		case ActionDelete:
+			fmt.Fprintf(w, "  - delete %s %s %s\n", c.Collection, c.RecordID, c.Label)
+		}
+	}
+
+	for _, u := range p.Unresolved {
+		fmt.Fprintf(w, "  ? unresolved %s.%s → %s (%s)\n", u.Collection, u.Field, u.Reference, u.Reason)
+	}
+}
+
+func sortedKeys(m map[string]FieldDiff) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s := string(data)
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
+
+// recordLabel picks a readable name for a record from its usual title fields
+func recordLabel(record map[string]any) string {
+	for _, field := range []string{"title", "name", "full_name", "spacecraft_name"} {
+		if s, _ := record[field].(string); strings.TrimSpace(s) != "" {
+			return fmt.Sprintf("%q", s)
+		}
+	}
+	return ""
+}
+
+var (
+	activeMu sync.Mutex
+	active   *Plan
+)
+
+// NoteUnresolved records a relation that couldn't be resolved. It does nothing unless
+// a dry run is in progress.
+func NoteUnresolved(collection, field, reference, reason string) {
+	activeMu.Lock()
+	plan := active
+	activeMu.Unlock()
+
+	if plan != nil {
+		plan.addUnresolved(Unresolved{Collection: collection, Field: field, Reference: reference, Reason: reason})
+	}
+}
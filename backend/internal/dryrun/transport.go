@@ -0,0 +1,344 @@
+package dryrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+// Transport passes reads through to Next and turns PocketBase record writes into
+// Plan entries, answering them as PocketBase would so the caller carries on. Planned
+// creates are kept in memory and added to later reads of their collection, so a
+// syncer that looks a record up before creating it plans the create only once.
+type Transport struct {
+	Plan *Plan
+	Next http.RoundTripper
+
+	created     atomic.Int64
+	plannedOnce sync.Once
+	planned     *pbfake.Server
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	collection, id, ok := recordPath(req)
+	if !ok {
+		return t.next().RoundTrip(req)
+	}
+
+	switch {
+	case req.Method == http.MethodGet && id == "":
+		return t.list(req, collection)
+	case req.Method == http.MethodGet && isPlanned(id):
+		return t.servePlanned(req, nil), nil
+	case req.Method == http.MethodPost && id == "":
+		return t.planCreate(req, collection)
+	case req.Method == http.MethodPatch && id != "":
+		return t.planUpdate(req, collection, id)
+	case req.Method == http.MethodDelete && id != "":
+		return t.planDelete(req, collection, id)
+	}
+	return t.next().RoundTrip(req)
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+// store returns the records of planned creates
+func (t *Transport) store() *pbfake.Server {
+	t.plannedOnce.Do(func() { t.planned = pbfake.New() })
+	return t.planned
+}
+
+// servePlanned answers req from the planned records, with body as the submitted fields
+func (t *Transport) servePlanned(req *http.Request, body map[string]any) *http.Response {
+	planReq := req.Clone(req.Context())
+	if body != nil {
+		data, _ := json.Marshal(body)
+		planReq.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	rec := httptest.NewRecorder()
+	t.store().ServeHTTP(rec, planReq)
+	resp := rec.Result()
+	resp.Request = req
+	return resp
+}
+
+// list reads a page of stored records and adds the planned records matching the same
+// filter after the last page
+func (t *Transport) list(req *http.Request, collection string) (*http.Response, error) {
+	resp, err := t.next().RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || len(t.store().Records(collection)) == 0 {
+		return resp, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	page := map[string]any{}
+	if err := json.Unmarshal(data, &page); err != nil {
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		return resp, nil
+	}
+	number, _ := page["page"].(float64)
+	totalPages, _ := page["totalPages"].(float64)
+	if number < totalPages {
+		return jsonResponse(req, resp.StatusCode, page), nil
+	}
+
+	planReq := req.Clone(req.Context())
+	query := planReq.URL.Query()
+	query.Set("page", "1")
+	query.Set("perPage", "1000")
+	planReq.URL.RawQuery = query.Encode()
+	var planned struct {
+		Items []any `json:"items"`
+	}
+	plannedResp := t.servePlanned(planReq, nil)
+	defer plannedResp.Body.Close()
+	if plannedResp.StatusCode != http.StatusOK || json.NewDecoder(plannedResp.Body).Decode(&planned) != nil || len(planned.Items) == 0 {
+		return jsonResponse(req, resp.StatusCode, page), nil
+	}
+
+	items, _ := page["items"].([]any)
+	totalItems, _ := page["totalItems"].(float64)
+	page["items"] = append(items, planned.Items...)
+	page["totalItems"] = int(totalItems) + len(planned.Items)
+	page["totalPages"] = max(int(totalPages), 1)
+	return jsonResponse(req, resp.StatusCode, page), nil
+}
+
+func (t *Transport) planCreate(req *http.Request, collection string) (*http.Response, error) {
+	fields, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Planned IDs are the right length for relation fields and easy to spot
+	id := fmt.Sprintf("dryrun%09d", t.created.Add(1))
+	t.Plan.add(Change{
+		Action:     ActionCreate,
+		Collection: collection,
+		RecordID:   id,
+		Label:      recordLabel(fields),
+		Fields:     fields,
+	})
+
+	record := map[string]any{}
+	for key, value := range fields {
+		record[key] = value
+	}
+	record["id"] = id
+	return t.servePlanned(req, record), nil
+}
+
+func (t *Transport) planUpdate(req *http.Request, collection, id string) (*http.Response, error) {
+	fields, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if isPlanned(id) {
+		// A record created earlier in the run; the fields become part of that create
+		t.Plan.amendCreate(collection, id, fields)
+		return t.servePlanned(req, fields), nil
+	}
+
+	current, err := t.fetch(req, collection, id)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := map[string]FieldDiff{}
+	for key, value := range fields {
+		if !sameValue(current[key], value) {
+			diff[key] = FieldDiff{Old: current[key], New: value}
+		}
+	}
+
+	if len(diff) == 0 {
+		t.Plan.addUnchanged()
+	} else {
+		t.Plan.add(Change{
+			Action:     ActionUpdate,
+			Collection: collection,
+			RecordID:   id,
+			Label:      recordLabel(current),
+			Diff:       diff,
+		})
+	}
+
+	for key, value := range fields {
+		current[key] = value
+	}
+	current["id"] = id
+	return jsonResponse(req, http.StatusOK, current), nil
+}
+
+func (t *Transport) planDelete(req *http.Request, collection, id string) (*http.Response, error) {
+	if isPlanned(id) {
+		t.Plan.add(Change{Action: ActionDelete, Collection: collection, RecordID: id})
+		return t.servePlanned(req, nil), nil
+	}
+
+	current, err := t.fetch(req, collection, id)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Plan.add(Change{
+		Action:     ActionDelete,
+		Collection: collection,
+		RecordID:   id,
+		Label:      recordLabel(current),
+	})
+	return jsonResponse(req, http.StatusNoContent, nil), nil
+}
+
+// fetch reads the stored record a planned update or delete refers to
+func (t *Transport) fetch(orig *http.Request, collection, id string) (map[string]any, error) {
+	req, err := http.NewRequest(http.MethodGet, orig.URL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", orig.Header.Get("Authorization"))
+
+	resp, err := t.next().RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("dry run: read %s/%s: %w", collection, id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return map[string]any{}, nil
+	}
+
+	record := map[string]any{}
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return nil, fmt.Errorf("dry run: decode %s/%s: %w", collection, id, err)
+	}
+	return record, nil
+}
+
+// isPlanned reports whether id was handed out for a planned create
+func isPlanned(id string) bool {
+	return strings.HasPrefix(id, "dryrun")
+}
+
+// recordPath matches /api/collections/{collection}/records[/{id}]
+func recordPath(req *http.Request) (string, string, bool) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "api" || parts[1] != "collections" || parts[3] != "records" {
+		return "", "", false
+	}
+	if len(parts) > 4 {
+		return parts[2], parts[4], true
+	}
+	return parts[2], "", true
+}
+
+func readBody(req *http.Request) (map[string]any, error) {
+	fields := map[string]any{}
+	if req.Body == nil {
+		return fields, nil
+	}
+	defer req.Body.Close()
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("dry run: decode request body: %w", err)
+	}
+	return fields, nil
+}
+
+// sameValue compares a stored value with a submitted one the way PocketBase would
+// store it: nil and empty values match, and times match across formats
+func sameValue(stored, submitted any) bool {
+	if isEmpty(stored) && isEmpty(submitted) {
+		return true
+	}
+
+	// Round-trip through JSON so ints, floats and nested maps compare alike
+	a, errA := normalize(stored)
+	b, errB := normalize(submitted)
+	if errA == nil && errB == nil && reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	sa, okA := stored.(string)
+	sb, okB := submitted.(string)
+	if okA && okB {
+		ta, errA := parseTime(sa)
+		tb, errB := parseTime(sb)
+		return errA == nil && errB == nil && ta.Equal(tb)
+	}
+	return false
+}
+
+func isEmpty(v any) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case []any:
+		return len(value) == 0
+	case []string:
+		return len(value) == 0
+	}
+	return false
+}
+
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.000Z", "2006-01-02 15:04:05Z"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("not a time: %s", s)
+}
+
+func jsonResponse(req *http.Request, status int, body any) *http.Response {
+	rec := httptest.NewRecorder()
+	if body != nil {
+		rec.Header().Set("Content-Type", "application/json")
+		rec.WriteHeader(status)
+		json.NewEncoder(rec).Encode(body)
+	} else {
+		rec.WriteHeader(status)
+	}
+	resp := rec.Result()
+	resp.Request = req
+	return resp
+}
@@ -0,0 +1,110 @@
+package dryrun
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+// planning routes the default transport through a Transport in front of an empty fake
+func planning(t *testing.T) (*pbclient.Client, *Plan, *pbfake.Server) {
+	t.Helper()
+	fake := pbfake.New()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	plan := &Plan{}
+	orig := http.DefaultTransport
+	http.DefaultTransport = &Transport{Plan: plan, Next: orig}
+	t.Cleanup(func() { http.DefaultTransport = orig })
+
+	client := pbclient.NewClient(srv.URL)
+	if err := client.Login("admin@example.com", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	return client, plan, fake
+}
+
+// ensure looks an agency up by api_id and creates it when missing, as the syncers do
+func ensure(t *testing.T, client *pbclient.Client, apiID int, name string) string {
+	t.Helper()
+	existing, err := client.QueryRecords("agencies", fmt.Sprintf("api_id=%d", apiID), "", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(existing) > 0 {
+		return existing[0]["id"].(string)
+	}
+	created, err := client.CreateRecord("agencies", map[string]any{"api_id": apiID, "name": name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return (*created)["id"].(string)
+}
+
+func TestRepeatedCreatePlannedOnce(t *testing.T) {
+	client, plan, fake := planning(t)
+
+	first := ensure(t, client, 121, "SpaceX")
+	second := ensure(t, client, 121, "SpaceX")
+	other := ensure(t, client, 44, "NASA")
+
+	if first != second {
+		t.Errorf("second lookup returned %s, want the planned %s", second, first)
+	}
+	if other == first {
+		t.Errorf("a different agency reused planned ID %s", first)
+	}
+	if creates, updates, deletes := plan.Counts(); creates != 2 || updates != 0 || deletes != 0 {
+		t.Errorf("planned %d creates, %d updates, %d deletes; want 2, 0, 0", creates, updates, deletes)
+	}
+	if n := len(fake.Records("agencies")); n != 0 {
+		t.Errorf("dry run wrote %d agencies", n)
+	}
+
+	found, err := client.FindRecordByField("agencies", "api_id", 121)
+	if err != nil || found == nil || (*found)["id"] != first {
+		t.Errorf("FindRecordByField = %v, %v; want the planned record %s", found, err, first)
+	}
+}
+
+func TestUpdateOfPlannedRecordAmendsCreate(t *testing.T) {
+	client, plan, _ := planning(t)
+
+	id := ensure(t, client, 121, "SpaceX")
+	if err := client.UpdateRecord("agencies", id, map[string]any{"abbrev": "SpX"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if creates, updates, _ := plan.Counts(); creates != 1 || updates != 0 {
+		t.Fatalf("planned %d creates and %d updates, want 1 and 0", creates, updates)
+	}
+	if got := plan.Changes[0].Fields["abbrev"]; got != "SpX" {
+		t.Errorf("planned create abbrev = %v, want SpX", got)
+	}
+}
+
+func TestListAddsPlannedRecordsAfterStored(t *testing.T) {
+	client, _, fake := planning(t)
+
+	// Stored before the dry run, so written to the fake directly
+	stored := httptest.NewRequest(http.MethodPost, "/api/collections/agencies/records", strings.NewReader(`{"api_id":44,"name":"NASA"}`))
+	fake.ServeHTTP(httptest.NewRecorder(), stored)
+
+	ensure(t, client, 121, "SpaceX")
+	all, err := client.ListAllRecords("agencies", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0]["name"] != "NASA" || all[1]["name"] != "SpaceX" {
+		t.Errorf("ListAllRecords = %v, want NASA then the planned SpaceX", all)
+	}
+	if ensure(t, client, 44, "NASA") != all[0]["id"] {
+		t.Errorf("lookup of a stored agency didn't return it")
+	}
+}
@@ -0,0 +1,51 @@
+package fixtures
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+// Options holds the -record and -replay command line flags shared by the syncers
+type Options struct {
+	RecordDir string
+	ReplayDir string
+
+	pocketbase *pbfake.Server
+}
+
+// Flags registers -record and -replay on the default flag set. Call flag.Parse and
+// then Install before running a syncer.
+func Flags() *Options {
+	o := &Options{}
+	flag.StringVar(&o.RecordDir, "record", "", "Record SpaceDevs responses as fixtures into this directory")
+	flag.StringVar(&o.ReplayDir, "replay", "", "Replay SpaceDevs fixtures from this directory against an in-memory PocketBase")
+	return o
+}
+
+// Install routes the default HTTP transport through a recording or replaying
+// Transport. Without either flag it does nothing.
+func (o *Options) Install() error {
+	switch {
+	case o.RecordDir != "" && o.ReplayDir != "":
+		return fmt.Errorf("-record and -replay can't be used together")
+	case o.RecordDir != "":
+		http.DefaultTransport = &Transport{Mode: Record, Dir: o.RecordDir, Next: http.DefaultTransport}
+		log.Printf("📼 Recording SpaceDevs responses into %s", o.RecordDir)
+	case o.ReplayDir != "":
+		o.pocketbase = pbfake.New()
+		http.DefaultTransport = &Transport{Mode: Replay, Dir: o.ReplayDir, PocketBase: o.pocketbase, Next: http.DefaultTransport}
+		log.Printf("📼 Replaying SpaceDevs fixtures from %s against an in-memory PocketBase", o.ReplayDir)
+	}
+	return nil
+}
+
+// Report logs what the in-memory PocketBase holds after a replay
+func (o *Options) Report() {
+	if o.pocketbase != nil {
+		log.Printf("📼 In-memory PocketBase: %s", o.pocketbase)
+	}
+}
@@ -0,0 +1,212 @@
+// Package fixtures records SpaceDevs API responses to disk and replays them, so the
+// syncers can run end-to-end without network access or rate limits.
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Version is written into every fixture file. Bump it when the file layout changes;
+// replaying a fixture with another version fails instead of silently misreading it.
+const Version = 1
+
+// Fixture is one recorded HTTP exchange
+type Fixture struct {
+	Version int               `json:"version"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Status  int               `json:"status"`
+	Header  map[string]string `json:"header"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// Mode selects what the transport does with SpaceDevs requests
+type Mode int
+
+const (
+	// Live passes every request through untouched
+	Live Mode = iota
+	// Record performs SpaceDevs requests and saves each response as a fixture
+	Record
+	// Replay answers SpaceDevs requests from fixtures and never reaches the network
+	Replay
+)
+
+// Transport intercepts requests to the SpaceDevs API according to Mode. When
+// PocketBase is set, every other request is served by that handler in-process,
+// whatever host it was addressed to.
+type Transport struct {
+	Mode       Mode
+	Dir        string
+	PocketBase http.Handler
+	Next       http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isSpaceDevs(req.URL.Host) {
+		switch t.Mode {
+		case Record:
+			return t.record(req)
+		case Replay:
+			return t.replay(req)
+		}
+	} else if t.PocketBase != nil {
+		return serveLocal(t.PocketBase, req), nil
+	}
+	return t.next().RoundTrip(req)
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.next().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := Fixture{
+		Version: Version,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Status:  resp.StatusCode,
+		Header:  map[string]string{"Content-Type": resp.Header.Get("Content-Type")},
+		Body:    body,
+	}
+	// Non-JSON bodies (e.g. HTML error pages) are stored as a JSON string
+	if !json.Valid(body) {
+		fixture.Body, _ = json.Marshal(string(body))
+	}
+
+	if err := writeFixture(t.Dir, fixture); err != nil {
+		return nil, fmt.Errorf("record fixture for %s: %w", req.URL, err)
+	}
+	return resp, nil
+}
+
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	fixture, err := findFixture(t.Dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	for key, value := range fixture.Header {
+		header.Set(key, value)
+	}
+
+	body := []byte(fixture.Body)
+	var text string
+	if json.Unmarshal(fixture.Body, &text) == nil {
+		body = []byte(text)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// serveLocal answers a request with an in-process handler
+func serveLocal(handler http.Handler, req *http.Request) *http.Response {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp
+}
+
+func isSpaceDevs(host string) bool {
+	return host == "thespacedevs.com" || strings.HasSuffix(host, ".thespacedevs.com")
+}
+
+// fixturePath names a fixture after its request path plus a hash of the full URL,
+// e.g. 2.3.0_launches_upcoming_3f2a9c1b.json, so files group by endpoint
+func fixturePath(dir, method, rawURL, path string) string {
+	sum := sha256.Sum256([]byte(method + " " + rawURL))
+	return filepath.Join(dir, endpointName(path)+"_"+hex.EncodeToString(sum[:4])+".json")
+}
+
+func endpointName(path string) string {
+	name := strings.Trim(path, "/")
+	name = strings.ReplaceAll(name, "/", "_")
+	if name == "" {
+		name = "root"
+	}
+	return name
+}
+
+func writeFixture(dir string, fixture Fixture) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(fixture.Method, fixture.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fixturePath(dir, fixture.Method, fixture.URL, req.URL.Path), data, 0o644)
+}
+
+// findFixture loads the fixture recorded for exactly this request. Requests whose
+// query changes between runs (e.g. net__lte=<now+7d>) fall back to the one fixture
+// recorded for the same endpoint.
+func findFixture(dir string, req *http.Request) (Fixture, error) {
+	path := fixturePath(dir, req.Method, req.URL.String(), req.URL.Path)
+	if _, err := os.Stat(path); err != nil {
+		matches, _ := filepath.Glob(filepath.Join(dir, endpointName(req.URL.Path)+"_*.json"))
+		sort.Strings(matches)
+		if len(matches) != 1 {
+			return Fixture{}, fmt.Errorf("no fixture recorded for %s %s in %s", req.Method, req.URL, dir)
+		}
+		path = matches[0]
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("parse fixture %s: %w", path, err)
+	}
+	if fixture.Version != Version {
+		return Fixture{}, fmt.Errorf("fixture %s has version %d, expected %d; re-record it", path, fixture.Version, Version)
+	}
+	return fixture, nil
+}
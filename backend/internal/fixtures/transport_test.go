@@ -0,0 +1,68 @@
+package fixtures
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func get(t *testing.T, rt http.RoundTripper, url string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	const url = "https://ll.thespacedevs.com/2.3.0/agencies/?limit=100"
+
+	upstream := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		rec.WriteString(`{"results":[{"id":44,"name":"NASA"}]}`)
+		return rec.Result(), nil
+	})
+	recorder := &Transport{Mode: Record, Dir: dir, Next: upstream}
+	if status, body := get(t, recorder, url); status != http.StatusOK || !strings.Contains(body, "NASA") {
+		t.Fatalf("recording returned %d %s", status, body)
+	}
+
+	offline := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("replay reached the network for %s", req.URL)
+		return nil, nil
+	})
+	replayer := &Transport{Mode: Replay, Dir: dir, Next: offline}
+	if status, body := get(t, replayer, url); status != http.StatusOK || !strings.Contains(body, `"NASA"`) {
+		t.Errorf("replay returned %d %s", status, body)
+	}
+
+	// A changed query falls back to the one fixture recorded for the endpoint
+	if status, _ := get(t, replayer, url+"&offset=100"); status != http.StatusOK {
+		t.Errorf("fallback replay returned %d", status)
+	}
+	if _, err := replayer.RoundTrip(httptest.NewRequest(http.MethodGet, "https://ll.thespacedevs.com/2.3.0/programs/", nil)); err == nil {
+		t.Error("replaying an unrecorded endpoint succeeded")
+	}
+}
+
+func TestReplayServesPocketBaseLocally(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})
+	replayer := &Transport{Mode: Replay, Dir: t.TempDir(), PocketBase: handler}
+	if _, body := get(t, replayer, "http://127.0.0.1:8080/api/collections/agencies/records"); body != "/api/collections/agencies/records" {
+		t.Errorf("PocketBase request answered with %q", body)
+	}
+}
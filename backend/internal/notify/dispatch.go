@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoRecipient is returned by a channel when the user has nowhere to receive it,
+// such as no registered devices. It doesn't count as a failed delivery.
+var ErrNoRecipient = errors.New("no recipient on this channel")
+
+// permanentError marks a delivery error that retrying can't fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the outbox gives up on the delivery straight away instead of
+// retrying it, e.g. for a payload the channel can never send
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Channel delivers a job to a user over one medium (APNs, Web Push, ...)
+type Channel interface {
+	Name() string
+	Deliver(job Job) error
+}
+
+// JobFromRecord reads a notification_jobs record
+func JobFromRecord(record map[string]interface{}) Job {
+	job := Job{}
+	job.ID, _ = record["id"].(string)
+	job.Key, _ = record["dedupe_key"].(string)
+	job.User, _ = record["user"].(string)
+	job.Event, _ = record["event"].(string)
+	job.Subscription, _ = record["subscription"].(string)
+	job.Trigger, _ = record["trigger"].(string)
+	job.Detail, _ = record["detail"].(map[string]interface{})
+	return job
+}
+
+// Title is the event title captured when the job was queued
+func (j Job) Title() string {
+	title, _ := j.Detail["title"].(string)
+	return title
+}
+
+// Thread groups the pushes about the same thing, so a newer one can replace an older
+// one: every alert for an event shares a thread, as do the passes of a station
+func (j Job) Thread() string {
+	if j.Event == "" && j.Trigger == TriggerVisiblePass {
+		return fmt.Sprintf("pass-%v", j.Detail["norad_id"])
+	}
+	return "event-" + j.Event
+}
+
+// TimeSensitive reports whether the job is worth interrupting the user for, as it is
+// about something minutes away
+func (j Job) TimeSensitive() bool {
+	return j.Trigger == TriggerT10m || j.Trigger == TriggerWebcastLive || j.Trigger == TriggerVisiblePass
+}
@@ -0,0 +1,376 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// Job statuses. A pending job becomes queued once the outbox holds a delivery for it
+// on every channel; the outbox tracks it from there. Jobs the user's preferences rule
+// out are suppressed instead.
+const (
+	JobPending    = "pending"
+	JobQueued     = "queued"
+	JobSuppressed = "suppressed"
+)
+
+// Job is a notification owed to one user for one event and trigger
+type Job struct {
+	ID           string
+	Key          string
+	User         string
+	Event        string
+	Subscription string
+	Trigger      string
+	Detail       map[string]any
+	// Text is the wording rendered for the delivery being made, if any
+	Text *templates.Text
+}
+
+// ChangeListener is told about every change the engine detects, whether or not any
+// user subscribed to the event
+type ChangeListener interface {
+	OnChange(event Event, change Change) error
+}
+
+// Engine evaluates subscriptions against upcoming and recent events
+type Engine struct {
+	Client    *pbclient.Client
+	Listeners []ChangeListener
+	// Lookahead is how far ahead events are evaluated. Status changes on launches
+	// further out are picked up once they come into range.
+	Lookahead time.Duration
+	// Lookback keeps launches in range after NET so their outcome is seen
+	Lookback time.Duration
+	Now      func() time.Time
+}
+
+// NewEngine returns an engine with the default evaluation window
+func NewEngine(client *pbclient.Client) *Engine {
+	return &Engine{
+		Client:    client,
+		Lookahead: 14 * 24 * time.Hour,
+		Lookback:  2 * 24 * time.Hour,
+		Now:       time.Now,
+	}
+}
+
+// Evaluate runs one pass over the events in range and returns the jobs it created.
+// Jobs and listener notifications are written before the event state that triggered
+// them, so a pass cut short is simply repeated next time and the dedupe keys drop
+// what was already created. An event whose jobs couldn't all be written keeps its
+// state and revisions for the same reason.
+func (en *Engine) Evaluate() ([]Job, error) {
+	now := en.Now()
+
+	subscriptions, err := LoadSubscriptions(en.Client)
+	if err != nil {
+		return nil, err
+	}
+	events, err := en.loadEvents(now)
+	if err != nil {
+		return nil, err
+	}
+	states, err := en.loadStates()
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := en.loadRevisions()
+	if err != nil {
+		return nil, err
+	}
+
+	var created []Job
+	failed := map[string]bool{}
+	for _, event := range events {
+		var prev *EventState
+		if state, ok := states[event.ID]; ok {
+			prev = &state
+		}
+
+		firings := DueTriggers(event, prev, now)
+		for _, revision := range revisions[event.ID] {
+			firings = append(firings, RevisionFiring(revision))
+		}
+
+		// A user following both the provider and the rocket still gets one job
+		seen := map[string]bool{}
+		for _, firing := range firings {
+			for _, sub := range subscriptions {
+				if !sub.Matches(event) || !sub.Wants(firing.Trigger) {
+					continue
+				}
+				key := firing.Key(sub.User, event.ID)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				job := Job{
+					Key:          key,
+					User:         sub.User,
+					Event:        event.ID,
+					Subscription: sub.ID,
+					Trigger:      firing.Trigger,
+					Detail:       withTitle(firing.Detail, event.Title),
+				}
+				isNew, err := createJob(en.Client, &job)
+				if err != nil {
+					log.Printf("❌ Failed to queue %s for %s: %v", firing.Trigger, event.Title, err)
+					failed[event.ID] = true
+					continue
+				}
+				if isNew {
+					log.Printf("🔔 Queued %s for %s", firing.Trigger, event.Title)
+					created = append(created, job)
+				}
+			}
+		}
+
+		for _, change := range DetectChanges(event, prev, now) {
+			for _, listener := range en.Listeners {
+				if err := listener.OnChange(event, change); err != nil {
+					log.Printf("❌ Failed to announce %s for %s: %v", change.Kind, event.Title, err)
+				}
+			}
+		}
+
+		if failed[event.ID] {
+			log.Printf("⏭️ Keeping notification state for %s until its jobs are queued", event.Title)
+			continue
+		}
+		if err := en.saveState(event, prev); err != nil {
+			log.Printf("❌ Failed to save notification state for %s: %v", event.Title, err)
+		}
+	}
+
+	// Revisions of launches outside the window are marked too, so they don't pile up
+	for eventID, eventRevisions := range revisions {
+		if failed[eventID] {
+			continue
+		}
+		for _, revision := range eventRevisions {
+			if err := en.Client.UpdateRecord("event_revisions", revision.ID, map[string]interface{}{"notified": true}); err != nil {
+				log.Printf("❌ Failed to mark revision %s notified: %v", revision.ID, err)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// createJob stores the job unless one with the same key exists. It reports whether
+// the job is new.
+func createJob(client *pbclient.Client, job *Job) (bool, error) {
+	existing, err := client.QueryRecords("notification_jobs", fmt.Sprintf(`dedupe_key="%s"`, job.Key), "", "", 1)
+	if err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+
+	created, err := client.CreateRecord("notification_jobs", map[string]interface{}{
+		"dedupe_key":   job.Key,
+		"user":         job.User,
+		"event":        job.Event,
+		"subscription": job.Subscription,
+		"trigger":      job.Trigger,
+		"detail":       job.Detail,
+		"status":       JobPending,
+	})
+	if err != nil {
+		return false, err
+	}
+	id, _ := (*created)["id"].(string)
+	if id == "" {
+		// The unique index on dedupe_key rejects a job another engine just created
+		return false, fmt.Errorf("create rejected: %v", (*created)["message"])
+	}
+	job.ID = id
+	return true, nil
+}
+
+func (en *Engine) saveState(event Event, prev *EventState) error {
+	data := map[string]interface{}{
+		"event":        event.ID,
+		"status":       event.Status,
+		"webcast_live": event.WebcastLive,
+		"net":          formatTime(event.Net),
+	}
+	if prev == nil {
+		_, err := en.Client.CreateRecord("notification_event_states", data)
+		return err
+	}
+	if prev.Status == event.Status && prev.WebcastLive == event.WebcastLive && prev.Net.Equal(event.Net) {
+		return nil
+	}
+	if statusChanged(event, prev) {
+		data["status_changes"] = prev.StatusChanges + 1
+	}
+	return en.Client.UpdateRecord("notification_event_states", prev.RecordID, data)
+}
+
+// LoadSubscriptions returns every enabled subscription
+func LoadSubscriptions(client *pbclient.Client) ([]Subscription, error) {
+	records, err := client.ListAllRecords("notification_subscriptions", "enabled=true", "")
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+
+	subscriptions := make([]Subscription, 0, len(records))
+	for _, record := range records {
+		sub := Subscription{}
+		sub.ID, _ = record["id"].(string)
+		sub.User, _ = record["user"].(string)
+		sub.TargetType, _ = record["target_type"].(string)
+		sub.Target, _ = record[targetField(sub.TargetType)].(string)
+		sub.Triggers = stringList(record["triggers"])
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, nil
+}
+
+func (en *Engine) loadEvents(now time.Time) ([]Event, error) {
+	// Newly stored launches are included wherever their NET is, so they can be announced
+	filter := fmt.Sprintf(`(datetime>="%s" && datetime<="%s") || created>="%s"`,
+		formatTime(now.Add(-en.Lookback)), formatTime(now.Add(en.Lookahead)), formatTime(now.Add(-createdWindow)))
+	records, err := en.Client.ListAllRecords("events", filter, "")
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	events := make([]Event, 0, len(records))
+	for _, record := range records {
+		events = append(events, EventFromRecord(record))
+	}
+	return events, nil
+}
+
+// loadRevisions returns the schedule changes not yet notified, by event
+func (en *Engine) loadRevisions() (map[string][]Revision, error) {
+	records, err := en.Client.ListAllRecords("event_revisions", "notified=false", "")
+	if err != nil {
+		return nil, fmt.Errorf("list event revisions: %w", err)
+	}
+
+	revisions := map[string][]Revision{}
+	for _, record := range records {
+		r := Revision{Detail: map[string]any{}}
+		r.ID, _ = record["id"].(string)
+		r.Event, _ = record["event"].(string)
+		r.Kind, _ = record["kind"].(string)
+		for _, field := range []string{"net_from", "net_to", "delta_seconds", "status_from", "status_to", "window_start_to", "window_end_to"} {
+			if v, ok := record[field]; ok && v != "" {
+				r.Detail[field] = v
+			}
+		}
+		revisions[r.Event] = append(revisions[r.Event], r)
+	}
+	return revisions, nil
+}
+
+func (en *Engine) loadStates() (map[string]EventState, error) {
+	records, err := en.Client.ListAllRecords("notification_event_states", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("list notification states: %w", err)
+	}
+
+	states := make(map[string]EventState, len(records))
+	for _, record := range records {
+		state := EventState{}
+		state.RecordID, _ = record["id"].(string)
+		state.Event, _ = record["event"].(string)
+		state.Status, _ = record["status"].(string)
+		state.WebcastLive, _ = record["webcast_live"].(bool)
+		state.Net, _ = parseTime(record["net"])
+		if n, ok := record["status_changes"].(float64); ok {
+			state.StatusChanges = int(n)
+		}
+		states[state.Event] = state
+	}
+	return states, nil
+}
+
+// EventFromRecord reads the fields the rules use from an events record
+func EventFromRecord(record map[string]interface{}) Event {
+	e := Event{}
+	e.ID, _ = record["id"].(string)
+	e.Title, _ = record["title"].(string)
+	e.Type, _ = record["type"].(string)
+	e.Net, _ = parseTime(record["datetime"])
+	e.Status, _ = record["status_abbrev"].(string)
+	e.WebcastLive, _ = record["webcast_live"].(bool)
+	e.Provider, _ = record["provider"].(string)
+	if provider, ok := expanded(record, "provider"); ok {
+		e.ProviderName, _ = provider["name"].(string)
+	}
+	e.Pad, _ = record["pad_id"].(string)
+	e.Rocket, _ = record["rocket_id"].(string)
+	e.Programs = stringList(record["programs"])
+	e.Location, _ = record["location"].(string)
+	e.Created, _ = parseTime(record["created"])
+	if videos, ok := record["vid_urls"].([]interface{}); ok && len(videos) > 0 {
+		if video, ok := videos[0].(map[string]interface{}); ok {
+			e.WebcastURL, _ = video["url"].(string)
+		}
+	}
+	return e
+}
+
+// targetField is the relation field holding the followed record for a target type
+func targetField(targetType string) string {
+	if targetType == TargetLaunch {
+		return "event"
+	}
+	return targetType
+}
+
+func withTitle(detail map[string]any, title string) map[string]any {
+	out := map[string]any{"title": title}
+	for k, v := range detail {
+		out[k] = v
+	}
+	return out
+}
+
+func stringList(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []interface{}:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// parseTime parses the RFC 3339 and PocketBase datetime formats stored in records
+func parseTime(v interface{}) (time.Time, error) {
+	s, _ := v.(string)
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.000Z", "2006-01-02 15:04:05Z"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05.000Z")
+}
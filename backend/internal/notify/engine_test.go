@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+// A job that can't be written leaves the event's state and revisions alone, so the
+// next pass queues it
+func TestEvaluateRetriesFailedJobs(t *testing.T) {
+	fake := pbfake.New()
+	rejectJobs := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rejectJobs && r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/notification_jobs/") {
+			http.Error(w, `{"message":"unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	client := pbclient.NewClient(srv.URL)
+
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	net := now.Add(72 * time.Hour)
+	event, err := client.CreateRecord("events", map[string]interface{}{
+		"title":         "Crew-10",
+		"datetime":      formatTime(net),
+		"status_abbrev": "Hold",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventID := (*event)["id"].(string)
+	for collection, record := range map[string]map[string]interface{}{
+		"notification_event_states": {"event": eventID, "status": "Go", "net": formatTime(net)},
+		"notification_subscriptions": {
+			"user":        "u1",
+			"enabled":     true,
+			"target_type": TargetLaunch,
+			"event":       eventID,
+			"triggers":    []string{TriggerStatusChange, TriggerSchedule},
+		},
+		"event_revisions": {"event": eventID, "kind": "net_changed", "notified": false},
+	} {
+		if _, err := client.CreateRecord(collection, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	en := &Engine{Client: client, Lookahead: 14 * 24 * time.Hour, Now: func() time.Time { return now }}
+	if _, err := en.Evaluate(); err != nil {
+		t.Fatal(err)
+	}
+	if state := fake.Records("notification_event_states")[0]; state["status"] != "Go" {
+		t.Errorf("state status = %v after failed jobs, want Go", state["status"])
+	}
+	if revision := fake.Records("event_revisions")[0]; revision["notified"] != false {
+		t.Error("revision marked notified after its job failed")
+	}
+
+	rejectJobs = false
+	created, err := en.Evaluate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 {
+		t.Errorf("queued %d jobs on retry, want the status change and the revision", len(created))
+	}
+	state := fake.Records("notification_event_states")[0]
+	if state["status"] != "Hold" || state["status_changes"] != float64(1) {
+		t.Errorf("state = %v, want Hold after one status change", state)
+	}
+	if revision := fake.Records("event_revisions")[0]; revision["notified"] != true {
+		t.Error("revision not marked notified after its job was queued")
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"log"
+
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// Message returns the title and body shown for a job: the text the outbox rendered for
+// its delivery when there is one, otherwise the built in English wording
+func (j Job) Message() (string, string) {
+	if j.Text != nil {
+		return j.Text.Title, j.Text.Body
+	}
+	text, err := RenderJob(templates.Default, j, templates.DefaultChannel, Event{}, templates.Context{})
+	if err != nil {
+		log.Printf("❌ Failed to render %s: %v", j.Key, err)
+		return j.Title(), ""
+	}
+	return text.Title, text.Body
+}
+
+// RenderJob renders a job's wording for a channel. The event fills in what the job's
+// detail doesn't hold, like the provider and the current NET; a zero Event renders
+// from the detail alone.
+func RenderJob(set *templates.Set, j Job, channel string, event Event, ctx templates.Context) (templates.Text, error) {
+	data := templates.Notification{
+		Trigger: j.Trigger,
+		Detail:  j.Detail,
+		Event:   templates.Event{ID: j.Event, Title: j.Title()},
+	}
+	if event.ID != "" {
+		if event.Title != "" {
+			data.Event.Title = event.Title
+		}
+		data.Event.Type = event.Type
+		data.Event.Net = event.Net
+		data.Event.Status = event.Status
+		data.Event.Provider = event.Provider
+		if event.ProviderName != "" {
+			data.Event.Provider = event.ProviderName
+		}
+		data.Event.Location = event.Location
+		data.Event.WebcastURL = event.WebcastURL
+	}
+	if data.Event.Net.IsZero() {
+		data.Event.Net, _ = parseTime(j.Detail["net"])
+	}
+	return set.Render(channel, j.Trigger, ctx, data)
+}
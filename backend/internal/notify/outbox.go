@@ -0,0 +1,422 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// Outbox delivery statuses. A delivery is queued, claimed as sending just before the
+// channel is called, then ends delivered, or failed to wait for a retry, or dead once
+// its retries are used up. Skipped means the user has nothing registered on the channel.
+const (
+	OutboxQueued    = "queued"
+	OutboxSending   = "sending"
+	OutboxDelivered = "delivered"
+	OutboxFailed    = "failed"
+	OutboxDead      = "dead"
+	OutboxSkipped   = "skipped"
+)
+
+// RetryPolicy says how often and how far apart a channel retries a delivery
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Delay is the wait after the given failed attempt, doubling from BaseDelay
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// DefaultRetryPolicy applies to channels without their own policy
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, BaseDelay: time.Minute, MaxDelay: 30 * time.Minute}
+
+// DefaultRetryPolicies are tuned per channel. Pushes are only useful close to the
+// moment they describe, so they give up within about half an hour.
+var DefaultRetryPolicies = map[string]RetryPolicy{
+	"apns":    {MaxAttempts: 5, BaseDelay: 30 * time.Second, MaxDelay: 10 * time.Minute},
+	"webpush": {MaxAttempts: 5, BaseDelay: 30 * time.Second, MaxDelay: 10 * time.Minute},
+}
+
+// OutboxEntry is one job's delivery over one channel
+type OutboxEntry struct {
+	ID             string
+	IdempotencyKey string
+	Job            string
+	User           string
+	Channel        string
+	Status         string
+	Attempts       int
+	NextAttempt    time.Time
+	ClaimedAt      time.Time
+	LastError      string
+	DeliveredAt    time.Time
+	Created        time.Time
+}
+
+// Outbox fans pending jobs out into notification_outbox, one delivery per channel, and
+// sends them. Every state change is written before the next step runs, so a crash at
+// any point is picked up by the next pass: jobs already fanned out are skipped by the
+// idempotency key, and deliveries left in sending are retried once SendingTimeout has
+// passed. A delivery interrupted mid-send may therefore go out twice, but none is lost.
+type Outbox struct {
+	Client   *pbclient.Client
+	Channels []Channel
+	Policies map[string]RetryPolicy
+	// SendingTimeout is how long a delivery may stay in sending before it is assumed
+	// to have been interrupted
+	SendingTimeout time.Duration
+	// Templates word each delivery in the user's language and timezone
+	Templates *templates.Set
+	Now       func() time.Time
+}
+
+// NewOutbox returns an outbox delivering over channels with the default retry policies
+func NewOutbox(client *pbclient.Client, channels []Channel) *Outbox {
+	return &Outbox{
+		Client:         client,
+		Channels:       channels,
+		Policies:       DefaultRetryPolicies,
+		SendingTimeout: 5 * time.Minute,
+		Templates:      templates.Default,
+		Now:            time.Now,
+	}
+}
+
+// Policy returns the retry policy for a channel
+func (o *Outbox) Policy(channel string) RetryPolicy {
+	if p, ok := o.Policies[channel]; ok {
+		return p
+	}
+	return DefaultRetryPolicy
+}
+
+// IdempotencyKey identifies the delivery of a job over a channel
+func IdempotencyKey(job Job, channel string) string {
+	return job.Key + ":" + channel
+}
+
+// Enqueue fans up to limit pending jobs out into a queued delivery per channel and
+// marks them queued. Each user's preferences pick the channels and hold deliveries
+// back until quiet hours end; jobs they rule out are marked suppressed. It returns how
+// many jobs were queued.
+func (o *Outbox) Enqueue(limit int) (int, error) {
+	if len(o.Channels) == 0 {
+		return 0, nil
+	}
+	records, err := o.Client.QueryRecords("notification_jobs", fmt.Sprintf(`status="%s"`, JobPending), "created", "event", limit)
+	if err != nil {
+		return 0, fmt.Errorf("list pending jobs: %w", err)
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	prefs, err := LoadPreferences(o.Client)
+	if err != nil {
+		return 0, err
+	}
+
+	now := o.Now()
+	queued := 0
+	for _, record := range records {
+		job := JobFromRecord(record)
+		event := Event{ID: job.Event}
+		if eventRecord, ok := expanded(record, "event"); ok {
+			event = EventFromRecord(eventRecord)
+		}
+		if event.Net.IsZero() {
+			// Jobs without an event, like visible passes, carry their moment in the detail
+			event.Net, _ = parseTime(job.Detail["net"])
+		}
+		pref, ok := prefs[job.User]
+		if !ok {
+			pref = DefaultPreferences(job.User)
+		}
+
+		plan := pref.Plan(job, event, now)
+		if plan.Suppressed != "" {
+			log.Printf("🔕 Not delivering %s: %s", job.Key, plan.Suppressed)
+			if err := o.Client.UpdateRecord("notification_jobs", job.ID, map[string]interface{}{
+				"status":            JobSuppressed,
+				"suppressed_reason": plan.Suppressed,
+			}); err != nil {
+				log.Printf("❌ Failed to mark job %s suppressed: %v", job.Key, err)
+			}
+			continue
+		}
+		if plan.DeliverAt.After(now) {
+			log.Printf("🌙 Holding %s until %s for quiet hours", job.Key, plan.DeliverAt.UTC().Format(time.RFC3339))
+		}
+
+		ok = true
+		for _, channel := range o.Channels {
+			if !plan.Allows(channel.Name()) {
+				continue
+			}
+			if err := o.add(job, channel.Name(), plan.DeliverAt); err != nil {
+				log.Printf("❌ Failed to queue %s over %s: %v", job.Key, channel.Name(), err)
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := o.Client.UpdateRecord("notification_jobs", job.ID, map[string]interface{}{"status": JobQueued}); err != nil {
+			log.Printf("❌ Failed to mark job %s queued: %v", job.Key, err)
+			continue
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// add stores a queued delivery, due at deliverAt, unless one with the same idempotency
+// key exists
+func (o *Outbox) add(job Job, channel string, deliverAt time.Time) error {
+	key := IdempotencyKey(job, channel)
+	existing, err := o.Client.QueryRecords("notification_outbox", fmt.Sprintf(`idempotency_key="%s"`, key), "", "", 1)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+
+	created, err := o.Client.CreateRecord("notification_outbox", map[string]interface{}{
+		"idempotency_key": key,
+		"job":             job.ID,
+		"user":            job.User,
+		"channel":         channel,
+		"status":          OutboxQueued,
+		"attempts":        0,
+		"next_attempt":    formatTime(deliverAt),
+	})
+	if err != nil {
+		return err
+	}
+	if id, _ := (*created)["id"].(string); id == "" {
+		return fmt.Errorf("create rejected: %v", (*created)["message"])
+	}
+	return nil
+}
+
+// DeliverDue sends up to limit deliveries that are queued or due a retry, oldest first,
+// and returns how many were delivered
+func (o *Outbox) DeliverDue(limit int) (int, error) {
+	o.recoverInterrupted()
+
+	now := o.Now()
+	filter := fmt.Sprintf(`(status="%s" || status="%s") && next_attempt<="%s"`, OutboxQueued, OutboxFailed, formatTime(now))
+	records, err := o.Client.QueryRecords("notification_outbox", filter, "next_attempt", "job", limit)
+	if err != nil {
+		return 0, fmt.Errorf("list due deliveries: %w", err)
+	}
+
+	channels := make(map[string]Channel, len(o.Channels))
+	for _, channel := range o.Channels {
+		channels[channel.Name()] = channel
+	}
+	var prefs map[string]Preferences
+	if len(records) > 0 {
+		if prefs, err = LoadPreferences(o.Client); err != nil {
+			return 0, err
+		}
+	}
+	events := map[string]Event{}
+
+	delivered := 0
+	for _, record := range records {
+		entry := OutboxEntryFromRecord(record)
+		channel, ok := channels[entry.Channel]
+		if !ok {
+			// Left for when the channel is configured again
+			continue
+		}
+		jobRecord, ok := expanded(record, "job")
+		if !ok {
+			o.update(entry, map[string]interface{}{"status": OutboxDead, "last_error": "job deleted"})
+			continue
+		}
+
+		// Claim the delivery before sending, so a crash from here on leaves it in sending
+		entry.Attempts++
+		if err := o.update(entry, map[string]interface{}{
+			"status":     OutboxSending,
+			"attempts":   entry.Attempts,
+			"claimed_at": formatTime(o.Now()),
+		}); err != nil {
+			continue
+		}
+
+		job := JobFromRecord(jobRecord)
+		if text, err := o.render(job, entry.Channel, prefs, events); err == nil {
+			job.Text = &text
+		} else {
+			log.Printf("⚠️ Using the default wording for %s: %v", entry.IdempotencyKey, err)
+		}
+
+		err := channel.Deliver(job)
+		switch {
+		case err == nil:
+			delivered++
+			o.update(entry, map[string]interface{}{
+				"status":       OutboxDelivered,
+				"last_error":   "",
+				"delivered_at": formatTime(o.Now()),
+			})
+		case errors.Is(err, ErrNoRecipient):
+			o.update(entry, map[string]interface{}{"status": OutboxSkipped, "last_error": err.Error()})
+		default:
+			log.Printf("❌ %s delivery of %s failed (attempt %d): %v", entry.Channel, entry.IdempotencyKey, entry.Attempts, err)
+			o.fail(entry, err.Error(), IsPermanent(err))
+		}
+	}
+	return delivered, nil
+}
+
+// render words a job for a channel in the user's language and timezone, as of now.
+// Events are looked up once per pass and kept in events.
+func (o *Outbox) render(job Job, channel string, prefs map[string]Preferences, events map[string]Event) (templates.Text, error) {
+	pref, ok := prefs[job.User]
+	if !ok {
+		pref = DefaultPreferences(job.User)
+	}
+
+	event, ok := events[job.Event]
+	if !ok && job.Event != "" {
+		records, err := o.Client.QueryRecords("events", fmt.Sprintf(`id="%s"`, job.Event), "", "provider", 1)
+		if err != nil {
+			return templates.Text{}, err
+		}
+		if len(records) > 0 {
+			event = EventFromRecord(records[0])
+		}
+		events[job.Event] = event
+	}
+
+	set := o.Templates
+	if set == nil {
+		set = templates.Default
+	}
+	return RenderJob(set, job, channel, event, pref.Context(o.Now()))
+}
+
+// recoverInterrupted returns deliveries stuck in sending, from a worker that stopped
+// mid-send, to the retry schedule
+func (o *Outbox) recoverInterrupted() {
+	cutoff := o.Now().Add(-o.SendingTimeout)
+	filter := fmt.Sprintf(`status="%s" && claimed_at<="%s"`, OutboxSending, formatTime(cutoff))
+	records, err := o.Client.ListAllRecords("notification_outbox", filter, "")
+	if err != nil {
+		log.Printf("❌ Failed to list interrupted deliveries: %v", err)
+		return
+	}
+	for _, record := range records {
+		entry := OutboxEntryFromRecord(record)
+		log.Printf("⚠️ Delivery %s was interrupted while sending", entry.IdempotencyKey)
+		o.fail(entry, "interrupted while sending", false)
+	}
+}
+
+// fail schedules the next attempt, or marks the delivery dead when it can't be retried
+func (o *Outbox) fail(entry OutboxEntry, reason string, permanent bool) {
+	policy := o.Policy(entry.Channel)
+	if permanent || entry.Attempts >= policy.MaxAttempts {
+		o.update(entry, map[string]interface{}{"status": OutboxDead, "last_error": reason})
+		return
+	}
+	o.update(entry, map[string]interface{}{
+		"status":       OutboxFailed,
+		"last_error":   reason,
+		"next_attempt": formatTime(o.Now().Add(policy.Delay(entry.Attempts))),
+	})
+}
+
+func (o *Outbox) update(entry OutboxEntry, data map[string]interface{}) error {
+	err := o.Client.UpdateRecord("notification_outbox", entry.ID, data)
+	if err != nil {
+		log.Printf("❌ Failed to update delivery %s: %v", entry.IdempotencyKey, err)
+	}
+	return err
+}
+
+// ListOutbox returns up to limit deliveries, newest first, optionally only those with
+// one of the given statuses. A limit of 0 returns every match, unordered.
+func ListOutbox(client *pbclient.Client, limit int, statuses ...string) ([]OutboxEntry, error) {
+	filter := ""
+	for i, status := range statuses {
+		if i > 0 {
+			filter += " || "
+		}
+		filter += fmt.Sprintf(`status="%s"`, status)
+	}
+	var records []map[string]interface{}
+	var err error
+	if limit > 0 {
+		records, err = client.QueryRecords("notification_outbox", filter, "-created", "", limit)
+	} else {
+		records, err = client.ListAllRecords("notification_outbox", filter, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	entries := make([]OutboxEntry, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, OutboxEntryFromRecord(record))
+	}
+	return entries, nil
+}
+
+// Requeue puts a failed or dead delivery back in the queue with its attempts reset
+func Requeue(client *pbclient.Client, entry OutboxEntry) error {
+	if entry.Status != OutboxFailed && entry.Status != OutboxDead {
+		return fmt.Errorf("delivery %s is %s, only failed and dead deliveries can be requeued", entry.ID, entry.Status)
+	}
+	return client.UpdateRecord("notification_outbox", entry.ID, map[string]interface{}{
+		"status":       OutboxQueued,
+		"attempts":     0,
+		"next_attempt": formatTime(time.Now()),
+	})
+}
+
+// OutboxEntryFromRecord reads a notification_outbox record
+func OutboxEntryFromRecord(record map[string]interface{}) OutboxEntry {
+	e := OutboxEntry{}
+	e.ID, _ = record["id"].(string)
+	e.IdempotencyKey, _ = record["idempotency_key"].(string)
+	e.Job, _ = record["job"].(string)
+	e.User, _ = record["user"].(string)
+	e.Channel, _ = record["channel"].(string)
+	e.Status, _ = record["status"].(string)
+	if n, ok := record["attempts"].(float64); ok {
+		e.Attempts = int(n)
+	}
+	e.NextAttempt, _ = parseTime(record["next_attempt"])
+	e.ClaimedAt, _ = parseTime(record["claimed_at"])
+	e.LastError, _ = record["last_error"].(string)
+	e.DeliveredAt, _ = parseTime(record["delivered_at"])
+	e.Created, _ = parseTime(record["created"])
+	return e
+}
+
+func expanded(record map[string]interface{}, field string) (map[string]interface{}, bool) {
+	expand, ok := record["expand"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := expand[field].(map[string]interface{})
+	return value, ok
+}
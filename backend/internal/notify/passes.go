@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/signal-k/notifs/internal/passes"
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// passBucket is how coarsely a pass's culmination is keyed. A fresh element set moves
+// a prediction by seconds, so the neighbouring buckets are checked too.
+const passBucket = 10 * time.Minute
+
+// tleRetry is how long a failed read of the element sets waits before the next
+const tleRetry = 30 * time.Minute
+
+// PassAlerts queues a visible_pass job for users following a station, shortly before
+// it comes into view from where they watch. Users without a location in their
+// preferences are skipped.
+type PassAlerts struct {
+	Client *pbclient.Client
+	// Source is the file or URL element sets are read from
+	Source string
+	// Lead is how long before the station comes into view the job is queued
+	Lead time.Duration
+	// Refresh is how long element sets are used before they are read again. They
+	// drift by a few seconds a day, more after a reboost.
+	Refresh time.Duration
+	Options passes.Options
+	Now     func() time.Time
+
+	tles     []passes.TLE
+	loadedAt time.Time
+	nextLoad time.Time
+	loadErr  error
+}
+
+// NewPassAlerts returns pass alerts reading element sets from source
+func NewPassAlerts(client *pbclient.Client, source string) *PassAlerts {
+	return &PassAlerts{
+		Client:  client,
+		Source:  source,
+		Lead:    15 * time.Minute,
+		Refresh: 12 * time.Hour,
+		Options: passes.DefaultOptions,
+		Now:     time.Now,
+	}
+}
+
+// station is the part of a stations record pass alerts need
+type station struct {
+	Name  string
+	Norad int
+}
+
+// Evaluate queues a job for each visible pass coming into view within Lead and returns
+// the jobs it created
+func (pa *PassAlerts) Evaluate() ([]Job, error) {
+	now := pa.Now()
+
+	subscriptions, err := LoadSubscriptions(pa.Client)
+	if err != nil {
+		return nil, err
+	}
+	var following []Subscription
+	for _, sub := range subscriptions {
+		if sub.TargetType == TargetStation && sub.Target != "" && sub.Wants(TriggerVisiblePass) {
+			following = append(following, sub)
+		}
+	}
+	if len(following) == 0 {
+		return nil, nil
+	}
+
+	prefs, err := LoadPreferences(pa.Client)
+	if err != nil {
+		return nil, err
+	}
+	stations, err := pa.loadStations()
+	if err != nil {
+		return nil, err
+	}
+	if err := pa.loadTLEs(now); err != nil {
+		return nil, err
+	}
+
+	var created []Job
+	satellites := map[int]*passes.Satellite{}
+	for _, sub := range following {
+		pref, ok := prefs[sub.User]
+		if !ok || pref.Observer == nil {
+			continue
+		}
+		st, ok := stations[sub.Target]
+		if !ok {
+			continue
+		}
+		sat, ok := satellites[st.Norad]
+		if !ok {
+			tle, found := passes.Find(pa.tles, strconv.Itoa(st.Norad))
+			if !found {
+				log.Printf("⚠️ No element set for %s (NORAD %d)", st.Name, st.Norad)
+			} else if sat, err = passes.NewSatellite(tle); err != nil {
+				log.Printf("⚠️ Can't propagate %s: %v", st.Name, err)
+			}
+			satellites[st.Norad] = sat
+		}
+		if sat == nil {
+			continue
+		}
+
+		// Passes are searched from a little before now, as a pass can come into view
+		// before or after it culminates
+		upcoming, err := passes.Predict(sat, *pref.Observer, now.Add(-passBucket), now.Add(pa.Lead+passBucket), pa.Options)
+		if err != nil {
+			log.Printf("❌ Failed to predict passes of %s: %v", st.Name, err)
+			continue
+		}
+		for _, pass := range upcoming {
+			start := pass.VisibleFrom.Time
+			if !pass.Visible || !start.After(now) || start.After(now.Add(pa.Lead)) {
+				continue
+			}
+			job := Job{
+				User:         sub.User,
+				Subscription: sub.ID,
+				Trigger:      TriggerVisiblePass,
+				Detail:       passDetail(st.Name, pass),
+			}
+			isNew, err := pa.createPassJob(&job, sub.Target, pass)
+			if err != nil {
+				log.Printf("❌ Failed to queue %s pass for %s: %v", st.Name, sub.User, err)
+				continue
+			}
+			if isNew {
+				log.Printf("🛰️ Queued %s pass at %s for %s", st.Name, start.UTC().Format(time.RFC3339), sub.User)
+				created = append(created, job)
+			}
+		}
+	}
+	return created, nil
+}
+
+// createPassJob keys the job by user, station and culmination, and stores it unless
+// a job for the same pass exists under a neighbouring key
+func (pa *PassAlerts) createPassJob(job *Job, stationID string, pass passes.Pass) (bool, error) {
+	bucket := pass.Culmination.Time.Truncate(passBucket).Unix()
+	key := func(bucket int64) string {
+		return Firing{Trigger: TriggerVisiblePass, Instance: strconv.FormatInt(bucket, 10)}.Key(job.User, stationID)
+	}
+	job.Key = key(bucket)
+
+	neighbours := []string{
+		fmt.Sprintf(`dedupe_key="%s"`, key(bucket-int64(passBucket.Seconds()))),
+		fmt.Sprintf(`dedupe_key="%s"`, key(bucket+int64(passBucket.Seconds()))),
+	}
+	existing, err := pa.Client.QueryRecords("notification_jobs", strings.Join(neighbours, " || "), "", "", 1)
+	if err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+	return createJob(pa.Client, job)
+}
+
+// passDetail is what templates need to describe a pass. Net is when the station comes
+// into view, which is what quiet hours and the countdown are measured against.
+func passDetail(name string, pass passes.Pass) map[string]any {
+	detail := map[string]any{
+		"satellite":     pass.Satellite,
+		"norad_id":      pass.Norad,
+		"net":           pass.VisibleFrom.Time.UTC().Format(time.RFC3339),
+		"visible_to":    pass.VisibleTo.Time.UTC().Format(time.RFC3339),
+		"rise":          pass.Rise.Time.UTC().Format(time.RFC3339),
+		"culmination":   pass.Culmination.Time.UTC().Format(time.RFC3339),
+		"set":           pass.Set.Time.UTC().Format(time.RFC3339),
+		"max_elevation": int(math.Round(pass.MaxElevation())),
+		"azimuth_from":  int(math.Round(pass.VisibleFrom.Azimuth)),
+		"azimuth_to":    int(math.Round(pass.VisibleTo.Azimuth)),
+	}
+	if pass.Magnitude != nil {
+		// Kept as text, so a magnitude of exactly 0 still reads as set in templates
+		detail["magnitude"] = strconv.FormatFloat(*pass.Magnitude, 'f', 1, 64)
+	}
+	return withTitle(detail, name)
+}
+
+// loadStations returns the stations with a catalog number, by record ID
+func (pa *PassAlerts) loadStations() (map[string]station, error) {
+	records, err := pa.Client.ListAllRecords("stations", "norad_id>0", "")
+	if err != nil {
+		return nil, fmt.Errorf("list stations: %w", err)
+	}
+	stations := make(map[string]station, len(records))
+	for _, record := range records {
+		id, _ := record["id"].(string)
+		name, _ := record["name"].(string)
+		norad, _ := record["norad_id"].(float64)
+		stations[id] = station{Name: name, Norad: int(norad)}
+	}
+	return stations, nil
+}
+
+// loadTLEs reads the element sets once Refresh has passed, keeping the old ones if the
+// source can't be read. CelesTrak blocks clients that fetch too often, so a failed
+// read waits tleRetry before the next.
+func (pa *PassAlerts) loadTLEs(now time.Time) error {
+	if now.Before(pa.nextLoad) {
+		if pa.tles == nil {
+			return pa.loadErr
+		}
+		return nil
+	}
+	tles, err := passes.LoadTLEs(pa.Source)
+	if err != nil {
+		pa.nextLoad, pa.loadErr = now.Add(tleRetry), fmt.Errorf("load element sets: %w", err)
+		if pa.tles != nil {
+			log.Printf("⚠️ Keeping element sets from %s: %v", pa.loadedAt.UTC().Format(time.RFC3339), err)
+			return nil
+		}
+		return pa.loadErr
+	}
+	pa.tles, pa.loadedAt, pa.nextLoad = tles, now, now.Add(pa.Refresh)
+	return nil
+}
@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/signal-k/notifs/internal/passes"
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// Delivery modes
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryDigest    = "digest"
+)
+
+// urgentTriggers may break through quiet hours and digest mode for users who opted in
+var urgentTriggers = []string{TriggerT10m}
+
+// countdownTriggers are the reminders before NET that MinLead applies to
+var countdownTriggers = []string{TriggerT24h, TriggerT1h, TriggerT10m}
+
+// timedTriggers lose their point once the moment they announce has passed, so they are
+// dropped rather than deferred past it. For a visible pass that moment is when the
+// station comes into view.
+var timedTriggers = []string{TriggerT24h, TriggerT1h, TriggerT10m, TriggerVisiblePass}
+
+// Preferences is how a user wants to be notified. The zero value of each setting means
+// no restriction, so users without a notification_preferences record get every alert
+// on every channel straight away.
+type Preferences struct {
+	User     string
+	Location *time.Location
+	// Locale picks the language notifications are written in, e.g. "es" or "pt-BR"
+	Locale string
+	// QuietStart and QuietEnd are minutes after local midnight; equal means no quiet hours
+	QuietStart int
+	QuietEnd   int
+	// UrgentInQuietHours lets the 10 minute reminder through quiet hours and digest mode
+	UrgentInQuietHours bool
+	// Channels limits delivery to these channels; empty means all
+	Channels []string
+	// MinLead drops countdown reminders closer to NET than this
+	MinLead   time.Duration
+	Delivery  string
+	Overrides map[string]ProviderOverride
+	// Observer is where the user watches the sky from, for visible pass alerts; nil
+	// when they haven't set a location
+	Observer *passes.Observer
+}
+
+// ProviderOverride changes the preferences for one provider's launches
+type ProviderOverride struct {
+	Muted            bool
+	Channels         []string
+	IgnoreQuietHours bool
+}
+
+// Plan is what the preferences decide for one job
+type Plan struct {
+	// Suppressed holds the reason the job isn't delivered at all
+	Suppressed string
+	// Channels the job may be delivered on; empty means all
+	Channels []string
+	// DeliverAt is when delivery may start, later than now during quiet hours
+	DeliverAt time.Time
+}
+
+// Allows reports whether the plan delivers on a channel
+func (p Plan) Allows(channel string) bool {
+	return len(p.Channels) == 0 || slices.Contains(p.Channels, channel)
+}
+
+// DefaultPreferences are used for users who never saved any
+func DefaultPreferences(user string) Preferences {
+	return Preferences{User: user, Location: time.UTC, Locale: templates.DefaultLocale, Delivery: DeliveryImmediate}
+}
+
+// Context is what templates need to write for the user at now
+func (p Preferences) Context(now time.Time) templates.Context {
+	return templates.Context{Locale: p.Locale, Location: p.Location, Now: now}
+}
+
+// Plan decides whether, where and when a job for event is delivered at now
+func (p Preferences) Plan(job Job, event Event, now time.Time) Plan {
+	plan := Plan{Channels: p.Channels, DeliverAt: now}
+	urgent := p.UrgentInQuietHours && slices.Contains(urgentTriggers, job.Trigger)
+	countdown := slices.Contains(countdownTriggers, job.Trigger)
+	timed := slices.Contains(timedTriggers, job.Trigger)
+
+	override, hasOverride := p.Overrides[event.Provider]
+	if hasOverride && override.Muted {
+		plan.Suppressed = "provider muted"
+		return plan
+	}
+	if hasOverride && len(override.Channels) > 0 {
+		plan.Channels = override.Channels
+	}
+
+	if countdown && p.MinLead > 0 && !event.Net.IsZero() && event.Net.Sub(now) < p.MinLead {
+		plan.Suppressed = fmt.Sprintf("less than %s before NET", p.MinLead)
+		return plan
+	}
+	if p.Delivery == DeliveryDigest && !urgent {
+		plan.Suppressed = "digest only"
+		return plan
+	}
+
+	if urgent || (hasOverride && override.IgnoreQuietHours) {
+		return plan
+	}
+	if until, quiet := p.QuietUntil(now); quiet {
+		if timed && !event.Net.IsZero() && !until.Before(event.Net) {
+			plan.Suppressed = "quiet hours until after NET"
+			return plan
+		}
+		plan.DeliverAt = until
+	}
+	return plan
+}
+
+// QuietUntil reports whether now falls in the user's quiet hours and when they end
+func (p Preferences) QuietUntil(now time.Time) (time.Time, bool) {
+	if p.QuietStart == p.QuietEnd {
+		return time.Time{}, false
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	quiet := minute >= p.QuietStart && minute < p.QuietEnd
+	if p.QuietStart > p.QuietEnd {
+		// Overnight, e.g. 22:00 to 07:00
+		quiet = minute >= p.QuietStart || minute < p.QuietEnd
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	end := time.Date(local.Year(), local.Month(), local.Day(), p.QuietEnd/60, p.QuietEnd%60, 0, 0, loc)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end, true
+}
+
+// LoadPreferences returns every saved preference record, by user
+func LoadPreferences(client *pbclient.Client) (map[string]Preferences, error) {
+	records, err := client.ListAllRecords("notification_preferences", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("list notification preferences: %w", err)
+	}
+
+	prefs := make(map[string]Preferences, len(records))
+	for _, record := range records {
+		p := PreferencesFromRecord(record)
+		prefs[p.User] = p
+	}
+	return prefs, nil
+}
+
+// PreferencesFromRecord reads a notification_preferences record. Invalid settings fall
+// back to no restriction rather than blocking the user's notifications.
+func PreferencesFromRecord(record map[string]interface{}) Preferences {
+	user, _ := record["user"].(string)
+	p := DefaultPreferences(user)
+
+	if tz, _ := record["timezone"].(string); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			p.Location = loc
+		} else {
+			log.Printf("⚠️ Unknown timezone %q for user %s, using UTC", tz, user)
+		}
+	}
+	if locale, _ := record["locale"].(string); locale != "" {
+		p.Locale = locale
+	}
+	start, okStart := clockMinutes(record["quiet_start"])
+	end, okEnd := clockMinutes(record["quiet_end"])
+	if okStart && okEnd {
+		p.QuietStart, p.QuietEnd = start, end
+	}
+	p.UrgentInQuietHours, _ = record["urgent_in_quiet_hours"].(bool)
+	p.Channels = stringList(record["channels"])
+	if minutes, ok := record["min_lead_minutes"].(float64); ok && minutes > 0 {
+		p.MinLead = time.Duration(minutes) * time.Minute
+	}
+	if delivery, _ := record["delivery"].(string); delivery != "" {
+		p.Delivery = delivery
+	}
+	// PocketBase stores an unset number as 0, and nobody watches from 0°N 0°E
+	lat, _ := record["latitude"].(float64)
+	lon, _ := record["longitude"].(float64)
+	if lat != 0 || lon != 0 {
+		p.Observer = &passes.Observer{Latitude: lat, Longitude: lon}
+		p.Observer.Altitude, _ = record["elevation_m"].(float64)
+	}
+
+	if overrides, ok := record["provider_overrides"].([]interface{}); ok {
+		p.Overrides = map[string]ProviderOverride{}
+		for _, item := range overrides {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			provider, _ := entry["provider"].(string)
+			if provider == "" {
+				continue
+			}
+			o := ProviderOverride{Channels: stringList(entry["channels"])}
+			o.Muted, _ = entry["muted"].(bool)
+			o.IgnoreQuietHours, _ = entry["ignore_quiet_hours"].(bool)
+			p.Overrides[provider] = o
+		}
+	}
+	return p
+}
+
+// clockMinutes parses an "HH:MM" time of day into minutes after midnight
+func clockMinutes(v interface{}) (int, bool) {
+	s, _ := v.(string)
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, false
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 23 {
+		return 0, false
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 || m > 59 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
@@ -0,0 +1,311 @@
+// Package notify turns user subscriptions and the events collection into notification
+// jobs. Each job is keyed by user, event and trigger so it is created at most once, no
+// matter how often or on how many restarts the engine evaluates the same event.
+package notify
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/signal-k/notifs/internal/sync"
+)
+
+// Triggers a subscription can ask for
+const (
+	TriggerT24h          = "t_minus_24h"
+	TriggerT1h           = "t_minus_1h"
+	TriggerT10m          = "t_minus_10m"
+	TriggerWebcastLive   = "webcast_live"
+	TriggerStatusChange  = "status_change"
+	TriggerLaunchOutcome = "launch_outcome"
+	TriggerSchedule      = "schedule_change"
+	TriggerVisiblePass   = "visible_pass"
+)
+
+// Triggers lists every trigger, in the order they usually fire
+var Triggers = []string{
+	TriggerT24h,
+	TriggerT1h,
+	TriggerT10m,
+	TriggerWebcastLive,
+	TriggerStatusChange,
+	TriggerLaunchOutcome,
+	TriggerSchedule,
+	TriggerVisiblePass,
+}
+
+// Subscription targets
+const (
+	TargetProvider = "provider"
+	TargetPad      = "pad"
+	TargetRocket   = "rocket"
+	TargetProgram  = "program"
+	TargetLaunch   = "launch"
+	TargetStation  = "station"
+)
+
+// TargetTypes lists every kind of thing a user can follow
+var TargetTypes = []string{TargetProvider, TargetPad, TargetRocket, TargetProgram, TargetLaunch, TargetStation}
+
+// leadTimes are the countdown reminders, tightest first. Each one only fires in its own
+// slot, up to the next tighter reminder, so a launch first seen an hour out doesn't get
+// a stale 24 hour reminder alongside the 1 hour one.
+var leadTimes = []struct {
+	Trigger string
+	Lead    time.Duration
+}{
+	{TriggerT10m, 10 * time.Minute},
+	{TriggerT1h, time.Hour},
+	{TriggerT24h, 24 * time.Hour},
+}
+
+// outcomeStatuses are the Launch Library status abbreviations for a finished launch
+var outcomeStatuses = []string{"Success", "Failure", "Partial Failure"}
+
+// outcomeWindow bounds how long after NET an outcome seen for the first time is still
+// worth announcing, so the first run doesn't notify the whole launch history
+const outcomeWindow = 48 * time.Hour
+
+// Event is the part of an events record the rules look at
+type Event struct {
+	ID          string
+	Title       string
+	Type        string
+	Net         time.Time
+	Status      string
+	WebcastLive bool
+	Provider    string
+	// ProviderName is only set when the provider relation was expanded
+	ProviderName string
+	Pad          string
+	Rocket       string
+	Programs     []string
+	// Location is the pad name, WebcastURL the highest priority video
+	Location   string
+	WebcastURL string
+	Created    time.Time
+}
+
+// EventState is what the engine last saw of an event, used to spot changes between runs
+type EventState struct {
+	RecordID    string
+	Event       string
+	Status      string
+	WebcastLive bool
+	Net         time.Time
+	// StatusChanges counts the status changes seen, so each one has its own instance
+	// even when a launch goes back to a status it had before
+	StatusChanges int
+}
+
+// statusChanged reports whether the event moved from one known status to another
+func statusChanged(e Event, prev *EventState) bool {
+	return prev != nil && prev.Status != "" && e.Status != "" && e.Status != prev.Status
+}
+
+// statusInstance tells the status change from prev apart from every earlier one: a
+// launch going Go, Hold, Go, Hold notifies both holds
+func statusInstance(e Event, prev *EventState) string {
+	return fmt.Sprintf("%d:%s", prev.StatusChanges+1, e.Status)
+}
+
+// Subscription is a user following one provider, pad, rocket, program, launch or
+// station. An empty Triggers list means every trigger.
+type Subscription struct {
+	ID         string
+	User       string
+	TargetType string
+	Target     string
+	Triggers   []string
+}
+
+// Matches reports whether the event belongs to what the subscription follows. Station
+// subscriptions match no event; their passes are found by PassAlerts.
+func (s Subscription) Matches(e Event) bool {
+	if s.Target == "" {
+		return false
+	}
+	switch s.TargetType {
+	case TargetProvider:
+		return e.Provider == s.Target
+	case TargetPad:
+		return e.Pad == s.Target
+	case TargetRocket:
+		return e.Rocket == s.Target
+	case TargetProgram:
+		return slices.Contains(e.Programs, s.Target)
+	case TargetLaunch:
+		return e.ID == s.Target
+	}
+	return false
+}
+
+// Wants reports whether the subscription asked for the trigger
+func (s Subscription) Wants(trigger string) bool {
+	return len(s.Triggers) == 0 || slices.Contains(s.Triggers, trigger)
+}
+
+// Firing is a trigger that is due for an event. Instance tells repeated firings of the
+// same trigger apart, such as status changes to different statuses.
+type Firing struct {
+	Trigger  string
+	Instance string
+	Detail   map[string]any
+}
+
+// Key identifies the job for a user, so it is only ever created once
+func (f Firing) Key(user, event string) string {
+	if f.Instance == "" {
+		return fmt.Sprintf("%s:%s:%s", user, event, f.Trigger)
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", user, event, f.Trigger, f.Instance)
+}
+
+// DueTriggers returns the triggers due for an event at now, given what was seen on the
+// previous run. prev is nil the first time an event is evaluated; status changes need
+// a previous status to compare with, so they never fire on first sight.
+func DueTriggers(e Event, prev *EventState, now time.Time) []Firing {
+	var firings []Firing
+
+	if !e.Net.IsZero() && now.Before(e.Net) && !isOutcome(e.Status) {
+		for _, lt := range leadTimes {
+			if !now.Before(e.Net.Add(-lt.Lead)) {
+				firings = append(firings, Firing{
+					Trigger: lt.Trigger,
+					Detail:  map[string]any{"net": e.Net.UTC().Format(time.RFC3339)},
+				})
+				break
+			}
+		}
+	}
+
+	if e.WebcastLive && (prev == nil || !prev.WebcastLive) {
+		firings = append(firings, Firing{Trigger: TriggerWebcastLive})
+	}
+
+	prevStatus := ""
+	if prev != nil {
+		prevStatus = prev.Status
+	}
+	switch {
+	case isOutcome(e.Status) && e.Status != prevStatus:
+		if prev != nil || now.Sub(e.Net) < outcomeWindow {
+			firings = append(firings, Firing{
+				Trigger: TriggerLaunchOutcome,
+				Detail:  map[string]any{"status": e.Status},
+			})
+		}
+	case statusChanged(e, prev):
+		firings = append(firings, Firing{
+			Trigger:  TriggerStatusChange,
+			Instance: statusInstance(e, prev),
+			Detail:   map[string]any{"from": prevStatus, "to": e.Status},
+		})
+	}
+
+	return firings
+}
+
+// Revision is a classified schedule change the sync stored in event_revisions
+type Revision struct {
+	ID     string
+	Event  string
+	Kind   string
+	Detail map[string]any
+}
+
+// RevisionFiring is the schedule_change firing for a revision. Each revision fires
+// once, so a launch that slips twice notifies twice.
+func RevisionFiring(r Revision) Firing {
+	detail := map[string]any{"kind": r.Kind}
+	for k, v := range r.Detail {
+		detail[k] = v
+	}
+	return Firing{Trigger: TriggerSchedule, Instance: r.ID, Detail: detail}
+}
+
+func isOutcome(status string) bool {
+	return slices.Contains(outcomeStatuses, status)
+}
+
+// Change kinds, announced once per event to listeners such as webhooks
+const (
+	ChangeLaunchCreated = "launch_created"
+	ChangeNetChanged    = "net_changed"
+	ChangeStatusChanged = "status_changed"
+	ChangeWebcastLive   = "webcast_live"
+	ChangeLaunchSuccess = "launch_success"
+	ChangeLaunchFailure = "launch_failure"
+)
+
+// ChangeKinds lists every change kind
+var ChangeKinds = []string{
+	ChangeLaunchCreated,
+	ChangeNetChanged,
+	ChangeStatusChanged,
+	ChangeWebcastLive,
+	ChangeLaunchSuccess,
+	ChangeLaunchFailure,
+}
+
+// createdWindow is how recently an event must have been stored to count as created
+// when the engine first sees it, so the first run doesn't announce every launch
+const createdWindow = 24 * time.Hour
+
+// Change is something that happened to an event since the previous pass. Instance
+// tells repeated changes of the same kind apart, like Firing.Instance.
+type Change struct {
+	Kind     string
+	Instance string
+	Detail   map[string]any
+}
+
+// DetectChanges returns what changed about an event since the previous pass. Like
+// DueTriggers, status and NET changes need a previous state to compare with.
+func DetectChanges(e Event, prev *EventState, now time.Time) []Change {
+	var changes []Change
+
+	if prev == nil && e.Type == sync.EventTypeLaunch && !e.Created.IsZero() && now.Sub(e.Created) < createdWindow {
+		changes = append(changes, Change{Kind: ChangeLaunchCreated})
+	}
+
+	if prev != nil && !prev.Net.IsZero() && !e.Net.IsZero() && !prev.Net.Equal(e.Net) {
+		changes = append(changes, Change{
+			Kind:     ChangeNetChanged,
+			Instance: e.Net.UTC().Format(time.RFC3339),
+			Detail: map[string]any{
+				"from":          prev.Net.UTC().Format(time.RFC3339),
+				"to":            e.Net.UTC().Format(time.RFC3339),
+				"delta_seconds": int64(e.Net.Sub(prev.Net).Seconds()),
+			},
+		})
+	}
+
+	if e.WebcastLive && (prev == nil || !prev.WebcastLive) {
+		changes = append(changes, Change{Kind: ChangeWebcastLive, Detail: map[string]any{"url": e.WebcastURL}})
+	}
+
+	prevStatus := ""
+	if prev != nil {
+		prevStatus = prev.Status
+	}
+	switch {
+	case isOutcome(e.Status) && e.Status != prevStatus:
+		if prev != nil || now.Sub(e.Net) < outcomeWindow {
+			kind := ChangeLaunchFailure
+			if e.Status == "Success" {
+				kind = ChangeLaunchSuccess
+			}
+			changes = append(changes, Change{Kind: kind, Detail: map[string]any{"status": e.Status}})
+		}
+	case statusChanged(e, prev):
+		changes = append(changes, Change{
+			Kind:     ChangeStatusChanged,
+			Instance: statusInstance(e, prev),
+			Detail:   map[string]any{"from": prevStatus, "to": e.Status},
+		})
+	}
+
+	return changes
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+// A launch going Go, Hold, Go, Hold notifies every change, including the second hold
+func TestStatusChangesFireEachTime(t *testing.T) {
+	srv := httptest.NewServer(pbfake.New())
+	t.Cleanup(srv.Close)
+	en := &Engine{Client: pbclient.NewClient(srv.URL)}
+
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	net := now.Add(72 * time.Hour)
+
+	var keys, instances []string
+	for _, status := range []string{"Go", "Hold", "Go", "Hold"} {
+		states, err := en.loadStates()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var prev *EventState
+		if state, ok := states["evt1"]; ok {
+			prev = &state
+		}
+
+		e := Event{ID: "evt1", Net: net, Status: status}
+		for _, f := range DueTriggers(e, prev, now) {
+			if f.Trigger == TriggerStatusChange {
+				keys = append(keys, f.Key("u1", e.ID))
+			}
+		}
+		for _, c := range DetectChanges(e, prev, now) {
+			if c.Kind == ChangeStatusChanged {
+				instances = append(instances, c.Instance)
+			}
+		}
+		if err := en.saveState(e, prev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"u1:evt1:status_change:1:Hold", "u1:evt1:status_change:2:Go", "u1:evt1:status_change:3:Hold"}
+	if len(keys) != len(want) {
+		t.Fatalf("status change keys = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("key %d = %s, want %s", i, keys[i], want[i])
+		}
+	}
+	if len(instances) != 3 || instances[0] == instances[2] {
+		t.Errorf("status change instances = %v, want three distinct", instances)
+	}
+}
@@ -0,0 +1,139 @@
+package passes
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	deg2rad = math.Pi / 180
+	rad2deg = 180 / math.Pi
+	// WGS-84 ellipsoid, for placing observers
+	wgs84Radius     = 6378.137 // km
+	wgs84Flattening = 1 / 298.257223563
+	auKm            = 149597870.7
+)
+
+// Observer is a place on the ground: latitude and longitude in degrees, north and east
+// positive, and altitude in meters above the ellipsoid
+type Observer struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// LookAngles is where something appears in an observer's sky. Azimuth is in degrees
+// clockwise from north, elevation in degrees above the horizon and range in km.
+type LookAngles struct {
+	Azimuth   float64
+	Elevation float64
+	Range     float64
+}
+
+// ecef returns the observer's earth-fixed position in km
+func (o Observer) ecef() Vector {
+	lat, lon := o.Latitude*deg2rad, o.Longitude*deg2rad
+	e2 := wgs84Flattening * (2 - wgs84Flattening)
+	sinLat := math.Sin(lat)
+	n := wgs84Radius / math.Sqrt(1-e2*sinLat*sinLat)
+	h := o.Altitude / 1000
+	return Vector{
+		(n + h) * math.Cos(lat) * math.Cos(lon),
+		(n + h) * math.Cos(lat) * math.Sin(lon),
+		(n*(1-e2) + h) * sinLat,
+	}
+}
+
+// look returns the look angles of an earth-fixed position
+func (o Observer) look(target Vector) LookAngles {
+	d := target.sub(o.ecef())
+	lat, lon := o.Latitude*deg2rad, o.Longitude*deg2rad
+	sinLat, cosLat := math.Sin(lat), math.Cos(lat)
+	sinLon, cosLon := math.Sin(lon), math.Cos(lon)
+
+	// Topocentric south, east and zenith components
+	south := sinLat*cosLon*d.X + sinLat*sinLon*d.Y - cosLat*d.Z
+	east := -sinLon*d.X + cosLon*d.Y
+	zenith := cosLat*cosLon*d.X + cosLat*sinLon*d.Y + sinLat*d.Z
+
+	rng := d.norm()
+	az := math.Atan2(east, -south) * rad2deg
+	if az < 0 {
+		az += 360
+	}
+	return LookAngles{Azimuth: az, Elevation: math.Asin(zenith/rng) * rad2deg, Range: rng}
+}
+
+// julian returns the Julian date of t
+func julian(t time.Time) float64 {
+	return float64(t.UnixNano())/86400e9 + 2440587.5
+}
+
+// gmst returns the Greenwich mean sidereal time at t in radians (IAU-82)
+func gmst(t time.Time) float64 {
+	tut1 := (julian(t) - 2451545) / 36525
+	seconds := -6.2e-6*tut1*tut1*tut1 + 0.093104*tut1*tut1 +
+		(876600*3600+8640184.812866)*tut1 + 67310.54841
+	theta := math.Mod(seconds*deg2rad/240, twoPi)
+	if theta < 0 {
+		theta += twoPi
+	}
+	return theta
+}
+
+// toECEF rotates a TEME position at t into the earth-fixed frame, ignoring polar
+// motion, which moves a pass by well under a second
+func toECEF(r Vector, t time.Time) Vector {
+	theta := gmst(t)
+	sin, cos := math.Sin(theta), math.Cos(theta)
+	return Vector{cos*r.X + sin*r.Y, -sin*r.X + cos*r.Y, r.Z}
+}
+
+// sunPosition returns the sun's position at t in km, in the same inertial frame as
+// SGP4's output. The Astronomical Almanac's low precision formula is good to about
+// 0.01°, far finer than twilight or the earth's shadow need.
+func sunPosition(t time.Time) Vector {
+	n := julian(t) - 2451545
+	meanLon := 280.460 + 0.9856474*n
+	g := (357.528 + 0.9856003*n) * deg2rad
+	lambda := (meanLon + 1.915*math.Sin(g) + 0.020*math.Sin(2*g)) * deg2rad
+	epsilon := (23.439 - 0.0000004*n) * deg2rad
+	dist := (1.00014 - 0.01671*math.Cos(g) - 0.00014*math.Cos(2*g)) * auKm
+	return Vector{
+		dist * math.Cos(lambda),
+		dist * math.Cos(epsilon) * math.Sin(lambda),
+		dist * math.Sin(epsilon) * math.Sin(lambda),
+	}
+}
+
+// SunElevation returns the sun's elevation in degrees above the observer's horizon
+func (o Observer) SunElevation(t time.Time) float64 {
+	return o.look(toECEF(sunPosition(t), t)).Elevation
+}
+
+// sunlit reports whether a satellite at r is in sunlight, treating the earth's shadow
+// as a cylinder; the penumbra lasts a few seconds of a low orbit
+func sunlit(r, sun Vector) bool {
+	toSun := sun.scale(1 / sun.norm())
+	along := r.dot(toSun)
+	if along > 0 {
+		return true
+	}
+	return r.sub(toSun.scale(along)).norm() > wgs84Radius
+}
+
+func (v Vector) sub(w Vector) Vector {
+	return Vector{v.X - w.X, v.Y - w.Y, v.Z - w.Z}
+}
+
+func (v Vector) scale(k float64) Vector {
+	return Vector{v.X * k, v.Y * k, v.Z * k}
+}
+
+func (v Vector) dot(w Vector) float64 {
+	return v.X*w.X + v.Y*w.Y + v.Z*w.Z
+}
+
+func (v Vector) norm() float64 {
+	return math.Sqrt(v.dot(v))
+}
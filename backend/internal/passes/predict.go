@@ -0,0 +1,282 @@
+package passes
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Options tune what counts as a pass worth reporting
+type Options struct {
+	// MinElevation is the lowest peak, in degrees, of a reported pass. Trees and
+	// buildings hide most of the sky below 10°.
+	MinElevation float64
+	// MaxSunElevation is how high the sun may be, in degrees, for the sky to be dark
+	// enough to see a satellite; -6° is the end of civil twilight
+	MaxSunElevation float64
+	// Step is how often the sky is checked while searching for passes. It must be
+	// shorter than the briefest pass worth finding.
+	Step time.Duration
+	// StdMagnitude is the satellite's brightness at 1000 km range and half phase. When
+	// zero, the known value for the satellite is used, if there is one.
+	StdMagnitude float64
+}
+
+// DefaultOptions are the options visible pass alerts and the passes command use
+var DefaultOptions = Options{MinElevation: 10, MaxSunElevation: -6, Step: time.Minute}
+
+// standardMagnitudes are the brightness at 1000 km and half phase of satellites bright
+// enough to look for, by NORAD catalog number
+var standardMagnitudes = map[int]float64{
+	25544: -1.8, // ISS
+	48274: -0.7, // Tiangong
+	20580: 2.2,  // Hubble
+}
+
+// visibleStep is how finely a pass is sampled for when it is sunlit against a dark sky
+const visibleStep = 10 * time.Second
+
+// Point is where a satellite is in the observer's sky at a moment of a pass
+type Point struct {
+	Time      time.Time `json:"time"`
+	Azimuth   float64   `json:"azimuth"`
+	Elevation float64   `json:"elevation"`
+	Range     float64   `json:"range_km"`
+	Sunlit    bool      `json:"sunlit"`
+}
+
+// Pass is one trip of a satellite across the observer's sky, from rising above the
+// horizon to setting below it
+type Pass struct {
+	Satellite   string `json:"satellite"`
+	Norad       int    `json:"norad_id"`
+	Rise        Point  `json:"rise"`
+	Culmination Point  `json:"culmination"`
+	Set         Point  `json:"set"`
+	// Dark is whether the observer's sky is dark at culmination
+	Dark bool `json:"dark"`
+	// Visible is whether the satellite is sunlit, above MinElevation, while the
+	// observer's sky is dark at some point of the pass. VisibleFrom and VisibleTo
+	// bound that part of the pass and Magnitude is its brightest, lower being
+	// brighter; all three are only set for visible passes.
+	Visible     bool     `json:"visible"`
+	VisibleFrom Point    `json:"visible_from,omitzero"`
+	VisibleTo   Point    `json:"visible_to,omitzero"`
+	Magnitude   *float64 `json:"magnitude,omitempty"`
+}
+
+// MaxElevation is the pass's highest elevation in degrees
+func (p Pass) MaxElevation() float64 {
+	return p.Culmination.Elevation
+}
+
+// Duration is how long the satellite is above the horizon
+func (p Pass) Duration() time.Duration {
+	return p.Set.Time.Sub(p.Rise.Time)
+}
+
+// Predict returns the passes of sat over observer that culminate between from and to,
+// in time order
+func Predict(sat *Satellite, observer Observer, from, to time.Time, opts Options) ([]Pass, error) {
+	if opts.Step <= 0 {
+		opts.Step = DefaultOptions.Step
+	}
+	p := predictor{sat: sat, observer: observer, opts: opts}
+	if opts.StdMagnitude != 0 {
+		p.stdMagnitude, p.hasMagnitude = opts.StdMagnitude, true
+	} else {
+		p.stdMagnitude, p.hasMagnitude = standardMagnitudes[sat.TLE.Norad]
+	}
+
+	// A pass in progress at from is found from its rise, up to a quarter orbit back;
+	// the search runs on past to by as much, to find the set of a pass culminating
+	// just before it
+	start := from
+	period := time.Duration(float64(24*time.Hour) / sat.TLE.MeanMotion)
+	for limit := from.Add(-period / 4); start.After(limit); start = start.Add(-opts.Step) {
+		el, err := p.elevation(start)
+		if err != nil {
+			return nil, err
+		}
+		if el < 0 {
+			break
+		}
+	}
+
+	var passes []Pass
+	prev, err := p.elevation(start)
+	if err != nil {
+		return nil, err
+	}
+	rise := time.Time{}
+	if prev >= 0 {
+		rise = start
+	}
+	for t := start.Add(opts.Step); !t.After(to.Add(period / 4)); t = t.Add(opts.Step) {
+		el, err := p.elevation(t)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case prev < 0 && el >= 0:
+			if rise, err = p.crossing(t.Add(-opts.Step), t); err != nil {
+				return nil, err
+			}
+		case prev >= 0 && el < 0 && !rise.IsZero():
+			set, err := p.crossing(t.Add(-opts.Step), t)
+			if err != nil {
+				return nil, err
+			}
+			pass, ok, err := p.pass(rise, set)
+			if err != nil {
+				return nil, err
+			}
+			culmination := pass.Culmination.Time
+			if ok && !culmination.Before(from) && culmination.Before(to) {
+				passes = append(passes, pass)
+			}
+			rise = time.Time{}
+		}
+		prev = el
+	}
+	return passes, nil
+}
+
+type predictor struct {
+	sat          *Satellite
+	observer     Observer
+	opts         Options
+	stdMagnitude float64
+	hasMagnitude bool
+}
+
+// look returns where the satellite is at t, and whether it is sunlit
+func (p predictor) look(t time.Time) (LookAngles, bool, Vector, error) {
+	r, _, err := p.sat.Propagate(t)
+	if err != nil {
+		return LookAngles{}, false, Vector{}, fmt.Errorf("%s at %s: %w", p.sat.TLE.Name, t.Format(time.RFC3339), err)
+	}
+	return p.observer.look(toECEF(r, t)), sunlit(r, sunPosition(t)), r, nil
+}
+
+func (p predictor) elevation(t time.Time) (float64, error) {
+	angles, _, _, err := p.look(t)
+	return angles.Elevation, err
+}
+
+func (p predictor) point(t time.Time) (Point, error) {
+	angles, lit, _, err := p.look(t)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{
+		Time:      t.Round(time.Second),
+		Azimuth:   angles.Azimuth,
+		Elevation: angles.Elevation,
+		Range:     angles.Range,
+		Sunlit:    lit,
+	}, nil
+}
+
+// crossing bisects the moment between a and b the satellite crosses the horizon
+func (p predictor) crossing(a, b time.Time) (time.Time, error) {
+	elA, err := p.elevation(a)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for b.Sub(a) > time.Second/2 {
+		mid := a.Add(b.Sub(a) / 2)
+		el, err := p.elevation(mid)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if (el >= 0) == (elA >= 0) {
+			a, elA = mid, el
+		} else {
+			b = mid
+		}
+	}
+	return a.Add(b.Sub(a) / 2), nil
+}
+
+// pass describes the pass between rise and set, reporting false when it peaks too low
+func (p predictor) pass(rise, set time.Time) (Pass, bool, error) {
+	// Elevation has a single peak over a low orbit pass, so a ternary search finds it
+	a, b := rise, set
+	for b.Sub(a) > time.Second {
+		m1, m2 := a.Add(b.Sub(a)/3), b.Add(-b.Sub(a)/3)
+		el1, err := p.elevation(m1)
+		if err != nil {
+			return Pass{}, false, err
+		}
+		el2, err := p.elevation(m2)
+		if err != nil {
+			return Pass{}, false, err
+		}
+		if el1 < el2 {
+			a = m1
+		} else {
+			b = m2
+		}
+	}
+
+	pass := Pass{Satellite: p.sat.TLE.ShortName(), Norad: p.sat.TLE.Norad}
+	var err error
+	if pass.Culmination, err = p.point(a.Add(b.Sub(a) / 2)); err != nil {
+		return Pass{}, false, err
+	}
+	if pass.Culmination.Elevation < p.opts.MinElevation {
+		return Pass{}, false, nil
+	}
+	if pass.Rise, err = p.point(rise); err != nil {
+		return Pass{}, false, err
+	}
+	if pass.Set, err = p.point(set); err != nil {
+		return Pass{}, false, err
+	}
+	pass.Dark = p.observer.SunElevation(pass.Culmination.Time) <= p.opts.MaxSunElevation
+
+	brightest := math.Inf(1)
+	for t := rise; !t.After(set); t = t.Add(visibleStep) {
+		angles, lit, r, err := p.look(t)
+		if err != nil {
+			return Pass{}, false, err
+		}
+		if !lit || angles.Elevation < p.opts.MinElevation || p.observer.SunElevation(t) > p.opts.MaxSunElevation {
+			continue
+		}
+		point := Point{Time: t.Round(time.Second), Azimuth: angles.Azimuth, Elevation: angles.Elevation, Range: angles.Range, Sunlit: true}
+		if !pass.Visible {
+			pass.Visible, pass.VisibleFrom = true, point
+		}
+		pass.VisibleTo = point
+		if p.hasMagnitude {
+			brightest = math.Min(brightest, p.magnitude(r, angles.Range, t))
+		}
+	}
+	if pass.Visible && p.hasMagnitude {
+		magnitude := math.Round(brightest*10) / 10
+		pass.Magnitude = &magnitude
+	}
+	return pass, true, nil
+}
+
+// magnitude estimates the satellite's apparent brightness from its standard magnitude,
+// its range and the phase angle between the sun and the observer, modelling it as a
+// diffusely reflecting sphere
+func (p predictor) magnitude(r Vector, rng float64, t time.Time) float64 {
+	// The observer's position in the inertial frame, by undoing the earth's rotation
+	theta := gmst(t)
+	o := p.observer.ecef()
+	sin, cos := math.Sin(theta), math.Cos(theta)
+	observer := Vector{cos*o.X - sin*o.Y, sin*o.X + cos*o.Y, o.Z}
+
+	toSun := sunPosition(t).sub(r)
+	toObserver := observer.sub(r)
+	cosPhase := toSun.dot(toObserver) / (toSun.norm() * toObserver.norm())
+	phase := math.Acos(math.Max(-1, math.Min(1, cosPhase)))
+	// The lit fraction relative to half phase, where the standard magnitude applies
+	illuminated := (math.Pi-phase)*math.Cos(phase) + math.Sin(phase)
+
+	return p.stdMagnitude + 5*math.Log10(rng/1000) - 2.5*math.Log10(math.Max(illuminated, 1e-6))
+}
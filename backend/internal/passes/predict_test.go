@@ -0,0 +1,100 @@
+package passes
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func near(t *testing.T, what string, got, want time.Time) {
+	t.Helper()
+	if d := got.Sub(want); d < -5*time.Second || d > 5*time.Second {
+		t.Errorf("%s = %s, want %s", what, got.Format(time.TimeOnly), want.Format(time.TimeOnly))
+	}
+}
+
+// The ISS over Berlin on 1 January 2024: three daylight passes, a bright evening pass
+// and a faint one low in the west after it
+func TestPredictISSOverBerlin(t *testing.T) {
+	sat := mustSatellite(t, "ISS (ZARYA)",
+		"1 25544U 98067A   24001.50000000  .00016717  00000-0  30194-3 0  9994",
+		"2 25544  51.6416 247.4627 0006703 130.5360 325.0288 15.49815407432103")
+	berlin := Observer{Latitude: 52.52, Longitude: 13.405, Altitude: 34}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	predicted, err := Predict(sat, berlin, from, from.Add(24*time.Hour), DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var visible []Pass
+	for _, pass := range predicted {
+		if pass.Rise.Time.After(pass.Culmination.Time) || pass.Culmination.Time.After(pass.Set.Time) {
+			t.Errorf("pass at %s is out of order", pass.Culmination.Time)
+		}
+		if pass.MaxElevation() < DefaultOptions.MinElevation {
+			t.Errorf("pass at %s peaks at %.1f°, below the minimum", pass.Culmination.Time, pass.MaxElevation())
+		}
+		if pass.Visible {
+			visible = append(visible, pass)
+		}
+	}
+	if len(visible) != 2 {
+		t.Fatalf("found %d visible passes, want 2: %+v", len(visible), visible)
+	}
+
+	pass := visible[0]
+	at := func(hour, min, sec int) time.Time { return time.Date(2024, 1, 1, hour, min, sec, 0, time.UTC) }
+	near(t, "rise", pass.Rise.Time, at(16, 36, 6))
+	near(t, "culmination", pass.Culmination.Time, at(16, 41, 26))
+	near(t, "set", pass.Set.Time, at(16, 46, 43))
+	near(t, "visible from", pass.VisibleFrom.Time, at(16, 38, 16))
+	near(t, "visible to", pass.VisibleTo.Time, at(16, 44, 26))
+	if el := pass.MaxElevation(); math.Abs(el-40.6) > 0.5 {
+		t.Errorf("max elevation = %.2f°, want 40.6°", el)
+	}
+	if !pass.Dark {
+		t.Error("the evening pass isn't dark")
+	}
+	// Rising in the west, setting in the southeast
+	if az := pass.Rise.Azimuth; math.Abs(az-281) > 2 {
+		t.Errorf("rise azimuth = %.1f°, want 281°", az)
+	}
+	if az := pass.Set.Azimuth; math.Abs(az-124) > 2 {
+		t.Errorf("set azimuth = %.1f°, want 124°", az)
+	}
+	if pass.Magnitude == nil || math.Abs(*pass.Magnitude+2.7) > 0.2 {
+		t.Errorf("magnitude = %v, want -2.7", pass.Magnitude)
+	}
+
+	if rise := visible[1].Rise.Time; rise.Before(at(18, 13, 0)) || rise.After(at(18, 14, 0)) {
+		t.Errorf("second visible pass rises at %s, want 18:13", rise.Format(time.TimeOnly))
+	}
+}
+
+func TestPredictWindow(t *testing.T) {
+	sat := mustSatellite(t, "ISS (ZARYA)",
+		"1 25544U 98067A   24001.50000000  .00016717  00000-0  30194-3 0  9994",
+		"2 25544  51.6416 247.4627 0006703 130.5360 325.0288 15.49815407432103")
+	berlin := Observer{Latitude: 52.52, Longitude: 13.405, Altitude: 34}
+
+	// Starting mid-pass still finds the pass, from its rise
+	from := time.Date(2024, 1, 1, 16, 40, 0, 0, time.UTC)
+	predicted, err := Predict(sat, berlin, from, from.Add(30*time.Minute), DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(predicted) != 1 {
+		t.Fatalf("found %d passes, want the one in progress", len(predicted))
+	}
+	near(t, "rise", predicted[0].Rise.Time, time.Date(2024, 1, 1, 16, 36, 6, 0, time.UTC))
+
+	// Passes culminating after the window are left out
+	predicted, err = Predict(sat, berlin, from.Add(2*time.Minute), from.Add(30*time.Minute), DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(predicted) != 0 {
+		t.Errorf("found %d passes culminating in the window, want none", len(predicted))
+	}
+}
@@ -0,0 +1,292 @@
+package passes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// WGS-72 constants, which the TLE mean elements are fitted against
+const (
+	earthRadius = 6378.135 // km
+	earthMu     = 398600.8 // km³/s²
+	j2          = 0.001082616
+	j3          = -0.00000253881
+	j4          = -0.00000165597
+	j3oj2       = j3 / j2
+	twoPi       = 2 * math.Pi
+	x2o3        = 2.0 / 3.0
+)
+
+var (
+	// xke is sqrt(mu) in earth radii³ per minute²
+	xke = 60 / math.Sqrt(earthRadius*earthRadius*earthRadius/earthMu)
+	// kmPerSec converts earth radii per minute
+	kmPerSec = earthRadius * xke / 60
+)
+
+// ErrDeepSpace is returned for orbits of 225 minutes or more, which need the SDP4
+// lunar and solar terms. Stations and everything else bright enough to watch pass
+// overhead orbit far lower.
+var ErrDeepSpace = errors.New("deep space orbits are not supported")
+
+// ErrDecayed is returned once the propagated orbit has fallen into the atmosphere
+var ErrDecayed = errors.New("satellite has decayed")
+
+// Vector is a position in km or a velocity in km/s
+type Vector struct {
+	X, Y, Z float64
+}
+
+// Satellite is an element set ready to propagate with SGP4. It follows the near earth
+// branch of the reference implementation in Vallado et al., "Revisiting Spacetrack
+// Report #3" (2006).
+type Satellite struct {
+	TLE TLE
+
+	isimp                               bool
+	ecco, inclo, argpo, nodeo, mo, no   float64
+	bstar, eta, cc1, cc4, cc5, d2, d3   float64
+	d4, delmo, sinmao, t2cof, t3cof     float64
+	t4cof, t5cof, x1mth2, x7thm1, con41 float64
+	mdot, argpdot, nodedot, nodecf      float64
+	omgcof, xmcof, xlcof, aycof         float64
+}
+
+// NewSatellite prepares an element set for propagation
+func NewSatellite(tle TLE) (*Satellite, error) {
+	const deg = math.Pi / 180
+	s := &Satellite{
+		TLE:   tle,
+		ecco:  tle.Eccentricity,
+		inclo: tle.Inclination * deg,
+		argpo: tle.ArgPerigee * deg,
+		nodeo: tle.RAAN * deg,
+		mo:    tle.MeanAnomaly * deg,
+		bstar: tle.BStar,
+	}
+	noKozai := tle.MeanMotion * twoPi / 1440
+	if noKozai <= 0 {
+		return nil, fmt.Errorf("TLE %d: mean motion must be positive", tle.Norad)
+	}
+
+	// Recover the original mean motion and semi-major axis from the Kozai mean motion
+	eccsq := s.ecco * s.ecco
+	omeosq := 1 - eccsq
+	rteosq := math.Sqrt(omeosq)
+	cosio := math.Cos(s.inclo)
+	cosio2 := cosio * cosio
+	ak := math.Pow(xke/noKozai, x2o3)
+	d1 := 0.75 * j2 * (3*cosio2 - 1) / (rteosq * omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1 - del*del - del*(1.0/3.0+134*del*del/81))
+	del = d1 / (adel * adel)
+	s.no = noKozai / (1 + del)
+	if twoPi/s.no >= 225 {
+		return nil, fmt.Errorf("TLE %d: %w", tle.Norad, ErrDeepSpace)
+	}
+
+	ao := math.Pow(xke/s.no, x2o3)
+	sinio := math.Sin(s.inclo)
+	po := ao * omeosq
+	con42 := 1 - 5*cosio2
+	s.con41 = -con42 - cosio2 - cosio2
+	posq := po * po
+	rp := ao * (1 - s.ecco)
+	s.isimp = rp < 220/earthRadius+1
+
+	// Atmospheric density parameters, adjusted for low perigees
+	sfour := 78/earthRadius + 1
+	qzms24 := math.Pow((120-78)/earthRadius, 4)
+	perige := (rp - 1) * earthRadius
+	if perige < 156 {
+		sfour = perige - 78
+		if perige < 98 {
+			sfour = 20
+		}
+		qzms24 = math.Pow((120-sfour)/earthRadius, 4)
+		sfour = sfour/earthRadius + 1
+	}
+
+	pinvsq := 1 / posq
+	tsi := 1 / (ao - sfour)
+	s.eta = ao * s.ecco * tsi
+	etasq := s.eta * s.eta
+	eeta := s.ecco * s.eta
+	psisq := math.Abs(1 - etasq)
+	coef := qzms24 * math.Pow(tsi, 4)
+	coef1 := coef / math.Pow(psisq, 3.5)
+	cc2 := coef1 * s.no * (ao*(1+1.5*etasq+eeta*(4+etasq)) +
+		0.375*j2*tsi/psisq*s.con41*(8+3*etasq*(8+etasq)))
+	s.cc1 = s.bstar * cc2
+	cc3 := 0.0
+	if s.ecco > 1e-4 {
+		cc3 = -2 * coef * tsi * j3oj2 * s.no * sinio / s.ecco
+	}
+	s.x1mth2 = 1 - cosio2
+	s.cc4 = 2 * s.no * coef1 * ao * omeosq * (s.eta*(2+0.5*etasq) + s.ecco*(0.5+2*etasq) -
+		j2*tsi/(ao*psisq)*(-3*s.con41*(1-2*eeta+etasq*(1.5-0.5*eeta))+
+			0.75*s.x1mth2*(2*etasq-eeta*(1+etasq))*math.Cos(2*s.argpo)))
+	s.cc5 = 2 * coef1 * ao * omeosq * (1 + 2.75*(etasq+eeta) + eeta*etasq)
+
+	// Secular rates from the earth's oblateness
+	cosio4 := cosio2 * cosio2
+	temp1 := 1.5 * j2 * pinvsq * s.no
+	temp2 := 0.5 * temp1 * j2 * pinvsq
+	temp3 := -0.46875 * j4 * pinvsq * pinvsq * s.no
+	s.mdot = s.no + 0.5*temp1*rteosq*s.con41 + 0.0625*temp2*rteosq*(13-78*cosio2+137*cosio4)
+	s.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7-114*cosio2+395*cosio4) + temp3*(3-36*cosio2+49*cosio4)
+	xhdot1 := -temp1 * cosio
+	s.nodedot = xhdot1 + (0.5*temp2*(4-19*cosio2)+2*temp3*(3-7*cosio2))*cosio
+	s.omgcof = s.bstar * cc3 * math.Cos(s.argpo)
+	if s.ecco > 1e-4 {
+		s.xmcof = -x2o3 * coef * s.bstar / eeta
+	}
+	s.nodecf = 3.5 * omeosq * xhdot1 * s.cc1
+	s.t2cof = 1.5 * s.cc1
+	if math.Abs(cosio+1) > 1.5e-12 {
+		s.xlcof = -0.25 * j3oj2 * sinio * (3 + 5*cosio) / (1 + cosio)
+	} else {
+		s.xlcof = -0.25 * j3oj2 * sinio * (3 + 5*cosio) / 1.5e-12
+	}
+	s.aycof = -0.5 * j3oj2 * sinio
+	s.delmo = math.Pow(1+s.eta*math.Cos(s.mo), 3)
+	s.sinmao = math.Sin(s.mo)
+	s.x7thm1 = 7*cosio2 - 1
+
+	if !s.isimp {
+		cc1sq := s.cc1 * s.cc1
+		s.d2 = 4 * ao * tsi * cc1sq
+		temp := s.d2 * tsi * s.cc1 / 3
+		s.d3 = (17*ao + sfour) * temp
+		s.d4 = 0.5 * temp * ao * tsi * (221*ao + 31*sfour) * s.cc1
+		s.t3cof = s.d2 + 2*cc1sq
+		s.t4cof = 0.25 * (3*s.d3 + s.cc1*(12*s.d2+10*cc1sq))
+		s.t5cof = 0.2 * (3*s.d4 + 12*s.cc1*s.d3 + 6*s.d2*s.d2 + 15*cc1sq*(2*s.d2+cc1sq))
+	}
+	return s, nil
+}
+
+// Propagate returns the satellite's position (km) and velocity (km/s) at t in the
+// TEME frame: true equator, mean equinox of date
+func (s *Satellite) Propagate(t time.Time) (Vector, Vector, error) {
+	return s.propagate(t.Sub(s.TLE.Epoch).Minutes())
+}
+
+// propagate runs SGP4 for tsince minutes after the element set's epoch
+func (s *Satellite) propagate(tsince float64) (Vector, Vector, error) {
+	// Secular gravity and drag
+	xmdf := s.mo + s.mdot*tsince
+	argpdf := s.argpo + s.argpdot*tsince
+	nodedf := s.nodeo + s.nodedot*tsince
+	argpm, mm := argpdf, xmdf
+	t2 := tsince * tsince
+	nodem := nodedf + s.nodecf*t2
+	tempa := 1 - s.cc1*tsince
+	tempe := s.bstar * s.cc4 * tsince
+	templ := s.t2cof * t2
+	if !s.isimp {
+		delomg := s.omgcof * tsince
+		delm := s.xmcof * (math.Pow(1+s.eta*math.Cos(xmdf), 3) - s.delmo)
+		temp := delomg + delm
+		mm = xmdf + temp
+		argpm = argpdf - temp
+		t3 := t2 * tsince
+		t4 := t3 * tsince
+		tempa = tempa - s.d2*t2 - s.d3*t3 - s.d4*t4
+		tempe += s.bstar * s.cc5 * (math.Sin(mm) - s.sinmao)
+		templ += s.t3cof*t3 + t4*(s.t4cof+tsince*s.t5cof)
+	}
+
+	am := math.Pow(xke/s.no, x2o3) * tempa * tempa
+	nm := xke / math.Pow(am, 1.5)
+	em := s.ecco - tempe
+	if em >= 1 || em < -0.001 || am < 0.95 {
+		return Vector{}, Vector{}, ErrDecayed
+	}
+	if em < 1e-6 {
+		em = 1e-6
+	}
+	mm += s.no * templ
+	xlm := mm + argpm + nodem
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+
+	// Long period periodics
+	sinim, cosim := math.Sin(s.inclo), math.Cos(s.inclo)
+	axnl := em * math.Cos(argpm)
+	temp := 1 / (am * (1 - em*em))
+	aynl := em*math.Sin(argpm) + temp*s.aycof
+	xl := mm + argpm + nodem + temp*s.xlcof*axnl
+
+	// Kepler's equation
+	u := math.Mod(xl-nodem, twoPi)
+	eo1 := u
+	var sineo1, coseo1 float64
+	tem5 := 9999.9
+	for ktr := 1; math.Abs(tem5) >= 1e-12 && ktr <= 10; ktr++ {
+		sineo1, coseo1 = math.Sin(eo1), math.Cos(eo1)
+		tem5 = 1 - coseo1*axnl - sineo1*aynl
+		tem5 = (u - aynl*coseo1 + axnl*sineo1 - eo1) / tem5
+		if math.Abs(tem5) >= 0.95 {
+			tem5 = math.Copysign(0.95, tem5)
+		}
+		eo1 += tem5
+	}
+
+	// Short period periodics
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1 - el2)
+	if pl < 0 {
+		return Vector{}, Vector{}, ErrDecayed
+	}
+	rl := am * (1 - ecose)
+	rdotl := math.Sqrt(am) * esine / rl
+	rvdotl := math.Sqrt(pl) / rl
+	betal := math.Sqrt(1 - el2)
+	temp = esine / (1 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := (cosu + cosu) * sinu
+	cos2u := 1 - 2*sinu*sinu
+	temp = 1 / pl
+	temp1 := 0.5 * j2 * temp
+	temp2 := temp1 * temp
+
+	mrt := rl*(1-1.5*temp2*betal*s.con41) + 0.5*temp1*s.x1mth2*cos2u
+	su -= 0.25 * temp2 * s.x7thm1 * sin2u
+	xnode := nodem + 1.5*temp2*cosim*sin2u
+	xinc := s.inclo + 1.5*temp2*cosim*sinim*cos2u
+	mvt := rdotl - nm*temp1*s.x1mth2*sin2u/xke
+	rvdot := rvdotl + nm*temp1*(s.x1mth2*cos2u+1.5*s.con41)/xke
+	if mrt < 1 {
+		return Vector{}, Vector{}, ErrDecayed
+	}
+
+	// Orientation vectors
+	sinsu, cossu := math.Sin(su), math.Cos(su)
+	snod, cnod := math.Sin(xnode), math.Cos(xnode)
+	sini, cosi := math.Sin(xinc), math.Cos(xinc)
+	xmx := -snod * cosi
+	xmy := cnod * cosi
+	ux := xmx*sinsu + cnod*cossu
+	uy := xmy*sinsu + snod*cossu
+	uz := sini * sinsu
+	vx := xmx*cossu - cnod*sinsu
+	vy := xmy*cossu - snod*sinsu
+	vz := sini * cossu
+
+	r := Vector{mrt * ux * earthRadius, mrt * uy * earthRadius, mrt * uz * earthRadius}
+	v := Vector{
+		(mvt*ux + rvdot*vx) * kmPerSec,
+		(mvt*uy + rvdot*vy) * kmPerSec,
+		(mvt*uz + rvdot*vz) * kmPerSec,
+	}
+	return r, v, nil
+}
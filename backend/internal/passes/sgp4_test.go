@@ -0,0 +1,120 @@
+package passes
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustSatellite(t *testing.T, name, line1, line2 string) *Satellite {
+	t.Helper()
+	tle, err := ParseTLE(name, line1, line2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sat, err := NewSatellite(tle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sat
+}
+
+func within(a, b Vector, tolerance float64) bool {
+	return a.sub(b).norm() <= tolerance
+}
+
+// The vectors are from the verification output published with Vallado et al.,
+// "Revisiting Spacetrack Report #3" (tcppver.out), and from Spacetrack Report #3
+// itself for 88888, whose older constants put it a few meters off
+func TestPropagateVerificationVectors(t *testing.T) {
+	tests := []struct {
+		name         string
+		line1, line2 string
+		tsince       float64
+		r, v         Vector
+		rTolerance   float64
+	}{
+		{
+			name:       "00005 at epoch",
+			line1:      "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+			line2:      "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667",
+			tsince:     0,
+			r:          Vector{7022.46529266, -1400.08296755, 0.03995155},
+			v:          Vector{1.893841015, 6.405893759, 4.534807250},
+			rTolerance: 1e-3,
+		},
+		{
+			name:       "00005 after 360 minutes",
+			line1:      "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+			line2:      "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667",
+			tsince:     360,
+			r:          Vector{-7154.03120202, -3783.17682504, -3536.19412294},
+			v:          Vector{4.741887409, -4.151817765, -2.093935425},
+			rTolerance: 1e-3,
+		},
+		{
+			name:       "00005 after 720 minutes",
+			line1:      "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+			line2:      "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667",
+			tsince:     720,
+			r:          Vector{-7134.59340119, 6531.68641334, 3260.27186483},
+			v:          Vector{-4.113793027, -2.911922039, -2.557327851},
+			rTolerance: 1e-3,
+		},
+		{
+			name:       "88888 at epoch",
+			line1:      "1 88888U          80275.98708465  .00073094  13844-3  66816-4 0    87",
+			line2:      "2 88888  72.8435 115.9689 0086731  52.6988 110.5714 16.05824518  1058",
+			tsince:     0,
+			r:          Vector{2328.97048951, -5995.22076416, 1719.97067261},
+			v:          Vector{2.91207230, -0.98341546, -7.09081703},
+			rTolerance: 1e-2,
+		},
+		{
+			name:       "88888 after 360 minutes",
+			line1:      "1 88888U          80275.98708465  .00073094  13844-3  66816-4 0    87",
+			line2:      "2 88888  72.8435 115.9689 0086731  52.6988 110.5714 16.05824518  1058",
+			tsince:     360,
+			r:          Vector{2456.10705566, -6071.93853760, 1222.89727783},
+			v:          Vector{2.67938992, -0.44829041, -7.22879231},
+			rTolerance: 1e-2,
+		},
+	}
+
+	for _, tt := range tests {
+		sat := mustSatellite(t, "", tt.line1, tt.line2)
+		r, v, err := sat.propagate(tt.tsince)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !within(r, tt.r, tt.rTolerance) {
+			t.Errorf("%s: r = %+v, want %+v", tt.name, r, tt.r)
+		}
+		if !within(v, tt.v, tt.rTolerance/1000) {
+			t.Errorf("%s: v = %+v, want %+v", tt.name, v, tt.v)
+		}
+	}
+}
+
+func TestPropagateAtEpochTime(t *testing.T) {
+	sat := mustSatellite(t, "",
+		"1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+		"2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667")
+
+	r, _, err := sat.Propagate(sat.TLE.Epoch.Add(360 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Vector{-7154.03120202, -3783.17682504, -3536.19412294}); !within(r, want, 1e-3) {
+		t.Errorf("r = %+v, want %+v", r, want)
+	}
+}
+
+func TestNewSatelliteRejectsDeepSpace(t *testing.T) {
+	// Molniya orbits take about 12 hours
+	tle := TLE{Norad: 8195, MeanMotion: 2.0, Eccentricity: 0.7, Inclination: 63.4, Epoch: time.Now()}
+	if _, err := NewSatellite(tle); !errors.Is(err, ErrDeepSpace) {
+		t.Errorf("NewSatellite = %v, want ErrDeepSpace", err)
+	}
+}
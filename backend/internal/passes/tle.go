@@ -0,0 +1,216 @@
+// Package passes predicts when satellites such as the ISS can be seen from the ground.
+// It reads two-line element sets (TLEs), propagates them with SGP4 and searches for
+// passes over an observer, working out when each pass rises, culminates and sets, how
+// bright it is, and whether it can actually be seen: the station lit by the sun
+// against a dark sky.
+package passes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLE is one satellite's two-line element set
+type TLE struct {
+	Name  string
+	Norad int
+	Epoch time.Time
+	// Inclination, RAAN, ArgPerigee and MeanAnomaly are in degrees
+	Inclination  float64
+	RAAN         float64
+	Eccentricity float64
+	ArgPerigee   float64
+	MeanAnomaly  float64
+	// MeanMotion is in revolutions per day
+	MeanMotion float64
+	// BStar is the drag term in inverse earth radii
+	BStar float64
+	// Revolution is the orbit number at epoch
+	Revolution int
+	Line1      string
+	Line2      string
+}
+
+// ParseTLE parses a two-line element set, with or without the name line before it
+func ParseTLE(name, line1, line2 string) (TLE, error) {
+	line1, line2 = strings.TrimRight(line1, " \r"), strings.TrimRight(line2, " \r")
+	if len(line1) < 69 || len(line2) < 69 || line1[0] != '1' || line2[0] != '2' {
+		return TLE{}, fmt.Errorf("not a two-line element set: %q", line1)
+	}
+	for _, line := range []string{line1, line2} {
+		if !validChecksum(line) {
+			return TLE{}, fmt.Errorf("bad checksum: %q", line)
+		}
+	}
+
+	t := TLE{Name: strings.TrimSpace(strings.TrimPrefix(name, "0 ")), Line1: line1, Line2: line2}
+	var errs []error
+	field := func(line string, from, to int) float64 {
+		v, err := strconv.ParseFloat(strings.TrimSpace(line[from:to]), 64)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return v
+	}
+
+	t.Norad = int(field(line1, 2, 7))
+	year := int(field(line1, 18, 20))
+	day := field(line1, 20, 32)
+	if year < 57 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	t.Epoch = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration((day - 1) * 24 * float64(time.Hour)))
+	t.BStar = exponent(line1[53:61], &errs)
+
+	t.Inclination = field(line2, 8, 16)
+	t.RAAN = field(line2, 17, 25)
+	t.Eccentricity = field(line2, 26, 33) / 1e7
+	t.ArgPerigee = field(line2, 34, 42)
+	t.MeanAnomaly = field(line2, 43, 51)
+	t.MeanMotion = field(line2, 52, 63)
+	t.Revolution = int(field(line2, 63, 68))
+
+	if err := errors.Join(errs...); err != nil {
+		return TLE{}, fmt.Errorf("TLE %d: %w", t.Norad, err)
+	}
+	if t.Name == "" {
+		t.Name = strconv.Itoa(t.Norad)
+	}
+	return t, nil
+}
+
+// ReadTLEs reads every element set in r, in the two or three line format CelesTrak
+// and Space-Track serve
+func ReadTLEs(r io.Reader) ([]TLE, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), " \r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var tles []TLE
+	for i := 0; i < len(lines); i++ {
+		name := ""
+		if !strings.HasPrefix(lines[i], "1 ") {
+			name = lines[i]
+			i++
+		}
+		if i+1 >= len(lines) {
+			return nil, fmt.Errorf("truncated element set after %q", lines[len(lines)-1])
+		}
+		tle, err := ParseTLE(name, lines[i], lines[i+1])
+		if err != nil {
+			return nil, err
+		}
+		tles = append(tles, tle)
+		i++
+	}
+	return tles, nil
+}
+
+// tleHTTPClient fetches element sets; CelesTrak can be slow to answer when busy
+var tleHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// LoadTLEs reads element sets from a file path or an http(s) URL
+func LoadTLEs(source string) ([]TLE, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ReadTLEs(file)
+	}
+
+	resp, err := tleHTTPClient.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("fetch TLEs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch TLEs: %s returned %s", source, resp.Status)
+	}
+	return ReadTLEs(resp.Body)
+}
+
+// Find returns the element set for a NORAD catalog number or, failing that, the first
+// whose name contains name, ignoring case
+func Find(tles []TLE, name string) (TLE, bool) {
+	if norad, err := strconv.Atoi(strings.TrimSpace(name)); err == nil {
+		for _, tle := range tles {
+			if tle.Norad == norad {
+				return tle, true
+			}
+		}
+		return TLE{}, false
+	}
+	for _, tle := range tles {
+		if strings.Contains(strings.ToLower(tle.Name), strings.ToLower(name)) {
+			return tle, true
+		}
+	}
+	return TLE{}, false
+}
+
+// ShortName is the name without CelesTrak's module suffix: "ISS (ZARYA)" is "ISS"
+func (t TLE) ShortName() string {
+	name, _, _ := strings.Cut(t.Name, " (")
+	return name
+}
+
+// validChecksum checks the last digit of a line: the sum of its digits, with each
+// minus sign counting as one, modulo ten
+func validChecksum(line string) bool {
+	sum := 0
+	for _, c := range line[:68] {
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
+	}
+	return int(line[68]-'0') == sum%10
+}
+
+// exponent parses the TLE's assumed decimal point notation, e.g. " 28098-4" for
+// 0.28098e-4
+func exponent(s string, errs *[]error) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	sign := 1.0
+	switch s[0] {
+	case '-':
+		sign, s = -1, s[1:]
+	case '+':
+		s = s[1:]
+	}
+	if len(s) < 2 {
+		*errs = append(*errs, fmt.Errorf("bad exponent field %q", s))
+		return 0
+	}
+	mantissa, err1 := strconv.ParseFloat("0."+strings.TrimSpace(s[:len(s)-2]), 64)
+	exp, err2 := strconv.Atoi(s[len(s)-2:])
+	if err := errors.Join(err1, err2); err != nil {
+		*errs = append(*errs, err)
+		return 0
+	}
+	return sign * mantissa * math.Pow(10, float64(exp))
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -174,3 +176,107 @@ func (c *Client) FindRecordByField(collection, field string, value interface{})
 	}
 	return nil, nil
 }
+
+// ListAllRecords returns every record in a collection matching a filter, following pagination.
+// Relations named in expand are included under each record's "expand" key.
+func (c *Client) ListAllRecords(collection, filter, expand string) ([]map[string]interface{}, error) {
+	var all []map[string]interface{}
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("perPage", "200")
+		if filter != "" {
+			query.Set("filter", filter)
+		}
+		if expand != "" {
+			query.Set("expand", expand)
+		}
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/collections/%s/records?%s", c.BaseURL, collection, query.Encode()), nil)
+		req.Header.Set("Authorization", c.Token)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var data struct {
+			TotalPages int                      `json:"totalPages"`
+			Items      []map[string]interface{} `json:"items"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&data)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode >= 300 {
+			return nil, fmt.Errorf("failed to list %s: %s", collection, res.Status)
+		}
+
+		all = append(all, data.Items...)
+		if len(data.Items) == 0 || page >= data.TotalPages {
+			return all, nil
+		}
+	}
+}
+
+// QueryRecords returns up to perPage records matching filter in the given sort order,
+// e.g. sort "-created_on" for newest first
+func (c *Client) QueryRecords(collection, filter, sort, expand string, perPage int) ([]map[string]interface{}, error) {
+	query := url.Values{}
+	query.Set("perPage", strconv.Itoa(perPage))
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+	if sort != "" {
+		query.Set("sort", sort)
+	}
+	if expand != "" {
+		query.Set("expand", expand)
+	}
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/collections/%s/records?%s", c.BaseURL, collection, query.Encode()), nil)
+	req.Header.Set("Authorization", c.Token)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to query %s: %s", collection, res.Status)
+	}
+
+	var data struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data.Items, nil
+}
+
+// UpdateRecord patches fields on an existing record
+func (c *Client) UpdateRecord(collection, id string, data map[string]interface{}) error {
+	url := fmt.Sprintf("%s/api/collections/%s/records/%s", c.BaseURL, collection, id)
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	req, _ := http.NewRequest("PATCH", url, bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Failed to update record %s: %s", id, res.Status)
+	}
+
+	return nil
+}
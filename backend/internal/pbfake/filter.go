@@ -0,0 +1,348 @@
+package pbfake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The filter syntax supported here is the subset of PocketBase's used by this repo:
+// comparisons joined with && and ||, parentheses, quoted or bare literals, the
+// = != > >= < <= ~ !~ operators (and their ?-prefixed "any element" forms), and the
+// LOWER()/TRIM() wrappers the title lookup uses.
+
+type filterExpr interface {
+	eval(record map[string]any) bool
+}
+
+type orExpr []filterExpr
+
+func (e orExpr) eval(record map[string]any) bool {
+	for _, sub := range e {
+		if sub.eval(record) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []filterExpr
+
+func (e andExpr) eval(record map[string]any) bool {
+	for _, sub := range e {
+		if !sub.eval(record) {
+			return false
+		}
+	}
+	return true
+}
+
+type operand struct {
+	field   string
+	literal any
+	lower   bool
+	trim    bool
+}
+
+func (o operand) value(record map[string]any) any {
+	var v any = o.literal
+	if o.field != "" {
+		v = record[o.field]
+	}
+	if s, ok := v.(string); ok {
+		if o.trim {
+			s = strings.TrimSpace(s)
+		}
+		if o.lower {
+			s = strings.ToLower(s)
+		}
+		return s
+	}
+	return v
+}
+
+type comparison struct {
+	left, right operand
+	op          string
+}
+
+func (c comparison) eval(record map[string]any) bool {
+	left := c.left.value(record)
+	right := c.right.value(record)
+
+	op := c.op
+	anyElement := strings.HasPrefix(op, "?")
+	op = strings.TrimPrefix(op, "?")
+
+	// Multi-value fields match when any element does, for = as well as ?=
+	if list, ok := left.([]any); ok && (anyElement || op == "=" || op == "~") {
+		for _, item := range list {
+			if compareOp(item, right, op) {
+				return true
+			}
+		}
+		return false
+	}
+	return compareOp(left, right, op)
+}
+
+func compareOp(left, right any, op string) bool {
+	switch op {
+	case "=":
+		return compareValues(left, right) == 0
+	case "!=":
+		return compareValues(left, right) != 0
+	case ">":
+		return compareValues(left, right) > 0
+	case ">=":
+		return compareValues(left, right) >= 0
+	case "<":
+		return compareValues(left, right) < 0
+	case "<=":
+		return compareValues(left, right) <= 0
+	case "~":
+		return strings.Contains(strings.ToLower(stringValue(left)), strings.ToLower(stringValue(right)))
+	case "!~":
+		return !strings.Contains(strings.ToLower(stringValue(left)), strings.ToLower(stringValue(right)))
+	}
+	return false
+}
+
+// compareValues orders two record values, numerically when both are numbers
+func compareValues(a, b any) int {
+	af, aNum := numberValue(a)
+	bf, bNum := numberValue(b)
+	if aNum && bNum {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(stringValue(a), stringValue(b))
+}
+
+func numberValue(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case nil:
+		return 0, false
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func stringValue(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// parseFilter parses a PocketBase filter expression
+func parseFilter(filter string) (filterExpr, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter %q", p.tokens[p.pos].text, filter)
+	}
+	return expr, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+var filterOperators = []string{"?!=", "?>=", "?<=", "?!~", "&&", "||", "!=", ">=", "<=", "!~", "?=", "?>", "?<", "?~", "=", ">", "<", "~", "(", ")"}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string in filter %q", s)
+			}
+			tokens = append(tokens, token{text: b.String(), quoted: true})
+			i = j + 1
+		default:
+			matched := false
+			for _, op := range filterOperators {
+				if strings.HasPrefix(s[i:], op) {
+					tokens = append(tokens, token{text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\"'()=!<>~&|?", rune(s[j])) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected %q in filter %q", s[i], s)
+			}
+			tokens = append(tokens, token{text: s[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	var terms orExpr
+	for {
+		term, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if p.peek() != "||" {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	var terms andExpr
+	for {
+		term, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if p.peek() != "&&" {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *filterParser) parsePrimary() (filterExpr, error) {
+	if p.peek() == "(" {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expr, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	if op == "" || op == "(" || op == ")" || op == "&&" || op == "||" {
+		return nil, fmt.Errorf("expected an operator after %q", left.field)
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return comparison{left: left, right: right, op: op}, nil
+}
+
+func (p *filterParser) parseOperand() (operand, error) {
+	if p.pos >= len(p.tokens) {
+		return operand{}, fmt.Errorf("unexpected end of filter")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	if tok.quoted {
+		return operand{literal: tok.text}, nil
+	}
+
+	// LOWER(x) / TRIM(x)
+	if fn := strings.ToUpper(tok.text); (fn == "LOWER" || fn == "TRIM") && p.peek() == "(" {
+		p.pos++
+		inner, err := p.parseOperand()
+		if err != nil {
+			return operand{}, err
+		}
+		if p.peek() != ")" {
+			return operand{}, fmt.Errorf("missing closing parenthesis after %s", fn)
+		}
+		p.pos++
+		if fn == "LOWER" {
+			inner.lower = true
+		} else {
+			inner.trim = true
+		}
+		return inner, nil
+	}
+
+	switch tok.text {
+	case "true":
+		return operand{literal: true}, nil
+	case "false":
+		return operand{literal: false}, nil
+	case "null":
+		return operand{literal: nil}, nil
+	}
+	if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+		return operand{literal: f}, nil
+	}
+	return operand{field: tok.text}, nil
+}
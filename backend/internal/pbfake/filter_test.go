@@ -0,0 +1,75 @@
+package pbfake
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	record := map[string]any{
+		"api_id":     float64(44),
+		"name":       "Expedition 72",
+		"title":      "  Crew-10 Launch ",
+		"status":     "Go",
+		"active":     true,
+		"crew":       []any{"astro1", "astro2"},
+		"quote":      `Dragon "Endurance"`,
+		"apostrophe": "O'Hara",
+		"empty":      "",
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`api_id=44`, true},
+		{`api_id="44"`, true},
+		{`api_id>40 && api_id<50`, true},
+		{`api_id>=45`, false},
+		{`name="Expedition 72"`, true},
+		{`name='Expedition 72'`, true},
+		{`name="expedition 72"`, false},
+		{`status!="Go"`, false},
+		{`status!="Hold"`, true},
+		{`name~"pedition"`, true},
+		{`name~"PEDITION"`, true},
+		{`name!~"Crew"`, true},
+		{`api_id=44 && status="Hold"`, false},
+		{`api_id=1 || status="Go"`, true},
+		{`api_id=1 || status="Hold"`, false},
+		{`api_id=1 || api_id=44 && status="Go"`, true},
+		{`(api_id=1 || api_id=44) && status="Hold"`, false},
+		{`(api_id=1 || api_id=44) && (status="Go" || status="Hold")`, true},
+		{`active=true`, true},
+		{`active=false`, false},
+		{`crew="astro2"`, true},
+		{`crew?="astro3"`, false},
+		{`quote="Dragon \"Endurance\""`, true},
+		{`apostrophe="O'Hara"`, true},
+		{`apostrophe='O\'Hara'`, true},
+		{`empty=""`, true},
+		{`missing=""`, true},
+		{`(LOWER(TRIM(title))='crew-10 launch')`, true},
+	}
+	for _, tt := range tests {
+		expr, err := parseFilter(tt.filter)
+		if err != nil {
+			t.Errorf("parseFilter(%s): %v", tt.filter, err)
+			continue
+		}
+		if got := expr.eval(record); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, filter := range []string{
+		`name="unterminated`,
+		`(api_id=44`,
+		`api_id=44 &&`,
+		`api_id`,
+		`api_id=44 status="Go"`,
+	} {
+		if _, err := parseFilter(filter); err == nil {
+			t.Errorf("parseFilter(%s) succeeded, want an error", filter)
+		}
+	}
+}
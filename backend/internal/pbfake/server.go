@@ -0,0 +1,350 @@
+// Package pbfake is an in-memory stand-in for the PocketBase records API. It keeps
+// just enough of the REST surface (auth, list with filter/sort/expand, view, create,
+// update, delete) to run the syncers end-to-end without a PocketBase instance.
+package pbfake
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a schemaless PocketBase fake. Collections are created on first write.
+type Server struct {
+	mu          sync.Mutex
+	collections map[string][]map[string]any
+}
+
+// New returns an empty fake
+func New() *Server {
+	return &Server{collections: make(map[string][]map[string]any)}
+}
+
+// Records returns a copy of every record stored in a collection, in insertion order
+func (s *Server) Records(collection string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]map[string]any, 0, len(s.collections[collection]))
+	for _, record := range s.collections[collection] {
+		records = append(records, copyRecord(record))
+	}
+	return records
+}
+
+// Counts returns the number of records per collection
+func (s *Server) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int, len(s.collections))
+	for name, records := range s.collections {
+		counts[name] = len(records)
+	}
+	return counts
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/auth-with-password") {
+		writeJSON(w, http.StatusOK, map[string]any{"token": "pbfake-token"})
+		return
+	}
+
+	// /api/collections/{collection}/records[/{id}]
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "api" || parts[1] != "collections" || parts[3] != "records" {
+		writeError(w, http.StatusNotFound, "The requested resource wasn't found.")
+		return
+	}
+	collection := parts[2]
+	id := ""
+	if len(parts) > 4 {
+		id = parts[4]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		s.list(w, r, collection)
+	case r.Method == http.MethodGet:
+		s.view(w, r, collection, id)
+	case r.Method == http.MethodPost && id == "":
+		s.create(w, r, collection)
+	case r.Method == http.MethodPatch && id != "":
+		s.update(w, r, collection, id)
+	case r.Method == http.MethodDelete && id != "":
+		s.delete(w, collection, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Unsupported method.")
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, collection string) {
+	query := r.URL.Query()
+
+	var match func(map[string]any) bool
+	if filter := query.Get("filter"); filter != "" {
+		expr, err := parseFilter(filter)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		match = expr.eval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []map[string]any{}
+	for _, record := range s.collections[collection] {
+		if match == nil || match(record) {
+			items = append(items, record)
+		}
+	}
+
+	if sortSpec := query.Get("sort"); sortSpec != "" {
+		sortRecords(items, sortSpec)
+	}
+
+	page := atoiDefault(query.Get("page"), 1)
+	perPage := atoiDefault(query.Get("perPage"), 30)
+	totalItems := len(items)
+	totalPages := (totalItems + perPage - 1) / perPage
+
+	start := (page - 1) * perPage
+	if start > totalItems {
+		start = totalItems
+	}
+	end := start + perPage
+	if end > totalItems {
+		end = totalItems
+	}
+
+	pageItems := make([]map[string]any, 0, end-start)
+	for _, record := range items[start:end] {
+		pageItems = append(pageItems, s.expand(record, query.Get("expand")))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"page":       page,
+		"perPage":    perPage,
+		"totalItems": totalItems,
+		"totalPages": totalPages,
+		"items":      pageItems,
+	})
+}
+
+func (s *Server) view(w http.ResponseWriter, r *http.Request, collection, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.find(collection, id)
+	if record == nil {
+		writeError(w, http.StatusNotFound, "The requested resource wasn't found.")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.expand(record, r.URL.Query().Get("expand")))
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, collection string) {
+	var data map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Format("2006-01-02 15:04:05.000Z")
+	record := copyRecord(data)
+	if id, _ := record["id"].(string); id == "" {
+		record["id"] = newID()
+	}
+	record["collectionName"] = collection
+	record["created"] = now
+	record["updated"] = now
+
+	s.collections[collection] = append(s.collections[collection], record)
+	writeJSON(w, http.StatusOK, copyRecord(record))
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, collection, id string) {
+	var data map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.find(collection, id)
+	if record == nil {
+		writeError(w, http.StatusNotFound, "The requested resource wasn't found.")
+		return
+	}
+	for key, value := range data {
+		if key == "id" {
+			continue
+		}
+		record[key] = value
+	}
+	record["updated"] = time.Now().UTC().Format("2006-01-02 15:04:05.000Z")
+	writeJSON(w, http.StatusOK, copyRecord(record))
+}
+
+func (s *Server) delete(w http.ResponseWriter, collection, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := s.collections[collection]
+	for i, record := range records {
+		if record["id"] == id {
+			s.collections[collection] = append(records[:i], records[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "The requested resource wasn't found.")
+}
+
+func (s *Server) find(collection, id string) map[string]any {
+	for _, record := range s.collections[collection] {
+		if record["id"] == id {
+			return record
+		}
+	}
+	return nil
+}
+
+// findAnywhere resolves a relation ID without knowing the target collection. IDs are
+// random, so they are unique across collections.
+func (s *Server) findAnywhere(id string) map[string]any {
+	for _, records := range s.collections {
+		for _, record := range records {
+			if record["id"] == id {
+				return record
+			}
+		}
+	}
+	return nil
+}
+
+// expand returns a copy of record with the named relation fields resolved under "expand"
+func (s *Server) expand(record map[string]any, fields string) map[string]any {
+	out := copyRecord(record)
+	if fields == "" {
+		return out
+	}
+
+	expanded := map[string]any{}
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		switch value := record[field].(type) {
+		case string:
+			if related := s.findAnywhere(value); related != nil {
+				expanded[field] = copyRecord(related)
+			}
+		case []any:
+			list := []any{}
+			for _, item := range value {
+				if id, ok := item.(string); ok {
+					if related := s.findAnywhere(id); related != nil {
+						list = append(list, copyRecord(related))
+					}
+				}
+			}
+			if len(list) > 0 {
+				expanded[field] = list
+			}
+		}
+	}
+	if len(expanded) > 0 {
+		out["expand"] = expanded
+	}
+	return out
+}
+
+// sortRecords applies a PocketBase sort spec such as "-created_on,name"
+func sortRecords(items []map[string]any, spec string) {
+	keys := strings.Split(spec, ",")
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, key := range keys {
+			key = strings.TrimSpace(key)
+			desc := strings.HasPrefix(key, "-")
+			key = strings.TrimLeft(key, "-+")
+
+			c := compareValues(items[i][key], items[j][key])
+			if c == 0 {
+				continue
+			}
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+func copyRecord(record map[string]any) map[string]any {
+	out := make(map[string]any, len(record))
+	for key, value := range record {
+		out[key] = value
+	}
+	return out
+}
+
+func newID() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 15)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b)
+}
+
+func atoiDefault(s string, fallback int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"status":  status,
+		"message": message,
+		"data":    map[string]any{},
+	})
+}
+
+// String summarizes the stored collections, e.g. for logging after a replay
+func (s *Server) String() string {
+	counts := s.Counts()
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, counts[name]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,211 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// Launch change kinds, classified each time a re-sync moves a launch
+const (
+	LaunchNetLater      = "net_later"
+	LaunchNetEarlier    = "net_earlier"
+	LaunchWindowChanged = "window_changed"
+	LaunchHold          = "hold"
+	LaunchTBD           = "tbd"
+	LaunchScrub         = "scrub"
+	LaunchNewDate       = "new_date"
+)
+
+// LaunchChangeKinds lists every launch change kind
+var LaunchChangeKinds = []string{
+	LaunchNetLater,
+	LaunchNetEarlier,
+	LaunchWindowChanged,
+	LaunchHold,
+	LaunchTBD,
+	LaunchScrub,
+	LaunchNewDate,
+}
+
+// scrubWindow is how close to NET a move has to happen to count as a scrub rather
+// than a slip
+const scrubWindow = 2 * time.Hour
+
+// LaunchSchedule is the part of a launch that moves as it approaches
+type LaunchSchedule struct {
+	Net         time.Time
+	WindowStart time.Time
+	WindowEnd   time.Time
+	Status      string
+}
+
+// LaunchChange is a classified change to a launch's schedule. Delta is how far the NET
+// moved, later being positive.
+type LaunchChange struct {
+	Kind       string
+	Event      string
+	Title      string
+	From       LaunchSchedule
+	To         LaunchSchedule
+	Delta      time.Duration
+	DetectedAt time.Time
+}
+
+// LaunchChangeHandler is called with every launch change the sync classifies
+type LaunchChangeHandler func(LaunchChange)
+
+var launchChangeHandlers []LaunchChangeHandler
+
+// OnLaunchChange registers a handler for launch changes classified in this process.
+// Every change is also stored in event_revisions, which is how other processes such as
+// cmd/notify consume them.
+func OnLaunchChange(handler LaunchChangeHandler) {
+	launchChangeHandlers = append(launchChangeHandlers, handler)
+}
+
+// ClassifyLaunchChanges compares a launch's schedule before and after a sync. A NET
+// moving once the previous NET was less than two hours away is a scrub; the next NET
+// move after a scrub, when afterScrub is set, is the new date. Other NET moves are
+// classified by direction. A window change is only reported on its own when the NET
+// stayed put or the window's length changed.
+func ClassifyLaunchChanges(prev, next LaunchSchedule, afterScrub bool, now time.Time) []LaunchChange {
+	var changes []LaunchChange
+	change := func(kind string) LaunchChange {
+		return LaunchChange{Kind: kind, From: prev, To: next, DetectedAt: now}
+	}
+
+	netMoved := !prev.Net.IsZero() && !next.Net.IsZero() && !prev.Net.Equal(next.Net)
+	if netMoved {
+		kind := LaunchNetLater
+		switch {
+		case !now.Before(prev.Net.Add(-scrubWindow)):
+			kind = LaunchScrub
+		case afterScrub:
+			kind = LaunchNewDate
+		case next.Net.Before(prev.Net):
+			kind = LaunchNetEarlier
+		}
+		c := change(kind)
+		c.Delta = next.Net.Sub(prev.Net)
+		changes = append(changes, c)
+	}
+
+	windowMoved := !prev.WindowStart.IsZero() && !next.WindowStart.IsZero() &&
+		(!prev.WindowStart.Equal(next.WindowStart) || !prev.WindowEnd.Equal(next.WindowEnd))
+	if windowMoved && (!netMoved || prev.WindowEnd.Sub(prev.WindowStart) != next.WindowEnd.Sub(next.WindowStart)) {
+		changes = append(changes, change(LaunchWindowChanged))
+	}
+
+	if next.Status != prev.Status && prev.Status != "" {
+		switch next.Status {
+		case "Hold":
+			changes = append(changes, change(LaunchHold))
+		case "TBD":
+			changes = append(changes, change(LaunchTBD))
+		}
+	}
+
+	return changes
+}
+
+// scheduleFromRecord reads the schedule stored on an events record
+func scheduleFromRecord(record map[string]interface{}) LaunchSchedule {
+	s := LaunchSchedule{}
+	s.Net, _ = parseRecordTime(record["datetime"])
+	s.WindowStart, _ = parseRecordTime(record["window_start"])
+	s.WindowEnd, _ = parseRecordTime(record["window_end"])
+	s.Status, _ = record["status_abbrev"].(string)
+	return s
+}
+
+// publishLaunchChanges classifies what a sync changed about a stored launch, records
+// each change in event_revisions and hands it to the registered handlers
+func publishLaunchChanges(client *pbclient.Client, record map[string]interface{}, next LaunchSchedule) {
+	eventID, _ := record["id"].(string)
+	title, _ := record["title"].(string)
+	prev := scheduleFromRecord(record)
+
+	afterScrub := false
+	if !prev.Net.IsZero() && !next.Net.IsZero() && !prev.Net.Equal(next.Net) {
+		afterScrub = lastNetRevision(client, eventID) == LaunchScrub
+	}
+
+	for _, change := range ClassifyLaunchChanges(prev, next, afterScrub, time.Now()) {
+		change.Event = eventID
+		change.Title = title
+		if err := recordLaunchChange(client, change); err != nil {
+			log.Printf("❌ Failed to record %s for %s: %v", change.Kind, title, err)
+		}
+		log.Printf("📆 %s: %s", title, describeLaunchChange(change))
+		for _, handler := range launchChangeHandlers {
+			handler(change)
+		}
+	}
+}
+
+// lastNetRevision returns the kind of the launch's latest recorded NET move
+func lastNetRevision(client *pbclient.Client, eventID string) string {
+	filter := fmt.Sprintf(`event="%s" && (kind="%s" || kind="%s" || kind="%s" || kind="%s")`,
+		eventID, LaunchNetLater, LaunchNetEarlier, LaunchScrub, LaunchNewDate)
+	records, err := client.QueryRecords("event_revisions", filter, "-created", "", 1)
+	if err != nil || len(records) == 0 {
+		return ""
+	}
+	kind, _ := records[0]["kind"].(string)
+	return kind
+}
+
+func recordLaunchChange(client *pbclient.Client, c LaunchChange) error {
+	created, err := client.CreateRecord("event_revisions", map[string]interface{}{
+		"event":             c.Event,
+		"kind":              c.Kind,
+		"net_from":          formatRecordTime(c.From.Net),
+		"net_to":            formatRecordTime(c.To.Net),
+		"delta_seconds":     int64(c.Delta.Seconds()),
+		"window_start_from": formatRecordTime(c.From.WindowStart),
+		"window_start_to":   formatRecordTime(c.To.WindowStart),
+		"window_end_from":   formatRecordTime(c.From.WindowEnd),
+		"window_end_to":     formatRecordTime(c.To.WindowEnd),
+		"status_from":       c.From.Status,
+		"status_to":         c.To.Status,
+		"notified":          false,
+	})
+	if err != nil {
+		return err
+	}
+	if id, _ := (*created)["id"].(string); id == "" {
+		return fmt.Errorf("create rejected: %v", (*created)["message"])
+	}
+	return nil
+}
+
+func describeLaunchChange(c LaunchChange) string {
+	switch c.Kind {
+	case LaunchNetLater, LaunchNetEarlier, LaunchScrub, LaunchNewDate:
+		return fmt.Sprintf("%s, NET %s → %s (%+.0fm)", c.Kind, c.From.Net.UTC().Format(time.RFC3339), c.To.Net.UTC().Format(time.RFC3339), c.Delta.Minutes())
+	case LaunchWindowChanged:
+		return fmt.Sprintf("window now %s – %s", c.To.WindowStart.UTC().Format(time.RFC3339), c.To.WindowEnd.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("status %s → %s", c.From.Status, c.To.Status)
+}
+
+// parseRecordTime parses the RFC 3339 and PocketBase datetime formats stored in records
+func parseRecordTime(value interface{}) (time.Time, error) {
+	s, _ := value.(string)
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.000Z", "2006-01-02 15:04:05Z"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time format: %q", s)
+}
+
+func formatRecordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05.000Z")
+}
@@ -0,0 +1,331 @@
+package sync
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// The launch model follows the 2.2 field names. The decoders in this file also accept
+// the 2.3 shapes, so the syncers read either API version into the same structs: image
+// objects instead of URLs, relative_time timelines, renamed link lists, object statuses
+// and country lists.
+
+// FlexibleImage accepts an image URL string (2.2) or an image object (2.3)
+type FlexibleImage string
+
+func (f *FlexibleImage) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*f = FlexibleImage(str)
+		return nil
+	}
+
+	var obj struct {
+		ImageURL string `json:"image_url"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		*f = ""
+		return nil
+	}
+	*f = FlexibleImage(obj.ImageURL)
+	return nil
+}
+
+// FlexibleString accepts a string or a number, e.g. founding_year
+type FlexibleString string
+
+func (f *FlexibleString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*f = FlexibleString(str)
+		return nil
+	}
+
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		*f = ""
+		return nil
+	}
+	*f = FlexibleString(num.String())
+	return nil
+}
+
+// spaceDevsCountry is a 2.3 country object
+type spaceDevsCountry struct {
+	Name        string `json:"name"`
+	Alpha2      string `json:"alpha_2_code"`
+	Alpha3      string `json:"alpha_3_code"`
+	Nationality string `json:"nationality_name"`
+}
+
+func (l *Launch) UnmarshalJSON(data []byte) error {
+	type launch Launch
+	var raw struct {
+		launch
+		InfoURLs2   []Link            `json:"info_urls"`
+		VideoURLs2  []VidURL          `json:"vid_urls"`
+		Image       FlexibleImage     `json:"image"`
+		Infographic FlexibleImage     `json:"infographic"`
+		Timeline    []json.RawMessage `json:"timeline"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*l = Launch(raw.launch)
+	// One malformed timeline entry shouldn't cost the whole page of launches
+	l.Timeline = nil
+	for _, entry := range raw.Timeline {
+		var t TimelineEntry
+		if err := json.Unmarshal(entry, &t); err != nil {
+			log.Printf("⚠️ Dropping timeline entry of %s: %v", l.Name, err)
+			continue
+		}
+		l.Timeline = append(l.Timeline, t)
+	}
+	l.Image = string(raw.Image)
+	l.Infographic = string(raw.Infographic)
+	if len(l.InfoURLs) == 0 {
+		l.InfoURLs = raw.InfoURLs2
+	}
+	if len(l.VideoURLs) == 0 {
+		l.VideoURLs = raw.VideoURLs2
+	}
+	return nil
+}
+
+func (a *Agency) UnmarshalJSON(data []byte) error {
+	type agency Agency
+	var raw struct {
+		agency
+		Type       FlexibleNameField  `json:"type"`
+		Founded    FlexibleString     `json:"founding_year"`
+		Country    []spaceDevsCountry `json:"country"`
+		Logo       FlexibleImage      `json:"logo"`
+		SocialLogo FlexibleImage      `json:"social_logo"`
+		Image      FlexibleImage      `json:"image"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*a = Agency(raw.agency)
+	a.Type = raw.Type.Name
+	a.Founded = string(raw.Founded)
+	a.Countries = raw.Country
+	a.SocialLogoURL = string(raw.SocialLogo)
+	// 2.3 lists countries; use the same alpha-2 code SyncAgencies stores
+	if a.Country == "" && len(raw.Country) > 0 {
+		codes := make([]string, 0, len(raw.Country))
+		for _, c := range raw.Country {
+			codes = append(codes, c.Alpha2)
+		}
+		a.Country = strings.Join(codes, ",")
+	}
+	if a.LogoURL == "" {
+		a.LogoURL = string(raw.Logo)
+	}
+	if a.ImageURL == "" {
+		a.ImageURL = string(raw.Image)
+	}
+	return nil
+}
+
+func (t *TimelineEntry) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Time         *int   `json:"time"`
+		Event        string `json:"event"`
+		RelativeTime string `json:"relative_time"`
+		Type         *struct {
+			Abbrev      string `json:"abbrev"`
+			Description string `json:"description"`
+		} `json:"type"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*t = TimelineEntry{Event: raw.Event}
+	if raw.Time != nil {
+		t.Time = *raw.Time
+	} else if raw.RelativeTime != "" {
+		offset, err := parseISODuration(raw.RelativeTime)
+		if err != nil {
+			return err
+		}
+		t.Time = int(offset.Seconds())
+	}
+	if t.Event == "" && raw.Type != nil {
+		t.Event = raw.Type.Description
+		if t.Event == "" {
+			t.Event = raw.Type.Abbrev
+		}
+	}
+	return nil
+}
+
+func (p *Program) UnmarshalJSON(data []byte) error {
+	type program Program
+	var raw struct {
+		program
+		Image json.RawMessage `json:"image"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*p = Program(raw.program)
+	var image struct {
+		ImageURL     string `json:"image_url"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	}
+	if p.ImageURL == "" && json.Unmarshal(raw.Image, &image) == nil {
+		p.ImageURL = image.ImageURL
+		p.ThumbnailURL = image.ThumbnailURL
+	}
+	return nil
+}
+
+func (r *RocketConfig) UnmarshalJSON(data []byte) error {
+	type rocketConfig RocketConfig
+	var raw struct {
+		rocketConfig
+		Image    FlexibleImage `json:"image"`
+		Families []struct {
+			Name string `json:"name"`
+		} `json:"families"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = RocketConfig(raw.rocketConfig)
+	if r.ImageURL == "" {
+		r.ImageURL = string(raw.Image)
+	}
+	if r.Family == "" && len(raw.Families) > 0 {
+		r.Family = raw.Families[0].Name
+	}
+	return nil
+}
+
+func (l *Launcher) UnmarshalJSON(data []byte) error {
+	type launcher Launcher
+	var raw struct {
+		launcher
+		Status FlexibleNameField `json:"status"`
+		Image  FlexibleImage     `json:"image"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*l = Launcher(raw.launcher)
+	l.Status = raw.Status.Name
+	if l.ImageURL == "" {
+		l.ImageURL = string(raw.Image)
+	}
+	return nil
+}
+
+func (l *Landing) UnmarshalJSON(data []byte) error {
+	type landing Landing
+	var raw struct {
+		landing
+		LandingLocation *LandingLocation `json:"landing_location"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*l = Landing(raw.landing)
+	if raw.LandingLocation != nil {
+		l.Location = *raw.LandingLocation
+	}
+	return nil
+}
+
+func (p *Pad) UnmarshalJSON(data []byte) error {
+	type pad Pad
+	var raw struct {
+		pad
+		Country *spaceDevsCountry `json:"country"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*p = Pad(raw.pad)
+	if p.CountryCode == "" && raw.Country != nil {
+		p.CountryCode = raw.Country.Alpha2
+	}
+	return nil
+}
+
+func (a *Astronaut) UnmarshalJSON(data []byte) error {
+	type astronaut Astronaut
+	var raw struct {
+		astronaut
+		// A string in 2.2, a list of countries in 2.3
+		Nationality json.RawMessage `json:"nationality"`
+		Image       *struct {
+			ImageURL     string `json:"image_url"`
+			ThumbnailURL string `json:"thumbnail_url"`
+		} `json:"image"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*a = Astronaut(raw.astronaut)
+	if raw.Image != nil && a.ProfileImageURL == "" {
+		a.ProfileImageURL = raw.Image.ImageURL
+		a.ProfileImageThumbnail = raw.Image.ThumbnailURL
+	}
+
+	var nationality string
+	if err := json.Unmarshal(raw.Nationality, &nationality); err == nil {
+		a.Nationality = nationality
+		return nil
+	}
+	var countries []spaceDevsCountry
+	if err := json.Unmarshal(raw.Nationality, &countries); err == nil {
+		names := make([]string, 0, len(countries))
+		for _, c := range countries {
+			names = append(names, c.Nationality)
+		}
+		a.Nationality = strings.Join(names, ", ")
+	}
+	return nil
+}
+
+func (s *SpacecraftConfig) UnmarshalJSON(data []byte) error {
+	type spacecraftConfig SpacecraftConfig
+	var raw struct {
+		spacecraftConfig
+		Image FlexibleImage `json:"image"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = SpacecraftConfig(raw.spacecraftConfig)
+	if s.ImageURL == "" {
+		s.ImageURL = string(raw.Image)
+	}
+	return nil
+}
+
+// LaunchLibraryVersion is the API version the launch sync reads. "2.2.0" still works
+// through the decoders above if 2.3 ever needs to be rolled back.
+var LaunchLibraryVersion = "2.3.0"
+
+// launchesURL builds the upcoming launches URL for a Launch Library version
+func launchesURL(version string, offset int) string {
+	if version == "2.2.0" {
+		return "https://ll.thespacedevs.com/2.2.0/launch/upcoming/?limit=50&mode=detailed&offset=" + strconv.Itoa(offset)
+	}
+	return "https://ll.thespacedevs.com/" + version + "/launches/upcoming/?limit=50&mode=detailed&offset=" + strconv.Itoa(offset)
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func decodeLaunch(t *testing.T, file string) Launch {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var page LaunchAPIResponse
+	if err := json.Unmarshal(data, &page); err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	if len(page.Results) != 1 {
+		t.Fatalf("%s: decoded %d launches, want 1", file, len(page.Results))
+	}
+	return page.Results[0]
+}
+
+// The same launch as served by /2.2.0/launch/upcoming and /2.3.0/launches/upcoming
+// decodes to the same model
+func TestDecodeLaunchVersions(t *testing.T) {
+	fields := []struct {
+		name string
+		get  func(Launch) any
+		want any
+	}{
+		{"image", func(l Launch) any { return l.Image }, "https://example.com/crew10.png"},
+		{"provider type", func(l Launch) any { return l.LaunchServiceProvider.Type }, "Commercial"},
+		{"provider founding year", func(l Launch) any { return l.LaunchServiceProvider.Founded }, "2002"},
+		{"provider administrator", func(l Launch) any { return l.LaunchServiceProvider.Administrator }, "CEO: Elon Musk"},
+		{"provider featured", func(l Launch) any { return l.LaunchServiceProvider.Featured }, true},
+		{"provider logo", func(l Launch) any { return l.LaunchServiceProvider.LogoURL }, "https://example.com/spx_logo.png"},
+		{"provider image", func(l Launch) any { return l.LaunchServiceProvider.ImageURL }, "https://example.com/spx.jpg"},
+		{"rocket family", func(l Launch) any { return l.Rocket.Configuration.Family }, "Falcon"},
+		{"rocket image", func(l Launch) any { return l.Rocket.Configuration.ImageURL }, "https://example.com/f9.jpg"},
+		{"rocket manufacturer type", func(l Launch) any { return l.Rocket.Configuration.Manufacturer.Type }, "Commercial"},
+		{"booster status", func(l Launch) any { return l.Rocket.LauncherStage[0].Launcher.Status }, "active"},
+		{"booster image", func(l Launch) any { return l.Rocket.LauncherStage[0].Launcher.ImageURL }, "https://example.com/b1085.jpg"},
+		{"mission agency type", func(l Launch) any { return l.Mission.Agencies[0].Type }, "Government"},
+		{"pad latitude", func(l Launch) any { return l.Pad.Latitude }, FlexibleFloat(28.60822681)},
+		{"info urls", func(l Launch) any { return l.InfoURLs[0].URL }, "https://example.com/crew10"},
+		{"video urls", func(l Launch) any { return l.VideoURLs[0].URL }, "https://www.youtube.com/watch?v=crew10"},
+		{"timeline", func(l Launch) any { return l.Timeline }, []TimelineEntry{{Time: -8400, Event: "Crew walk out"}, {Time: 0, Event: "Liftoff"}}},
+		{"program image", func(l Launch) any { return l.Program[0].ImageURL }, "https://example.com/ccp.png"},
+		{"program end date", func(l Launch) any { return l.Program[0].EndDate }, ""},
+		{"program info url", func(l Launch) any { return l.Program[0].InfoURL }, "https://www.nasa.gov/commercialcrew"},
+		{"program type", func(l Launch) any { return l.Program[0].Type.Name }, "Human Exploration"},
+		{"program agency", func(l Launch) any { return l.Program[0].Agencies[0].Ref().Type.Name }, "Government"},
+		{"program patch agency", func(l Launch) any { return l.Program[0].MissionPatches[0].Agency.Type.Name }, "Government"},
+	}
+
+	for _, file := range []string{"launch_upcoming_2.2.json", "launch_upcoming_2.3.json"} {
+		launch := decodeLaunch(t, file)
+		for _, f := range fields {
+			if got := f.get(launch); !reflect.DeepEqual(got, f.want) {
+				t.Errorf("%s: %s = %#v, want %#v", file, f.name, got, f.want)
+			}
+		}
+	}
+
+	// 2.2 only has the alpha-3 country code; 2.3 adds the country list, the social
+	// logo and image thumbnails
+	v22 := decodeLaunch(t, "launch_upcoming_2.2.json")
+	if got := v22.LaunchServiceProvider.Country; got != "USA" {
+		t.Errorf("2.2 provider country = %q, want USA", got)
+	}
+	v23 := decodeLaunch(t, "launch_upcoming_2.3.json")
+	provider := v23.LaunchServiceProvider
+	if provider.Country != "US" || len(provider.Countries) != 1 || provider.Countries[0].Nationality != "American" {
+		t.Errorf("2.3 provider country = %q %+v, want US", provider.Country, provider.Countries)
+	}
+	if provider.SocialLogoURL != "https://example.com/spx_social.png" {
+		t.Errorf("2.3 provider social logo = %q", provider.SocialLogoURL)
+	}
+	if got := v23.Program[0].ThumbnailURL; got != "https://example.com/ccp_thumb.png" {
+		t.Errorf("2.3 program thumbnail = %q", got)
+	}
+}
+
+func TestLaunchDropsMalformedTimelineEntries(t *testing.T) {
+	data := `{"results":[{"id":"a","name":"Crew-10","timeline":[
+		{"type":{"abbrev":"Crew","description":"Crew walk out"},"relative_time":"-PT2H20M"},
+		{"type":{"abbrev":"Bad","description":"Bad offset"},"relative_time":"T-2 hours"},
+		{"type":{"abbrev":"Liftoff","description":"Liftoff"},"relative_time":"PT0S"}
+	]},{"id":"b","name":"Starlink"}]}`
+
+	var page LaunchAPIResponse
+	if err := json.Unmarshal([]byte(data), &page); err != nil {
+		t.Fatalf("a bad timeline entry failed the page: %v", err)
+	}
+	if len(page.Results) != 2 {
+		t.Fatalf("decoded %d launches, want 2", len(page.Results))
+	}
+	want := []TimelineEntry{{Time: -8400, Event: "Crew walk out"}, {Time: 0, Event: "Liftoff"}}
+	got := page.Results[0].Timeline
+	if len(got) != len(want) {
+		t.Fatalf("timeline = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("timeline[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// pocketbaseURL is the local PocketBase instance the standalone syncers write to
+const pocketbaseURL = "http://127.0.0.1:8080"
+
+var pbHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+type pbListResponse struct {
+	Page       int              `json:"page"`
+	TotalPages int              `json:"totalPages"`
+	Items      []map[string]any `json:"items"`
+}
+
+// findPBRecord returns the first record in a collection matching a PocketBase filter, or nil
+func findPBRecord(collection, filter string) (map[string]any, error) {
+	endpoint := fmt.Sprintf("%s/api/collections/%s/records?perPage=1&filter=%s", pocketbaseURL, collection, url.QueryEscape(filter))
+
+	res, err := pbHTTPClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%s lookup HTTP error: %w", collection, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("%s lookup failed: HTTP %d: %s", collection, res.StatusCode, body)
+	}
+
+	var data pbListResponse
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode %s lookup: %w", collection, err)
+	}
+
+	if len(data.Items) == 0 {
+		return nil, nil
+	}
+	return data.Items[0], nil
+}
+
+// findPBRecordID resolves a filter to a PocketBase record ID, returning "" when nothing matches
+func findPBRecordID(collection, filter string) (string, error) {
+	record, err := findPBRecord(collection, filter)
+	if err != nil || record == nil {
+		return "", err
+	}
+	id, _ := record["id"].(string)
+	return id, nil
+}
+
+// listPBRecords returns every record in a collection matching a filter, following pagination
+func listPBRecords(collection, filter string) ([]map[string]any, error) {
+	var all []map[string]any
+	for page := 1; ; page++ {
+		endpoint := fmt.Sprintf("%s/api/collections/%s/records?page=%d&perPage=200", pocketbaseURL, collection, page)
+		if filter != "" {
+			endpoint += "&filter=" + url.QueryEscape(filter)
+		}
+
+		res, err := pbHTTPClient.Get(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("%s list HTTP error: %w", collection, err)
+		}
+
+		if res.StatusCode != 200 {
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			return nil, fmt.Errorf("%s list failed: HTTP %d: %s", collection, res.StatusCode, body)
+		}
+
+		var data pbListResponse
+		err = json.NewDecoder(res.Body).Decode(&data)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode %s list: %w", collection, err)
+		}
+
+		all = append(all, data.Items...)
+		if len(data.Items) == 0 || page >= data.TotalPages {
+			return all, nil
+		}
+	}
+}
+
+// createPBRecord inserts a record and returns its PocketBase ID
+func createPBRecord(collection string, payload map[string]any) (string, error) {
+	endpoint := fmt.Sprintf("%s/api/collections/%s/records", pocketbaseURL, collection)
+	body, err := sendPBRequest("POST", endpoint, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", fmt.Errorf("decode created %s record: %w", collection, err)
+	}
+	return created.ID, nil
+}
+
+// updatePBRecord patches an existing record with the given fields
+func updatePBRecord(collection, id string, payload map[string]any) error {
+	endpoint := fmt.Sprintf("%s/api/collections/%s/records/%s", pocketbaseURL, collection, id)
+	_, err := sendPBRequest("PATCH", endpoint, payload)
+	return err
+}
+
+// upsertPBRecordByAPIID updates the record whose api_id matches, or creates it when missing.
+// It returns the record ID and whether a new record was created.
+func upsertPBRecordByAPIID(collection string, apiID int, payload map[string]any) (string, bool, error) {
+	return upsertPBRecordByFilter(collection, fmt.Sprintf("api_id=%d", apiID), payload)
+}
+
+// upsertPBRecordByFilter updates the first record matching filter, or creates it when missing
+func upsertPBRecordByFilter(collection, filter string, payload map[string]any) (string, bool, error) {
+	existingID, err := findPBRecordID(collection, filter)
+	if err != nil {
+		return "", false, err
+	}
+
+	if existingID != "" {
+		return existingID, false, updatePBRecord(collection, existingID, payload)
+	}
+
+	id, err := createPBRecord(collection, payload)
+	return id, true, err
+}
+
+func sendPBRequest(method, endpoint string, payload map[string]any) ([]byte, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest(method, endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := pbHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s to Pocketbase: %w", method, err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("PB HTTP %d: %s", res.StatusCode, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,254 @@
+package sync
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/signal-k/notifs/internal/fixtures"
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+// replay answers SpaceDevs requests from testdata/fixtures and every PocketBase
+// request from an empty fake
+func replay(t *testing.T) *pbfake.Server {
+	t.Helper()
+	fake := pbfake.New()
+	orig := http.DefaultTransport
+	http.DefaultTransport = &fixtures.Transport{Mode: fixtures.Replay, Dir: "testdata/fixtures", PocketBase: fake}
+	t.Cleanup(func() { http.DefaultTransport = orig })
+	return fake
+}
+
+// only returns the single record stored in collection
+func only(t *testing.T, fake *pbfake.Server, collection string) map[string]any {
+	t.Helper()
+	records := fake.Records(collection)
+	if len(records) != 1 {
+		t.Fatalf("%s holds %d records, want 1: %v", collection, len(records), records)
+	}
+	return records[0]
+}
+
+// byAPIID returns the record in collection with the given api_id
+func byAPIID(t *testing.T, fake *pbfake.Server, collection string, apiID int) map[string]any {
+	t.Helper()
+	for _, record := range fake.Records(collection) {
+		if id, _ := record["api_id"].(float64); int(id) == apiID {
+			return record
+		}
+	}
+	t.Fatalf("no %s record with api_id %d", collection, apiID)
+	return nil
+}
+
+func expectFields(t *testing.T, record map[string]any, want map[string]any) {
+	t.Helper()
+	for field, value := range want {
+		if got := record[field]; got != value {
+			t.Errorf("%s = %#v, want %#v", field, got, value)
+		}
+	}
+}
+
+func TestReplaySyncers(t *testing.T) {
+	fake := replay(t)
+
+	t.Run("agencies", func(t *testing.T) {
+		if err := SyncAgencies(); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(fake.Records("agencies")); n != 2 {
+			t.Fatalf("stored %d agencies, want 2", n)
+		}
+		expectFields(t, byAPIID(t, fake, "agencies", 44), map[string]any{
+			"abbrev":       "NASA",
+			"type_name":    "Government",
+			"country_code": "US",
+			"logo_url":     "https://example.com/nasa_logo.png",
+		})
+	})
+
+	t.Run("stations", func(t *testing.T) {
+		if err := SyncStations(); err != nil {
+			t.Fatal(err)
+		}
+		expectFields(t, only(t, fake, "stations"), map[string]any{
+			"name":   "International Space Station",
+			"status": "Active",
+			"orbit":  "Low Earth Orbit",
+		})
+	})
+
+	t.Run("docking locations", func(t *testing.T) {
+		if err := SyncDockingLocations(); err != nil {
+			t.Fatal(err)
+		}
+		expectFields(t, only(t, fake, "docking_locations"), map[string]any{
+			"name":    "Harmony forward",
+			"station": only(t, fake, "stations")["id"],
+		})
+	})
+
+	t.Run("programs", func(t *testing.T) {
+		if err := SyncPrograms(); err != nil {
+			t.Fatal(err)
+		}
+		program := only(t, fake, "programs")
+		expectFields(t, program, map[string]any{
+			"name":            "International Space Station",
+			"type":            "Station",
+			"image_thumb_url": "https://example.com/iss_program_thumb.jpg",
+		})
+		if agencies, _ := program["agencies"].([]any); len(agencies) != 1 || agencies[0] != byAPIID(t, fake, "agencies", 44)["id"] {
+			t.Errorf("agencies = %v, want NASA only", agencies)
+		}
+		if patches, _ := program["mission_patches"].([]any); len(patches) != 1 {
+			t.Errorf("mission_patches = %v, want one patch", patches)
+		}
+	})
+
+	t.Run("astronauts", func(t *testing.T) {
+		// A second sync updates the astronaut rather than adding another
+		for range 2 {
+			if err := SyncAstronauts(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// The earthling is filtered out
+		expectFields(t, only(t, fake, "astronauts"), map[string]any{
+			"name":        "Sunita Williams",
+			"status":      "Active",
+			"nationality": "American",
+			"agency":      byAPIID(t, fake, "agencies", 44)["id"],
+			"priority":    float64(1),
+		})
+	})
+
+	t.Run("expeditions", func(t *testing.T) {
+		if err := SyncExpeditions(); err != nil {
+			t.Fatal(err)
+		}
+		expedition := only(t, fake, "expeditions")
+		expectFields(t, expedition, map[string]any{
+			"name":    "Expedition 72",
+			"station": only(t, fake, "stations")["id"],
+			"patches": "https://example.com/iss_patch.png",
+		})
+		expectFields(t, only(t, fake, "expedition_crew"), map[string]any{
+			"expedition": expedition["id"],
+			"astronaut":  only(t, fake, "astronauts")["id"],
+			"role":       "Commander",
+		})
+	})
+
+	t.Run("spacewalks before their event", func(t *testing.T) {
+		if err := SyncSpacewalks(); err != nil {
+			t.Fatal(err)
+		}
+		expectFields(t, only(t, fake, "spacewalks"), map[string]any{
+			"name":              "US Spacewalk 91",
+			"duration_seconds":  float64(6 * 60 * 60),
+			"expedition":        only(t, fake, "expeditions")["id"],
+			"pending_relations": true,
+		})
+	})
+
+	t.Run("events", func(t *testing.T) {
+		if err := SyncEvents(); err != nil {
+			t.Fatal(err)
+		}
+		event := only(t, fake, "events")
+		expectFields(t, event, map[string]any{
+			"title":          "US Spacewalk 91",
+			"type":           EventTypeSpacewalk,
+			"date_precision": "Hour",
+		})
+		if stations, _ := event["stations"].([]any); len(stations) != 1 {
+			t.Errorf("stations = %v, want the ISS", stations)
+		}
+		if programs, _ := event["programs"].([]any); len(programs) != 1 {
+			t.Errorf("programs = %v, want the ISS program", programs)
+		}
+	})
+
+	t.Run("spacewalks after their event", func(t *testing.T) {
+		if err := SyncSpacewalks(); err != nil {
+			t.Fatal(err)
+		}
+		expectFields(t, only(t, fake, "spacewalks"), map[string]any{
+			"event":             only(t, fake, "events")["id"],
+			"pending_relations": false,
+		})
+	})
+
+	t.Run("docking events", func(t *testing.T) {
+		if err := SyncDockingEvents(); err != nil {
+			t.Fatal(err)
+		}
+		expectFields(t, only(t, fake, "docking_events"), map[string]any{
+			"location_name":      "Harmony forward",
+			"chaser_launch_name": "Falcon 9 Block 5 | Crew-10",
+			"details":            "Docking with International Space Station",
+		})
+	})
+
+	t.Run("payloads", func(t *testing.T) {
+		if err := SyncPayloads(); err != nil {
+			t.Fatal(err)
+		}
+		expectFields(t, only(t, fake, "payloads"), map[string]any{
+			"name":          "Cygnus NG-21",
+			"operator_name": "National Aeronautics and Space Administration",
+			"program_name":  "International Space Station",
+		})
+	})
+
+	t.Run("launches", func(t *testing.T) {
+		client := pbclient.NewClient(pocketbaseURL)
+		if err := client.Login("admin@example.com", "secret"); err != nil {
+			t.Fatal(err)
+		}
+		count, err := SyncLaunchesOnce(client, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatalf("synced %d launches, want 1", count)
+		}
+
+		launch := byTitle(t, fake, "Falcon 9 Block 5 | Crew-10")
+		expectFields(t, launch, map[string]any{
+			"provider":      byAPIID(t, fake, "agencies", 121)["id"],
+			"status_abbrev": "Go",
+		})
+		if programs, _ := launch["programs"].([]any); len(programs) != 1 || programs[0] != only(t, fake, "programs")["id"] {
+			t.Errorf("programs = %v, want the ISS program", programs)
+		}
+		if timeline, _ := launch["timeline"].([]any); len(timeline) != 2 {
+			t.Errorf("timeline = %v, want two entries", timeline)
+		}
+		// SpaceX was synced with the agencies; the launch reuses it
+		spacex := 0
+		for _, agency := range fake.Records("agencies") {
+			if agency["api_id"] == float64(121) {
+				spacex++
+			}
+		}
+		if spacex != 1 {
+			t.Errorf("stored SpaceX %d times, want once", spacex)
+		}
+	})
+}
+
+// byTitle returns the events record with the given title
+func byTitle(t *testing.T, fake *pbfake.Server, title string) map[string]any {
+	t.Helper()
+	for _, record := range fake.Records("events") {
+		if record["title"] == title {
+			return record
+		}
+	}
+	t.Fatalf("no event titled %q", title)
+	return nil
+}
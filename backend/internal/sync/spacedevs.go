@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// spaceDevsAPI is the Launch Library 2.3 base URL shared by the 2.3 syncers
+const spaceDevsAPI = "https://ll.thespacedevs.com/2.3.0"
+
+var spaceDevsHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// getSpaceDevsJSON fetches a SpaceDevs URL and decodes the JSON body into out
+func getSpaceDevsJSON(apiURL string, out any) error {
+	resp, err := spaceDevsHTTPClient.Get(apiURL)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", apiURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return NewSyncError(resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("parse JSON: %w", err)
+	}
+	return nil
+}
+
+// parseISODuration parses the ISO 8601 durations the 2.3 API uses, such as
+// "PT6H30M" or "-P0DT01H00M00S". Years and months are not supported.
+func parseISODuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	sign := time.Duration(1)
+	if s[0] == '-' || s[0] == '+' {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	if len(s) < 2 || s[0] != 'P' {
+		return 0, fmt.Errorf("invalid ISO 8601 duration: %s", s)
+	}
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, c := range s[1:] {
+		switch {
+		case c == 'T':
+			inTime = true
+		case (c >= '0' && c <= '9') || c == '.':
+			number += string(c)
+		default:
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid ISO 8601 duration: %s", s)
+			}
+			number = ""
+
+			var unit time.Duration
+			switch {
+			case c == 'W' && !inTime:
+				unit = 7 * 24 * time.Hour
+			case c == 'D' && !inTime:
+				unit = 24 * time.Hour
+			case c == 'H' && inTime:
+				unit = time.Hour
+			case c == 'M' && inTime:
+				unit = time.Minute
+			case c == 'S' && inTime:
+				unit = time.Second
+			default:
+				return 0, fmt.Errorf("unsupported ISO 8601 duration unit %q in %s", c, s)
+			}
+			total += time.Duration(value * float64(unit))
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration: %s", s)
+	}
+
+	return sign * total, nil
+}
@@ -1,61 +1,34 @@
 package sync
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type SpaceDevsAgencyResponse struct {
-	Results []SpaceDevsAgency `json:"results"`
-	Next    string            `json:"next"`
-	Count   int               `json:"count"`
+	Results []Agency `json:"results"`
+	Next    string   `json:"next"`
+	Count   int      `json:"count"`
 }
 
-type SpaceDevsAgency struct {
-	ID            int    `json:"id"`
-	Name          string `json:"name"`
-	Abbrev        string `json:"abbrev"`
-	Description   string `json:"description"`
-	Administrator string `json:"administrator"`
-	FoundingYear  int    `json:"founding_year"`
-	Launchers     string `json:"launchers"`
-	Spacecraft    string `json:"spacecraft"`
-	Featured      bool   `json:"featured"`
-	URL           string `json:"url"`
-
-	Type struct {
-		Name string `json:"name"`
-	} `json:"type"`
-
-	Country []struct {
-		Name        string `json:"name"`
-		Alpha2      string `json:"alpha_2_code"`
-		Nationality string `json:"nationality_name"`
-	} `json:"country"`
-
-	Logo struct {
-		ImageURL string `json:"image_url"`
-	} `json:"logo"`
-
-	SocialLogo struct {
-		ImageURL string `json:"image_url"`
-	} `json:"social_logo"`
-
-	Image *struct {
-		ImageURL string `json:"image_url"`
-	} `json:"image"`
+// AgencyRef is the minimal agency reference embedded in astronauts, payloads and patches
+type AgencyRef struct {
+	ID     int                `json:"id"`
+	Name   string             `json:"name"`
+	Abbrev string             `json:"abbrev"`
+	Type   *FlexibleNameField `json:"type"` // a string in 2.2
 }
 
 // SyncAgencies fetches and stores agencies in Pocketbase
 func SyncAgencies() error {
 	fmt.Println("🏢 Syncing launch agencies...")
 
-	pageURL := "https://ll.thespacedevs.com/2.3.0/agencies/?limit=100"
+	pageURL := spaceDevsAPI + "/agencies/?limit=100&mode=detailed"
 	client := &http.Client{Timeout: 10 * time.Second}
 
 	var count, skipped int
@@ -64,9 +37,9 @@ func SyncAgencies() error {
 		if err != nil {
 			return fmt.Errorf("failed to fetch from SpaceDevs: %w", err)
 		}
-		defer resp.Body.Close()
 
 		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
 			return fmt.Errorf("failed to read body: %w", err)
 		}
@@ -81,7 +54,7 @@ func SyncAgencies() error {
 				skipped++
 				continue
 			}
-			if err := createAgencyInPocketbase(agency); err != nil {
+			if err := upsertAgencyInPocketbase(agency); err != nil {
 				log.Printf("❌ Failed: %s — %v", agency.Name, err)
 			} else {
 				log.Printf("✅ Synced agency: %s", agency.Name)
@@ -96,64 +69,75 @@ func SyncAgencies() error {
 	return nil
 }
 
-func createAgencyInPocketbase(a SpaceDevsAgency) error {
-	pbURL := "http://127.0.0.1:8080/api/collections/agencies/records"
-
+// upsertAgencyInPocketbase writes the full 2.3 agency onto the canonical agency record,
+// enriching any minimal record the launch sync or other syncers created earlier
+func upsertAgencyInPocketbase(a Agency) error {
 	countryName := ""
-	countryCode := ""
+	countryCode := a.Country
 	nationality := ""
-	if len(a.Country) > 0 {
-		countryName = a.Country[0].Name
-		countryCode = a.Country[0].Alpha2
-		nationality = a.Country[0].Nationality
+	if len(a.Countries) > 0 {
+		countryName = a.Countries[0].Name
+		countryCode = a.Countries[0].Alpha2
+		nationality = a.Countries[0].Nationality
+	}
+
+	countries := []map[string]any{}
+	for _, c := range a.Countries {
+		countries = append(countries, map[string]any{
+			"name":             c.Name,
+			"alpha_2_code":     c.Alpha2,
+			"alpha_3_code":     c.Alpha3,
+			"nationality_name": c.Nationality,
+		})
 	}
 
+	foundingYear, _ := strconv.Atoi(a.Founded)
 	payload := map[string]any{
 		"api_id":           a.ID,
 		"name":             a.Name,
 		"abbrev":           a.Abbrev,
-		"type_name":        a.Type.Name,
-		"description":      a.Description,
+		"type_name":        a.Type,
+		"description":      a.Desc,
 		"administrator":    a.Administrator,
-		"founding_year":    a.FoundingYear,
+		"founding_year":    foundingYear,
 		"launchers":        a.Launchers,
 		"spacecraft":       a.Spacecraft,
 		"featured":         a.Featured,
 		"url":              a.URL,
+		"info_url":         a.InfoURL,
+		"wiki_url":         a.WikiURL,
 		"country_name":     countryName,
 		"country_code":     countryCode,
 		"nationality_name": nationality,
-		"logo_url":         a.Logo.ImageURL,
-		"social_logo_url":  a.SocialLogo.ImageURL,
-		"image_url":        "",
+		"countries":        countries,
+		"logo_url":         a.LogoURL,
+		"social_logo_url":  a.SocialLogoURL,
+		"image_url":        a.ImageURL,
 	}
 
-	if a.Image != nil {
-		payload["image_url"] = a.Image.ImageURL
-	}
+	_, _, err := upsertPBRecordByAPIID("agencies", a.ID, payload)
+	return err
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
+// ensureAgency returns the agency record for a SpaceDevs agency reference, creating a
+// minimal record when SyncAgencies hasn't stored it yet. A zero ID resolves to "".
+func ensureAgency(ref AgencyRef) (string, error) {
+	if ref.ID == 0 {
+		return "", nil
 	}
 
-	req, err := http.NewRequest("POST", pbURL, bytes.NewBuffer(body))
-	if err != nil {
-		return err
+	id, err := findPBRecordID("agencies", fmt.Sprintf("api_id=%d", ref.ID))
+	if err != nil || id != "" {
+		return id, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	res, err := client.Do(req)
-	if err != nil {
-		return err
+	payload := map[string]any{
+		"api_id": ref.ID,
+		"name":   ref.Name,
+		"abbrev": ref.Abbrev,
 	}
-	defer res.Body.Close()
-
-	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("PB error: %d - %s", res.StatusCode, string(body))
+	if ref.Type != nil {
+		payload["type_name"] = ref.Type.Name
 	}
-
-	return nil
+	return createPBRecord("agencies", payload)
 }
@@ -1,14 +1,12 @@
 package sync
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
-	"time"
 )
 
 type SpaceDevsAstronaut struct {
@@ -17,8 +15,8 @@ type SpaceDevsAstronaut struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"type"`
-	Priority int `json:"id"`
-	Status   struct {
+	ID     int `json:"id"`
+	Status struct {
 		Name string `json:"name"`
 	} `json:"status"`
 	InSpace     bool    `json:"in_space"`
@@ -104,11 +102,11 @@ func SyncAstronauts() error {
 			continue
 		}
 
-		if err := createAstronautInPocketbase(astro); err != nil {
+		if err := upsertAstronautInPocketbase(astro); err != nil {
 			log.Printf("❌ Error inserting %s: %v", astro.Name, err)
 			errors = append(errors, fmt.Errorf("failed to insert %s: %w", astro.Name, err))
 		} else {
-			log.Printf("✅ Inserted astronaut: %s", astro.Name)
+			log.Printf("✅ Synced astronaut: %s", astro.Name)
 		}
 	}
 
@@ -121,9 +119,8 @@ func SyncAstronauts() error {
 	return nil
 }
 
-func createAstronautInPocketbase(astro SpaceDevsAstronaut) error {
-	pbURL := "http://127.0.0.1:8080/api/collections/astronauts/records"
-
+// upsertAstronautInPocketbase stores an astronaut keyed by its SpaceDevs ID
+func upsertAstronautInPocketbase(astro SpaceDevsAstronaut) error {
 	// Helper function to safely get string from pointer
 	getStringPtr := func(s *string) string {
 		if s != nil {
@@ -160,10 +157,19 @@ func createAstronautInPocketbase(astro SpaceDevsAstronaut) error {
 		return "Unknown"
 	}
 
+	// Listed lowest first: astronauts in space now, then active ones, then the rest
+	priority := 2
+	if astro.InSpace {
+		priority = 0
+	} else if mapStatus(astro.Status.Name) == "Active" {
+		priority = 1
+	}
+
 	payload := map[string]interface{}{
+		"api_id":           astro.ID,
 		"name":             astro.Name,
 		"role":             astro.Role.Name,
-		"priority":         astro.Priority,
+		"priority":         priority,
 		"status":           mapStatus(astro.Status.Name),
 		"in_space":         astro.InSpace,
 		"eva_time_total":   astro.EvaTime,
@@ -180,33 +186,71 @@ func createAstronautInPocketbase(astro SpaceDevsAstronaut) error {
 		"bio":              astro.Bio,
 		"wikipedia_url":    astro.WikipediaURL,
 		"agency_type":      astro.Agency.Type.Name,
-		// // Assumes 'agency' is a relation field using agency ID or name
-		// // Replace with correct Pocketbase ID if needed
-		// "agency": astro.Agency.ID,
 	}
 
-	jsonData, err := json.Marshal(payload)
+	agencyID, err := ensureAgency(AgencyRef{ID: astro.Agency.ID, Name: astro.Agency.Name})
 	if err != nil {
-		return err
+		return fmt.Errorf("agency lookup: %w", err)
+	}
+	if agencyID != "" {
+		payload["agency"] = agencyID
 	}
 
-	req, err := http.NewRequest("POST", pbURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+	_, _, err = upsertPBRecordByAPIID("astronauts", astro.ID, payload)
+	return err
+}
+
+// resolveAstronautID finds an astronaut by SpaceDevs ID, falling back to a normalized
+// name comparison so accents, punctuation and casing differences still match
+func resolveAstronautID(apiID int, name string) (string, error) {
+	if apiID != 0 {
+		id, err := findPBRecordID("astronauts", fmt.Sprintf("api_id=%d", apiID))
+		if err != nil || id != "" {
+			return id, err
+		}
+	}
+
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return "", nil
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	res, err := client.Do(req)
+	// Narrow the candidates by surname, then compare the normalized full names
+	surname := strings.ReplaceAll(parts[len(parts)-1], `"`, "")
+	candidates, err := listPBRecords("astronauts", fmt.Sprintf(`name~"%s"`, surname))
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("HTTP %d: %s", res.StatusCode, body)
+	want := normalizeName(name)
+	for _, candidate := range candidates {
+		candidateName, _ := candidate["name"].(string)
+		if normalizeName(candidateName) == want {
+			id, _ := candidate["id"].(string)
+			return id, nil
+		}
 	}
+	return "", nil
+}
 
-	return nil
+var nameAccentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ý", "y", "ÿ", "y", "ñ", "n", "ç", "c", "š", "s", "ž", "z", "č", "c",
+)
+
+// normalizeName lowercases a person's name and strips accents and punctuation
+func normalizeName(name string) string {
+	name = nameAccentReplacer.Replace(strings.ToLower(name))
+
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
 }
@@ -1,46 +1,72 @@
 package sync
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"time"
+
+	"github.com/signal-k/notifs/internal/dryrun"
 )
 
 type DockingEventResponse struct {
 	Results []DockingEvent `json:"results"`
+	Next    string         `json:"next"`
 }
 
 type DockingEvent struct {
-	ID        int           `json:"id"`
-	URL       string        `json:"url"`
-	Docking   string        `json:"docking"`
-	Departure string        `json:"departure"`
-	Location  Location      `json:"docking_location"`
-	Target    PayloadFlight `json:"payload_flight_target"`
-	Chaser    PayloadFlight `json:"payload_flight_chaser"`
+	ID                 int                  `json:"id"`
+	URL                string               `json:"url"`
+	Docking            string               `json:"docking"`
+	Departure          string               `json:"departure"`
+	Location           Location             `json:"docking_location"`
+	SpaceStationTarget *StationReference    `json:"space_station_target"`
+	ChaserFlight       *SpacecraftFlightRef `json:"flight_vehicle_chaser"`
+	Target             PayloadFlight        `json:"payload_flight_target"`
+	Chaser             PayloadFlight        `json:"payload_flight_chaser"`
 }
 
 type Location struct {
-	ID      int      `json:"id"`
-	Name    string   `json:"name"`
-	Payload *Payload `json:"payload"`
+	ID           int               `json:"id"`
+	Name         string            `json:"name"`
+	Spacestation *StationReference `json:"spacestation"`
+	Payload      *Payload          `json:"payload"`
+}
+
+type StationReference struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// LaunchReference is the short launch summary the 2.3 API embeds in other objects
+type LaunchReference struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Net  string `json:"net"`
+}
+
+// SpacecraftFlightRef is a spacecraft flight as embedded in a docking event
+type SpacecraftFlightRef struct {
+	ID          int    `json:"id"`
+	Destination string `json:"destination"`
+	MissionEnd  string `json:"mission_end"`
+	Spacecraft  struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"spacecraft"`
+	Launch *LaunchReference `json:"launch"`
 }
 
 type PayloadFlight struct {
-	ID      int      `json:"id"`
-	Payload *Payload `json:"payload"`
-	Launch  *Launch  `json:"launch"`
+	ID      int              `json:"id"`
+	Payload *Payload         `json:"payload"`
+	Launch  *LaunchReference `json:"launch"`
 }
 
 type Payload struct {
-	ID       int              `json:"id"`
-	Name     string           `json:"name"`
-	Operator *SpaceDevsAgency `json:"operator"`
-	Image    *Image           `json:"image"`
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Operator *Agency `json:"operator"`
+	Image    *Image  `json:"image"`
 }
 
 type Image struct {
@@ -51,33 +77,35 @@ type Image struct {
 func SyncDockingEvents() error {
 	fmt.Println("🔄 Syncing docking events...")
 
-	url := "https://ll.thespacedevs.com/2.3.0/docking_events/?format=json&limit=1000"
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to fetch docking events: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	var data DockingEventResponse
-	if err := json.Unmarshal(body, &data); err != nil {
-		return fmt.Errorf("unmarshal error: %w", err)
-	}
+	// The API serves at most 100 per page; newest dockings first
+	pageURL := spaceDevsAPI + "/docking_events/?format=json&limit=100&mode=detailed&ordering=-docking"
+	now := time.Now()
+	for pageURL != "" {
+		var data DockingEventResponse
+		if err := getSpaceDevsJSON(pageURL, &data); err != nil {
+			return fmt.Errorf("failed to fetch docking events: %w", err)
+		}
 
-	for _, event := range data.Results {
-		if err := upsertDockingEvent(event); err != nil {
-			log.Printf("❌ Error inserting docking event %d: %v", event.ID, err)
-		} else {
-			log.Printf("✅ Synced docking event %d", event.ID)
+		for _, event := range data.Results {
+			created, err := upsertDockingEvent(event, now)
+			if err != nil {
+				log.Printf("❌ Error syncing docking event %d: %v", event.ID, err)
+			} else if created {
+				log.Printf("✅ Inserted docking event %d", event.ID)
+			} else {
+				log.Printf("🔁 Updated docking event %d", event.ID)
+			}
 		}
+
+		pageURL = data.Next
 	}
 
 	return nil
 }
 
-func upsertDockingEvent(event DockingEvent) error {
-	apiURL := "http://127.0.0.1:8080/api/collections/docking_events/records"
-
+// upsertDockingEvent stores a docking event keyed by api_id, so a departure detected on a
+// later run updates the existing record. It reports whether a new record was created.
+func upsertDockingEvent(event DockingEvent, now time.Time) (bool, error) {
 	getPayloadInfo := func(p *Payload) (int, string, string, string) {
 		if p == nil {
 			return 0, "", "", ""
@@ -97,10 +125,14 @@ func upsertDockingEvent(event DockingEvent) error {
 	chaserPayloadID, chaserPayloadName, chaserOperator, chaserImage := getPayloadInfo(event.Chaser.Payload)
 	targetPayloadID, targetPayloadName, targetOperator, targetImage := getPayloadInfo(event.Target.Payload)
 
+	chaserLaunch := event.Chaser.Launch
+	if chaserLaunch == nil && event.ChaserFlight != nil {
+		chaserLaunch = event.ChaserFlight.Launch
+	}
 	chaserLaunchID, chaserLaunchName := "", ""
-	if event.Chaser.Launch != nil {
-		chaserLaunchID = event.Chaser.Launch.ID
-		chaserLaunchName = event.Chaser.Launch.Name
+	if chaserLaunch != nil {
+		chaserLaunchID = chaserLaunch.ID
+		chaserLaunchName = chaserLaunch.Name
 	}
 
 	targetLaunchID, targetLaunchName := "", ""
@@ -109,6 +141,12 @@ func upsertDockingEvent(event DockingEvent) error {
 		targetLaunchName = event.Target.Launch.Name
 	}
 
+	// Station-based dockings name the station directly; fall back to the port's station
+	targetName := locationPayloadName
+	if event.SpaceStationTarget != nil {
+		targetName = event.SpaceStationTarget.Name
+	}
+
 	payload := map[string]interface{}{
 		"api_id":                event.ID,
 		"docking_time":          event.Docking,
@@ -130,26 +168,105 @@ func upsertDockingEvent(event DockingEvent) error {
 		"target_image_url":      targetImage,
 		"target_launch_id":      targetLaunchID,
 		"target_launch_name":    targetLaunchName,
-		"is_active":             true,
-		"details":               fmt.Sprintf("Docking with %s", locationPayloadName),
+		"is_active":             dockingIsActive(event, now),
+		"details":               fmt.Sprintf("Docking with %s", targetName),
 		"source_url":            event.URL,
 	}
 
-	jsonData, _ := json.Marshal(payload)
-	req, _ := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
+	if err := resolveDockingRelations(event, chaserLaunchID, payload); err != nil {
+		return false, err
+	}
+
+	_, created, err := upsertPBRecordByAPIID("docking_events", event.ID, payload)
+	return created, err
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	res, err := client.Do(req)
+// dockingIsActive reports whether the vehicle has docked and not yet departed at now
+func dockingIsActive(event DockingEvent, now time.Time) bool {
+	docked, err := parseDate(event.Docking)
+	if err != nil || docked.After(now) {
+		return false
+	}
+	departed, err := parseDate(event.Departure)
 	if err != nil {
-		return err
+		return true // no departure scheduled yet
 	}
-	defer res.Body.Close()
+	return departed.After(now)
+}
 
-	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("PB insert error: %s", string(body))
+// resolveDockingRelations links the docking event to its station, port, chaser
+// spacecraft flight and the launch event that carried the chaser
+func resolveDockingRelations(event DockingEvent, chaserLaunchID string, payload map[string]interface{}) error {
+	station := event.SpaceStationTarget
+	if station == nil {
+		station = event.Location.Spacestation
+	}
+	if station != nil {
+		stationID, err := findPBRecordID("stations", fmt.Sprintf("api_id=%d", station.ID))
+		if err != nil {
+			return fmt.Errorf("lookup station: %w", err)
+		}
+		if stationID != "" {
+			payload["station"] = stationID
+		} else {
+			dryrun.NoteUnresolved("docking_events", "station", fmt.Sprintf("station api_id=%d", station.ID), "not synced yet")
+		}
+	}
+
+	if event.Location.ID != 0 {
+		locationID, err := findPBRecordID("docking_locations", fmt.Sprintf("api_id=%d", event.Location.ID))
+		if err != nil {
+			return fmt.Errorf("lookup docking location: %w", err)
+		}
+		if locationID != "" {
+			payload["docking_location"] = locationID
+		} else {
+			dryrun.NoteUnresolved("docking_events", "docking_location", fmt.Sprintf("docking location api_id=%d", event.Location.ID), "not synced yet")
+		}
+	}
+
+	launchEventID := ""
+	if chaserLaunchID != "" {
+		id, err := findPBRecordID("events", fmt.Sprintf(`spacedevs_id="%s"`, chaserLaunchID))
+		if err != nil {
+			return fmt.Errorf("lookup launch event: %w", err)
+		}
+		launchEventID = id
+		if id != "" {
+			payload["chaser_launch_event"] = id
+		} else {
+			dryrun.NoteUnresolved("docking_events", "chaser_launch_event", "launch "+chaserLaunchID, "not synced yet")
+		}
+	}
+
+	if event.ChaserFlight != nil {
+		flightID, err := upsertSpacecraftFlight(*event.ChaserFlight, launchEventID)
+		if err != nil {
+			return fmt.Errorf("store chaser spacecraft flight: %w", err)
+		}
+		payload["chaser_spacecraft_flight"] = flightID
 	}
 
 	return nil
 }
+
+// upsertSpacecraftFlight stores the embedded spacecraft flight keyed by api_id
+func upsertSpacecraftFlight(flight SpacecraftFlightRef, launchEventID string) (string, error) {
+	payload := map[string]interface{}{
+		"api_id":            flight.ID,
+		"spacecraft_api_id": flight.Spacecraft.ID,
+		"spacecraft_name":   flight.Spacecraft.Name,
+		"destination":       flight.Destination,
+		"mission_end":       flight.MissionEnd,
+	}
+	if flight.Launch != nil {
+		payload["launch_api_id"] = flight.Launch.ID
+		payload["launch_name"] = flight.Launch.Name
+	}
+	if launchEventID != "" {
+		payload["launch_event"] = launchEventID
+	}
+
+	id, _, err := upsertPBRecordByAPIID("spacecraft_flights", flight.ID, payload)
+	return id, err
+}
@@ -0,0 +1,44 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// eventUpdatePayload maps a Launch Library update onto an event_updates record
+func eventUpdatePayload(eventID string, u Update) map[string]interface{} {
+	return map[string]interface{}{
+		"api_id":        u.ID,
+		"event":         eventID,
+		"author":        u.CreatedBy,
+		"comment":       u.Comment,
+		"info_url":      u.InfoURL,
+		"profile_image": u.ProfileImage,
+		"created_on":    u.CreatedOn,
+	}
+}
+
+// syncLaunchUpdates stores each update posted on a launch, keyed by its SpaceDevs
+// update ID so re-syncing the same launch never duplicates the feed
+func syncLaunchUpdates(client *pbclient.Client, eventID string, updates []Update) {
+	for _, u := range updates {
+		if u.ID == 0 {
+			continue
+		}
+		upsertLaunchRecord(client, "event_updates", "api_id", u.ID, fmt.Sprintf("update %d", u.ID), eventUpdatePayload(eventID, u))
+	}
+}
+
+// upsertEventUpdates is the standalone-syncer counterpart of syncLaunchUpdates
+func upsertEventUpdates(eventID string, updates []Update) {
+	for _, u := range updates {
+		if u.ID == 0 {
+			continue
+		}
+		if _, _, err := upsertPBRecordByAPIID("event_updates", u.ID, eventUpdatePayload(eventID, u)); err != nil {
+			log.Printf("❌ Failed to store update %d: %v", u.ID, err)
+		}
+	}
+}
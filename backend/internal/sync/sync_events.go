@@ -0,0 +1,294 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/signal-k/notifs/internal/dryrun"
+)
+
+// Event types stored in events.type. Launches come from the launch sync; everything
+// else is classified from the Launch Library event type name.
+const (
+	EventTypeLaunch          = "rocket_launch"
+	EventTypePressConference = "press_conference"
+	EventTypeStaticFire      = "static_fire"
+	EventTypeLanding         = "landing"
+	EventTypeMoonLanding     = "moon_landing"
+	EventTypeSpacewalk       = "spacewalk"
+	EventTypeDocking         = "docking"
+	EventTypeTest            = "test"
+	EventTypeOther           = "other"
+)
+
+// EventTypes lists every value events.type can take
+var EventTypes = []string{
+	EventTypeLaunch,
+	EventTypePressConference,
+	EventTypeStaticFire,
+	EventTypeLanding,
+	EventTypeMoonLanding,
+	EventTypeSpacewalk,
+	EventTypeDocking,
+	EventTypeTest,
+	EventTypeOther,
+}
+
+// eventTypeRules maps words in Launch Library type names to our taxonomy.
+// Order matters: "Moon Landing" must match before "Landing".
+var eventTypeRules = []struct {
+	match     string
+	eventType string
+}{
+	{"moon landing", EventTypeMoonLanding},
+	{"lunar landing", EventTypeMoonLanding},
+	{"press", EventTypePressConference},
+	{"static fire", EventTypeStaticFire},
+	{"hot fire", EventTypeStaticFire},
+	{"spacewalk", EventTypeSpacewalk},
+	{"eva", EventTypeSpacewalk},
+	{"landing", EventTypeLanding},
+	{"splashdown", EventTypeLanding},
+	{"docking", EventTypeDocking},
+	{"berthing", EventTypeDocking},
+	{"test", EventTypeTest},
+	{"rehearsal", EventTypeTest},
+}
+
+// maxPreviousEventPages bounds how far back the previous events feed is walked per run
+const maxPreviousEventPages = 3
+
+type SpaceDevsEvent struct {
+	ID   int    `json:"id"`
+	URL  string `json:"url"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"type"`
+	Description   string `json:"description"`
+	WebcastLive   bool   `json:"webcast_live"`
+	Location      string `json:"location"`
+	NewsURL       string `json:"news_url"`
+	VideoURL      string `json:"video_url"`
+	Date          string `json:"date"`
+	DatePrecision *struct {
+		Name string `json:"name"`
+	} `json:"date_precision"`
+	Duration    string `json:"duration"`
+	LastUpdated string `json:"last_updated"`
+	Image       *struct {
+		ImageURL     string `json:"image_url"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	} `json:"image"`
+	Agencies    []AgencyRef       `json:"agencies"`
+	Launches    []LaunchReference `json:"launches"`
+	Expeditions []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"expeditions"`
+	Spacestations []StationReference `json:"spacestations"`
+	Program       []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"program"`
+	InfoURLs []Link   `json:"info_urls"`
+	VidURLs  []VidURL `json:"vid_urls"`
+	Updates  []Update `json:"updates"`
+}
+
+type SpaceDevsEventsResponse struct {
+	Results []SpaceDevsEvent `json:"results"`
+	Next    string           `json:"next"`
+}
+
+// SyncEvents stores the non-launch events (press conferences, static fires, landings,
+// EVAs, dockings...) from the upcoming and recent previous event feeds
+func SyncEvents() error {
+	fmt.Println("📅 Syncing Launch Library events...")
+
+	upcoming, err := fetchSpaceDevsEvents(spaceDevsAPI+"/events/upcoming/?limit=100&mode=detailed", 0)
+	if err != nil {
+		return fmt.Errorf("failed to fetch upcoming events: %w", err)
+	}
+
+	previous, err := fetchSpaceDevsEvents(spaceDevsAPI+"/events/previous/?limit=100&mode=detailed", maxPreviousEventPages)
+	if err != nil {
+		return fmt.Errorf("failed to fetch previous events: %w", err)
+	}
+
+	events := append(upcoming, previous...)
+	fmt.Printf("✅ Fetched %d upcoming and %d previous events\n", len(upcoming), len(previous))
+
+	var errors []error
+	for _, event := range events {
+		if err := upsertSpaceDevsEvent(event); err != nil {
+			log.Printf("❌ Error syncing event %s: %v", event.Name, err)
+			errors = append(errors, fmt.Errorf("failed to sync %s: %w", event.Name, err))
+		} else {
+			log.Printf("✅ Synced event: %s (%s)", event.Name, classifyEventType(event.Type.Name))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d errors during sync: %v", len(errors), errors[0])
+	}
+	return nil
+}
+
+// fetchSpaceDevsEvents follows the paginated feed, stopping after maxPages when it is non-zero
+func fetchSpaceDevsEvents(pageURL string, maxPages int) ([]SpaceDevsEvent, error) {
+	var events []SpaceDevsEvent
+	for page := 1; pageURL != ""; page++ {
+		var data SpaceDevsEventsResponse
+		if err := getSpaceDevsJSON(pageURL, &data); err != nil {
+			return nil, err
+		}
+		events = append(events, data.Results...)
+
+		if maxPages > 0 && page >= maxPages {
+			break
+		}
+		pageURL = data.Next
+	}
+	return events, nil
+}
+
+// classifyEventType maps a Launch Library event type name onto events.type
+func classifyEventType(typeName string) string {
+	// Pad with spaces so rules only match whole words ("EVA" but not "Elevation")
+	name := " " + strings.ToLower(typeName) + " "
+	for _, rule := range eventTypeRules {
+		if strings.Contains(name, " "+rule.match+" ") {
+			return rule.eventType
+		}
+	}
+	return EventTypeOther
+}
+
+// upsertSpaceDevsEvent stores an event keyed by event_api_id and links the launches,
+// expeditions, stations, programs and agencies it mentions
+func upsertSpaceDevsEvent(event SpaceDevsEvent) error {
+	vids := event.VidURLs
+	if event.VideoURL != "" {
+		vids = append([]VidURL{{Title: event.Name, URL: event.VideoURL}}, vids...)
+	}
+	vidURLs := eventVideos(vids)
+
+	infoURLs := []map[string]any{}
+	for _, info := range event.InfoURLs {
+		infoURLs = append(infoURLs, map[string]any{"title": info.Title, "url": info.URL, "priority": info.Priority})
+	}
+
+	payload := map[string]any{
+		"event_api_id": event.ID,
+		"title":        event.Name,
+		"type":         classifyEventType(event.Type.Name),
+		"type_name":    event.Type.Name,
+		"slug":         event.Slug,
+		"datetime":     event.Date,
+		"location":     event.Location,
+		"description":  event.Description,
+		"source_url":   event.URL,
+		"news_url":     event.NewsURL,
+		"webcast_live": event.WebcastLive,
+		"vid_urls":     vidURLs,
+		"info_urls":    infoURLs,
+		"duration":     event.Duration,
+	}
+	if event.DatePrecision != nil {
+		payload["date_precision"] = event.DatePrecision.Name
+	}
+	if event.Image != nil {
+		payload["image"] = event.Image.ImageURL
+	}
+
+	if err := resolveEventRelations(event, payload); err != nil {
+		return err
+	}
+
+	eventID, _, err := upsertPBRecordByFilter("events", fmt.Sprintf("event_api_id=%d", event.ID), payload)
+	if err != nil {
+		return err
+	}
+
+	upsertEventUpdates(eventID, event.Updates)
+	return nil
+}
+
+// resolveEventRelations fills the relation fields on payload. Launches, expeditions,
+// stations and programs that haven't been synced yet are skipped and picked up next run;
+// agencies are created on demand.
+func resolveEventRelations(event SpaceDevsEvent, payload map[string]any) error {
+	launchIDs := []string{}
+	for _, launch := range event.Launches {
+		id, err := findPBRecordID("events", fmt.Sprintf(`spacedevs_id="%s"`, launch.ID))
+		if err != nil {
+			return fmt.Errorf("lookup launch %s: %w", launch.Name, err)
+		}
+		if id != "" {
+			launchIDs = appendUnique(launchIDs, id)
+		} else {
+			dryrun.NoteUnresolved("events", "launches", "launch "+launch.Name, "not synced yet")
+		}
+	}
+	payload["launches"] = launchIDs
+
+	expeditionIDs := []string{}
+	for _, exp := range event.Expeditions {
+		id, err := findPBRecordID("expeditions", fmt.Sprintf("api_id=%d", exp.ID))
+		if err != nil {
+			return fmt.Errorf("lookup expedition %s: %w", exp.Name, err)
+		}
+		if id != "" {
+			expeditionIDs = appendUnique(expeditionIDs, id)
+		} else {
+			dryrun.NoteUnresolved("events", "expeditions", "expedition "+exp.Name, "not synced yet")
+		}
+	}
+	payload["expeditions"] = expeditionIDs
+
+	stationIDs := []string{}
+	for _, station := range event.Spacestations {
+		id, err := findPBRecordID("stations", fmt.Sprintf("api_id=%d", station.ID))
+		if err != nil {
+			return fmt.Errorf("lookup station %s: %w", station.Name, err)
+		}
+		if id != "" {
+			stationIDs = appendUnique(stationIDs, id)
+		} else {
+			dryrun.NoteUnresolved("events", "stations", "station "+station.Name, "not synced yet")
+		}
+	}
+	payload["stations"] = stationIDs
+
+	programIDs := []string{}
+	for _, program := range event.Program {
+		id, err := findPBRecordID("programs", fmt.Sprintf("api_id=%d", program.ID))
+		if err != nil {
+			return fmt.Errorf("lookup program %s: %w", program.Name, err)
+		}
+		if id != "" {
+			programIDs = appendUnique(programIDs, id)
+		} else {
+			dryrun.NoteUnresolved("events", "programs", "program "+program.Name, "not synced yet")
+		}
+	}
+	payload["programs"] = programIDs
+
+	agencyIDs := []string{}
+	for _, agency := range event.Agencies {
+		id, err := ensureAgency(agency)
+		if err != nil {
+			return fmt.Errorf("agency lookup: %w", err)
+		}
+		if id != "" {
+			agencyIDs = appendUnique(agencyIDs, id)
+		}
+	}
+	payload["agencies"] = agencyIDs
+
+	return nil
+}
@@ -1,55 +1,113 @@
 package sync
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/signal-k/notifs/internal/dryrun"
 )
 
-type LaunchEE struct {
-	Name   string `json:"name"`
-	Net    string `json:"net"` // Launch datetime
-	Status struct {
-		Name string `json:"name"`
-	} `json:"status"`
-	Mission struct {
+// expeditionMatchTolerance is how far a crew launch or landing may sit from an
+// expedition boundary and still count as starting or ending it
+const expeditionMatchTolerance = 3 * 24 * time.Hour
+
+// expeditionFlightLookback is how long before an expedition starts the flight that
+// ends it may have launched; crews have stayed aboard for up to about a year
+const expeditionFlightLookback = 400 * 24 * time.Hour
+
+// FlightCrewMember is an astronaut's seat on an expedition or spacecraft flight in the 2.3 API
+type FlightCrewMember struct {
+	ID   int `json:"id"`
+	Role struct {
+		ID       int    `json:"id"`
+		Role     string `json:"role"`
+		Priority int    `json:"priority"`
+	} `json:"role"`
+	Astronaut struct {
+		ID   int    `json:"id"`
 		Name string `json:"name"`
-	} `json:"mission"`
+	} `json:"astronaut"`
 }
 
 type Expedition struct {
-	Name  string `json:"name"`
-	Start string `json:"start"`
-	End   string `json:"end"`
-	URL   string `json:"url"`
+	ID    int                `json:"id"`
+	Name  string             `json:"name"`
+	Start string             `json:"start"`
+	End   string             `json:"end"`
+	URL   string             `json:"url"`
+	Crew  []FlightCrewMember `json:"crew"`
 }
 
 type SpaceDevsExpeditionsResponse struct {
 	Results []Expedition `json:"results"`
+	Next    string       `json:"next"`
+}
+
+// SpacecraftFlight is a crewed or cargo spacecraft flight from the 2.3 API
+type SpacecraftFlight struct {
+	ID          int    `json:"id"`
+	Destination string `json:"destination"`
+	MissionEnd  string `json:"mission_end"`
+	Launch      struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Net  string `json:"net"`
+	} `json:"launch"`
+	LaunchCrew    []FlightCrewMember `json:"launch_crew"`
+	LandingCrew   []FlightCrewMember `json:"landing_crew"`
+	DockingEvents []struct {
+		Docking   string `json:"docking"`
+		Departure string `json:"departure"`
+	} `json:"docking_events"`
 }
 
-type LaunchesResponse struct {
-	Results []LaunchEE `json:"results"`
+type SpacecraftFlightsResponse struct {
+	Results []SpacecraftFlight `json:"results"`
+	Next    string             `json:"next"`
 }
 
-// MatchExpeditionLaunches compares expedition start/end with launches on same day.
+// ExpeditionLaunchMatch links an expedition boundary to the launch of a spacecraft flight
+type ExpeditionLaunchMatch struct {
+	LaunchID   string `json:"launch_id"`
+	LaunchName string `json:"launch_name"`
+	FlightID   int    `json:"flight_id"`
+	Role       string `json:"role"`   // "start" or "end"
+	Source     string `json:"source"` // "spacecraft_flight" or "same_day"
+}
+
+// MatchExpeditionLaunches links each expedition to the launches that started or
+// ended it and stores the result on the expedition record. Crew overlap on 2.3
+// spacecraft flights is preferred; same-day matches are only used as a fallback
+// and are always flagged for review. Expeditions whose matches are settled are
+// skipped, and only the flights around the remaining ones are fetched.
 func MatchExpeditionLaunches() error {
-	fmt.Println("📡 Fetching launches and expeditions...")
+	fmt.Println("📡 Fetching expeditions and spacecraft flights...")
 
 	expeditions, err := fetchExpeditions()
 	if err != nil {
 		return err
 	}
 
-	launches, err := fetchLaunches()
+	expeditions, err = unsettledExpeditions(expeditions)
+	if err != nil {
+		return err
+	}
+	if len(expeditions) == 0 {
+		fmt.Println("✅ Every expedition already has its launches stored")
+		return nil
+	}
+
+	from, to := expeditionFlightWindow(expeditions, time.Now())
+	flights, err := fetchSpacecraftFlights(from, to)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("🔍 Matching %d expeditions against %d launches...\n", len(expeditions), len(launches))
+	fmt.Printf("🔍 Matching %d expeditions against %d spacecraft flights...\n", len(expeditions), len(flights))
 
+	var stored, review int
 	for _, exp := range expeditions {
 		startDate, err := parseDate(exp.Start)
 		if err != nil {
@@ -61,67 +119,275 @@ func MatchExpeditionLaunches() error {
 			endDate, _ = parseDate(exp.End)
 		}
 
-		matchFound := false
+		matches := matchExpeditionFlights(exp, startDate, endDate, flights)
+		needsReview := matchesNeedReview(matches)
 
-		for _, launch := range launches {
-			launchDate, err := parseDate(launch.Net)
-			if err != nil {
-				fmt.Printf("⚠️  Invalid launch date for '%s': %s\n", launch.Name, launch.Net)
-				continue
-			}
+		if len(matches) == 0 {
+			fmt.Printf("❌ No launch match found for expedition: %s\n", exp.Name)
+		}
+		for _, m := range matches {
+			fmt.Printf("🚀 Launch '%s' matches expedition %s '%s' (%s)\n", m.LaunchName, strings.ToUpper(m.Role), exp.Name, m.Source)
+		}
+
+		if err := storeExpeditionLaunchMatches(exp, matches, needsReview); err != nil {
+			fmt.Printf("❌ Failed to store launch matches for %s: %v\n", exp.Name, err)
+			continue
+		}
+		stored++
+		if needsReview {
+			review++
+		}
+	}
+
+	fmt.Printf("✅ Stored launch matches for %d expeditions (%d flagged for review)\n", stored, review)
+	return nil
+}
+
+// matchExpeditionFlights finds flights whose crew launched at the expedition start
+// or landed at its end, falling back to same-day launches when crew data is missing
+func matchExpeditionFlights(exp Expedition, start, end time.Time, flights []SpacecraftFlight) []ExpeditionLaunchMatch {
+	crew := make(map[int]bool)
+	for _, member := range exp.Crew {
+		crew[member.Astronaut.ID] = true
+	}
 
-			// fmt.Printf("🔎 Comparing Expedition '%s' (%s) with Launch '%s' (%s)\n",
-			// 	exp.Name, startDate.Format("2006-01-02"), launch.Name, launchDate.Format("2006-01-02"))
+	var matches []ExpeditionLaunchMatch
+	for _, f := range flights {
+		launchDate, err := parseDate(f.Launch.Net)
+		if err != nil {
+			continue
+		}
 
-			if sameDay(launchDate, startDate) {
-				fmt.Printf("🚀 Launch '%s' matches expedition START '%s'\n", launch.Name, exp.Name)
-				matchFound = true
+		if sharesCrew(crew, f.LaunchCrew) && (withinTolerance(launchDate, start) || dockedNear(f, start)) {
+			matches = append(matches, newExpeditionMatch(f, "start", "spacecraft_flight"))
+		}
+
+		if !end.IsZero() && sharesCrew(crew, f.LandingCrew) {
+			if missionEnd, err := parseDate(f.MissionEnd); err == nil && withinTolerance(missionEnd, end) {
+				matches = append(matches, newExpeditionMatch(f, "end", "spacecraft_flight"))
 			}
+		}
+	}
+
+	if hasRole(matches, "start") && (end.IsZero() || hasRole(matches, "end")) {
+		return matches
+	}
 
-			if !endDate.IsZero() && sameDay(launchDate, endDate) {
-				fmt.Printf("🛬 Launch '%s' matches expedition END '%s'\n", launch.Name, exp.Name)
-				matchFound = true
+	// Fall back to the old same-day heuristic for whichever boundary is still unmatched
+	for _, f := range flights {
+		launchDate, err := parseDate(f.Launch.Net)
+		if err != nil {
+			continue
+		}
+		if !hasRole(matches, "start") && sameDay(launchDate, start) {
+			matches = append(matches, newExpeditionMatch(f, "start", "same_day"))
+		}
+		if !end.IsZero() && !hasRole(matches, "end") {
+			if missionEnd, err := parseDate(f.MissionEnd); err == nil && sameDay(missionEnd, end) {
+				matches = append(matches, newExpeditionMatch(f, "end", "same_day"))
 			}
 		}
+	}
 
-		if !matchFound {
-			fmt.Printf("❌ No launch match found for expedition: %s\n", exp.Name)
+	return matches
+}
+
+func newExpeditionMatch(f SpacecraftFlight, role, source string) ExpeditionLaunchMatch {
+	return ExpeditionLaunchMatch{
+		LaunchID:   f.Launch.ID,
+		LaunchName: f.Launch.Name,
+		FlightID:   f.ID,
+		Role:       role,
+		Source:     source,
+	}
+}
+
+// matchesNeedReview flags heuristic matches and boundaries with more than one candidate launch
+func matchesNeedReview(matches []ExpeditionLaunchMatch) bool {
+	perRole := make(map[string]map[string]bool)
+	for _, m := range matches {
+		if m.Source != "spacecraft_flight" {
+			return true
+		}
+		if perRole[m.Role] == nil {
+			perRole[m.Role] = make(map[string]bool)
+		}
+		perRole[m.Role][m.LaunchID] = true
+	}
+	for _, launches := range perRole {
+		if len(launches) > 1 {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil
+// storeExpeditionLaunchMatches writes the matches onto the expedition record, relating
+// any launches that already exist in the events collection
+func storeExpeditionLaunchMatches(exp Expedition, matches []ExpeditionLaunchMatch, needsReview bool) error {
+	expeditionID, err := findPBRecordID("expeditions", fmt.Sprintf("api_id=%d", exp.ID))
+	if err != nil {
+		return err
+	}
+	if expeditionID == "" {
+		dryrun.NoteUnresolved("expeditions", "start_launches", "expedition "+exp.Name, "not synced yet")
+		return fmt.Errorf("expedition %q not synced yet", exp.Name)
+	}
+
+	startLaunches := []string{}
+	endLaunches := []string{}
+	for _, m := range matches {
+		if m.LaunchID == "" {
+			continue
+		}
+		eventID, err := findPBRecordID("events", fmt.Sprintf(`spacedevs_id="%s"`, m.LaunchID))
+		if err != nil {
+			return err
+		}
+		if eventID == "" {
+			continue
+		}
+		if m.Role == "start" {
+			startLaunches = appendUnique(startLaunches, eventID)
+		} else {
+			endLaunches = appendUnique(endLaunches, eventID)
+		}
+	}
+
+	if matches == nil {
+		matches = []ExpeditionLaunchMatch{}
+	}
+
+	return updatePBRecord("expeditions", expeditionID, map[string]any{
+		"start_launches":      startLaunches,
+		"end_launches":        endLaunches,
+		"launch_matches":      matches,
+		"launch_match_review": needsReview,
+	})
 }
 
 func fetchExpeditions() ([]Expedition, error) {
-	url := "https://ll.thespacedevs.com/2.3.0/expeditions/?limit=100&ordering=-start"
-	resp, err := http.Get(url)
+	var expeditions []Expedition
+	pageURL := spaceDevsAPI + "/expeditions/?limit=100&ordering=-start&mode=detailed"
+	for pageURL != "" {
+		var data SpaceDevsExpeditionsResponse
+		if err := getSpaceDevsJSON(pageURL, &data); err != nil {
+			return nil, fmt.Errorf("fetch expeditions: %w", err)
+		}
+		expeditions = append(expeditions, data.Results...)
+		pageURL = data.Next
+	}
+	return expeditions, nil
+}
+
+// unsettledExpeditions drops the expeditions that have ended and already have a start
+// and end launch stored without needing review; their matches won't change
+func unsettledExpeditions(expeditions []Expedition) ([]Expedition, error) {
+	records, err := listPBRecords("expeditions", `end_date!="" && launch_match_review=false`)
 	if err != nil {
-		return nil, fmt.Errorf("fetch expeditions: %w", err)
+		return nil, err
+	}
+	settled := make(map[int]bool, len(records))
+	for _, record := range records {
+		apiID, _ := record["api_id"].(float64)
+		startLaunches, _ := record["start_launches"].([]any)
+		endLaunches, _ := record["end_launches"].([]any)
+		if len(startLaunches) > 0 && len(endLaunches) > 0 {
+			settled[int(apiID)] = true
+		}
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	var data SpaceDevsExpeditionsResponse
-	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, fmt.Errorf("parse expeditions JSON: %w", err)
+	var pending []Expedition
+	for _, exp := range expeditions {
+		if !settled[exp.ID] || exp.End == "" {
+			pending = append(pending, exp)
+		}
 	}
-	return data.Results, nil
+	return pending, nil
 }
 
-func fetchLaunches() ([]LaunchEE, error) {
-	url := "https://ll.thespacedevs.com/2.2.0/launch/upcoming/?limit=50&mode=detailed&offset="
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("fetch launches: %w", err)
+// expeditionFlightWindow is the span of launch dates a flight starting or ending one
+// of the expeditions can have. Ongoing expeditions run until now.
+func expeditionFlightWindow(expeditions []Expedition, now time.Time) (from, to time.Time) {
+	for _, exp := range expeditions {
+		start, err := parseDate(exp.Start)
+		if err != nil {
+			continue
+		}
+		end := now
+		if exp.End != "" {
+			if parsed, err := parseDate(exp.End); err == nil {
+				end = parsed
+			}
+		}
+		if from.IsZero() || start.Before(from) {
+			from = start
+		}
+		if end.After(to) {
+			to = end
+		}
+	}
+	return from.Add(-expeditionFlightLookback), to.Add(expeditionMatchTolerance)
+}
+
+func fetchSpacecraftFlights(from, to time.Time) ([]SpacecraftFlight, error) {
+	var flights []SpacecraftFlight
+	pageURL := spaceDevsAPI + "/spacecraft/flights/?limit=100&mode=detailed" +
+		"&launch__net__gte=" + url.QueryEscape(from.UTC().Format(time.RFC3339)) +
+		"&launch__net__lte=" + url.QueryEscape(to.UTC().Format(time.RFC3339))
+	for pageURL != "" {
+		var data SpacecraftFlightsResponse
+		if err := getSpaceDevsJSON(pageURL, &data); err != nil {
+			return nil, fmt.Errorf("fetch spacecraft flights: %w", err)
+		}
+		flights = append(flights, data.Results...)
+		pageURL = data.Next
 	}
-	defer resp.Body.Close()
+	return flights, nil
+}
 
-	body, _ := io.ReadAll(resp.Body)
-	var data LaunchesResponse
-	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, fmt.Errorf("parse launches JSON: %w", err)
+func sharesCrew(crew map[int]bool, members []FlightCrewMember) bool {
+	for _, member := range members {
+		if crew[member.Astronaut.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+func dockedNear(f SpacecraftFlight, t time.Time) bool {
+	for _, d := range f.DockingEvents {
+		if docked, err := parseDate(d.Docking); err == nil && withinTolerance(docked, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func withinTolerance(a, b time.Time) bool {
+	diff := a.Sub(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= expeditionMatchTolerance
+}
+
+func hasRole(matches []ExpeditionLaunchMatch, role string) bool {
+	for _, m := range matches {
+		if m.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(ids []string, id string) []string {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
 	}
-	return data.Results, nil
+	return append(ids, id)
 }
 
 func sameDay(a, b time.Time) bool {
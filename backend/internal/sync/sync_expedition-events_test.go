@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreExpeditionLaunchMatchesByAPIID(t *testing.T) {
+	fake := replay(t)
+
+	eventID, err := createPBRecord("events", map[string]any{"title": "Soyuz MS-25", "spacedevs_id": "launch-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same name, different expedition: only the api_id may pick the record
+	for _, exp := range []map[string]any{
+		{"api_id": 70, "name": `Expedition "70"`},
+		{"api_id": 71, "name": `Expedition "70"`},
+	} {
+		if _, err := createPBRecord("expeditions", exp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp := Expedition{ID: 71, Name: `Expedition "70"`}
+	matches := []ExpeditionLaunchMatch{{LaunchID: "launch-1", Role: "start", Source: "spacecraft_flight"}}
+	if err := storeExpeditionLaunchMatches(exp, matches, false); err != nil {
+		t.Fatal(err)
+	}
+
+	starts, _ := byAPIID(t, fake, "expeditions", 71)["start_launches"].([]any)
+	if len(starts) != 1 || starts[0] != eventID {
+		t.Errorf("expedition 71 start_launches = %v, want [%s]", starts, eventID)
+	}
+	if starts, _ := byAPIID(t, fake, "expeditions", 70)["start_launches"].([]any); len(starts) != 0 {
+		t.Errorf("expedition 70 start_launches = %v, want none", starts)
+	}
+}
+
+func TestUnsettledExpeditions(t *testing.T) {
+	replay(t)
+
+	for _, exp := range []map[string]any{
+		// Ended with both boundaries stored
+		{"api_id": 1, "end_date": "2024-03-01", "start_launches": []string{"a"}, "end_launches": []string{"b"}, "launch_match_review": false},
+		// Ended, but its match was flagged for review
+		{"api_id": 2, "end_date": "2024-06-01", "start_launches": []string{"a"}, "end_launches": []string{"b"}, "launch_match_review": true},
+		// Ended without an end launch
+		{"api_id": 3, "end_date": "2024-09-01", "start_launches": []string{"a"}, "end_launches": []string{}, "launch_match_review": false},
+	} {
+		if _, err := createPBRecord("expeditions", exp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expeditions := []Expedition{
+		{ID: 1, Start: "2023-09-27", End: "2024-03-01"},
+		{ID: 2, Start: "2024-03-01", End: "2024-06-01"},
+		{ID: 3, Start: "2024-06-01", End: "2024-09-01"},
+		{ID: 4, Start: "2024-09-01"},
+	}
+	pending, err := unsettledExpeditions(expeditions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, exp := range pending {
+		ids = append(ids, exp.ID)
+	}
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 3 || ids[2] != 4 {
+		t.Errorf("unsettled expeditions = %v, want [2 3 4]", ids)
+	}
+
+	// The window reaches back for long stays and runs to now for the ongoing expedition
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	from, to := expeditionFlightWindow(pending, now)
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Add(-expeditionFlightLookback); !from.Equal(want) {
+		t.Errorf("window starts %s, want %s", from, want)
+	}
+	if want := now.Add(expeditionMatchTolerance); !to.Equal(want) {
+		t.Errorf("window ends %s, want %s", to, want)
+	}
+}
@@ -1,32 +1,27 @@
 package sync
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"time"
+
+	"github.com/signal-k/notifs/internal/dryrun"
 )
 
 type SpaceDevsExpedition struct {
+	ID        int    `json:"id"`
 	Name      string `json:"name"`
 	StartDate string `json:"start"`
 	EndDate   string `json:"end"`
 	URL       string `json:"url"`
 	Station   struct {
+		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"spacestation"`
-	MissionPatches []struct {
-		Name     string `json:"name"`
-		ImageURL string `json:"image_url"`
-	} `json:"mission_patches"`
-	Crew []struct {
-		Astronaut struct {
-			Name string `json:"name"`
-		} `json:"astronaut"`
-	} `json:"crew"`
+	MissionPatches []MissionPatch     `json:"mission_patches"`
+	Crew           []FlightCrewMember `json:"crew"`
 }
 
 type SpaceDevsResponseExpeditions struct {
@@ -36,7 +31,7 @@ type SpaceDevsResponseExpeditions struct {
 func SyncExpeditions() error {
 	fmt.Println("🚀 Fetching expeditions...")
 
-	resp, err := http.Get("https://ll.thespacedevs.com/2.3.0/expeditions/?limit=30&ordering=-start&mode=detailed")
+	resp, err := http.Get(spaceDevsAPI + "/expeditions/?limit=30&ordering=-start&mode=detailed")
 	if err != nil {
 		return fmt.Errorf("failed to fetch expeditions: %w", err)
 	}
@@ -53,49 +48,67 @@ func SyncExpeditions() error {
 	}
 
 	for _, exp := range data.Results {
-		if err := createExpeditionInPocketbase(exp); err != nil {
-			fmt.Printf("❌ Failed to insert %s: %v\n", exp.Name, err)
+		if err := upsertExpeditionInPocketbase(exp); err != nil {
+			fmt.Printf("❌ Failed to sync %s: %v\n", exp.Name, err)
 		} else {
-			fmt.Printf("✅ Inserted expedition: %s\n", exp.Name)
+			fmt.Printf("✅ Synced expedition: %s\n", exp.Name)
 		}
 	}
 
 	return nil
 }
 
-func createExpeditionInPocketbase(exp SpaceDevsExpedition) error {
+// upsertExpeditionInPocketbase stores an expedition keyed by its SpaceDevs ID, along with
+// its mission patches and one expedition_crew record per crew member
+func upsertExpeditionInPocketbase(exp SpaceDevsExpedition) error {
 	var stationId string
 	var err error
 
 	if exp.Station.Name != "" {
-		stationId, err = findStationIdByName(exp.Station.Name)
+		stationId, err = findPBRecordID("stations", fmt.Sprintf("api_id=%d", exp.Station.ID))
+		if err != nil || stationId == "" {
+			stationId, err = findStationIdByName(exp.Station.Name)
+		}
 		if err != nil {
 			fmt.Printf("⚠️  Station not found for expedition %s: %s\n", exp.Name, exp.Station.Name)
+			dryrun.NoteUnresolved("expeditions", "station", "station "+exp.Station.Name, "not found")
 			stationId = ""
 		}
 	}
 
+	// Resolve every crew member up front so the crew relation and role records agree
 	crewIds := []string{}
-	for _, member := range exp.Crew {
-		name := member.Astronaut.Name
-		id, err := findAstronautIdByName(name)
-		if err == nil && id != "" {
-			crewIds = append(crewIds, id)
+	astronautIds := make([]string, len(exp.Crew))
+	for i, member := range exp.Crew {
+		id, err := resolveAstronautID(member.Astronaut.ID, member.Astronaut.Name)
+		if err != nil {
+			fmt.Printf("⚠️  Astronaut lookup failed for %s: %v\n", member.Astronaut.Name, err)
+			continue
 		}
+		if id == "" {
+			fmt.Printf("⚠️  Astronaut %s not synced yet\n", member.Astronaut.Name)
+			dryrun.NoteUnresolved("expeditions", "crew", "astronaut "+member.Astronaut.Name, "not synced yet")
+			continue
+		}
+		astronautIds[i] = id
+		crewIds = appendUnique(crewIds, id)
 	}
 
-	// Pick first mission patch if any
+	patchIds := upsertMissionPatches(exp.MissionPatches)
+
+	// The app still reads the first patch image from the plain patches field
 	patchImage := ""
 	if len(exp.MissionPatches) > 0 {
 		patchImage = exp.MissionPatches[0].ImageURL
 	}
-	fmt.Printf("🎖️  Expedition '%s' patch image: %s\n", exp.Name, patchImage)
 
 	payload := map[string]any{
-		"name":       exp.Name,
-		"start_date": exp.StartDate,
-		"url":        exp.URL,
-		"crew":       crewIds,
+		"api_id":          exp.ID,
+		"name":            exp.Name,
+		"start_date":      exp.StartDate,
+		"url":             exp.URL,
+		"crew":            crewIds,
+		"mission_patches": patchIds,
 	}
 
 	if exp.EndDate != "" {
@@ -108,68 +121,49 @@ func createExpeditionInPocketbase(exp SpaceDevsExpedition) error {
 		payload["patches"] = patchImage
 	}
 
-	jsonData, err := json.Marshal(payload)
+	expeditionID, err := findPBRecordID("expeditions", fmt.Sprintf(`api_id=%d || (api_id=0 && name="%s")`, exp.ID, exp.Name))
 	if err != nil {
-		return fmt.Errorf("failed to marshal expedition: %w", err)
+		return err
 	}
-
-	req, err := http.NewRequest("POST", "http://127.0.0.1:8080/api/collections/expeditions/records", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to build POST request: %w", err)
+	if expeditionID != "" {
+		err = updatePBRecord("expeditions", expeditionID, payload)
+	} else {
+		expeditionID, err = createPBRecord("expeditions", payload)
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	res, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("POST request failed: %w", err)
+		return err
 	}
-	defer res.Body.Close()
 
-	body, _ := io.ReadAll(res.Body)
-
-	if res.StatusCode >= 400 {
-		return fmt.Errorf("HTTP %d: %s", res.StatusCode, string(body))
+	for i, member := range exp.Crew {
+		if err := upsertExpeditionCrew(expeditionID, astronautIds[i], member); err != nil {
+			fmt.Printf("❌ Failed to store crew role for %s on %s: %v\n", member.Astronaut.Name, exp.Name, err)
+		}
 	}
 
-	fmt.Printf("✅ Success for %s\n", exp.Name)
 	return nil
 }
 
-func findStationIdByName(name string) (string, error) {
-	encodedName := url.QueryEscape(fmt.Sprintf(`name="%s"`, name))
-	query := fmt.Sprintf("http://127.0.0.1:8080/api/collections/stations/records?filter=%s", encodedName)
-
-	resp, err := http.Get(query)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	var result struct {
-		Items []struct {
-			ID string `json:"id"`
-		} `json:"items"`
-	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+// upsertExpeditionCrew records a crew member's role (Commander, Flight Engineer, ...) on an expedition
+func upsertExpeditionCrew(expeditionID, astronautID string, member FlightCrewMember) error {
+	payload := map[string]any{
+		"api_id":           member.ID,
+		"expedition":       expeditionID,
+		"astronaut_api_id": member.Astronaut.ID,
+		"astronaut_name":   member.Astronaut.Name,
+		"role":             member.Role.Role,
+		"role_priority":    member.Role.Priority,
 	}
-
-	if len(result.Items) == 0 {
-		return "", fmt.Errorf("station %s not found", name)
+	if astronautID != "" {
+		payload["astronaut"] = astronautID
 	}
 
-	return result.Items[0].ID, nil
+	_, _, err := upsertPBRecordByAPIID("expedition_crew", member.ID, payload)
+	return err
 }
 
-func findAstronautIdByName(name string) (string, error) {
+func findStationIdByName(name string) (string, error) {
 	encodedName := url.QueryEscape(fmt.Sprintf(`name="%s"`, name))
-	query := fmt.Sprintf("http://127.0.0.1:8080/api/collections/astronauts/records?filter=%s", encodedName)
+	query := fmt.Sprintf("http://127.0.0.1:8080/api/collections/stations/records?filter=%s", encodedName)
 
 	resp, err := http.Get(query)
 	if err != nil {
@@ -192,7 +186,7 @@ func findAstronautIdByName(name string) (string, error) {
 	}
 
 	if len(result.Items) == 0 {
-		return "", fmt.Errorf("astronaut %s not found", name)
+		return "", fmt.Errorf("station %s not found", name)
 	}
 
 	return result.Items[0].ID, nil
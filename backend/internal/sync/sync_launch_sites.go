@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// FlexibleFloat accepts coordinates sent either as JSON numbers (2.3) or as
+// numeric strings (2.2). Empty or unparseable values decode to zero.
+type FlexibleFloat float64
+
+func (f *FlexibleFloat) UnmarshalJSON(data []byte) error {
+	var num float64
+	if err := json.Unmarshal(data, &num); err == nil {
+		*f = FlexibleFloat(num)
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		// null and other shapes are treated as missing
+		*f = 0
+		return nil
+	}
+
+	num, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		*f = 0
+		return nil
+	}
+	*f = FlexibleFloat(num)
+	return nil
+}
+
+// LaunchSite is the launch location a pad or landing zone belongs to, e.g.
+// "Cape Canaveral SFS, FL, USA"
+type LaunchSite struct {
+	ID                int           `json:"id"`
+	Name              string        `json:"name"`
+	CountryCode       string        `json:"country_code"`
+	Description       string        `json:"description"`
+	MapImage          string        `json:"map_image"`
+	TimezoneName      string        `json:"timezone_name"`
+	Latitude          FlexibleFloat `json:"latitude"`
+	Longitude         FlexibleFloat `json:"longitude"`
+	TotalLaunchCount  int           `json:"total_launch_count"`
+	TotalLandingCount int           `json:"total_landing_count"`
+}
+
+// syncLaunchSite upserts a launch location keyed by api_id and returns its record ID.
+// The launch and landing totals are refreshed on every pass.
+func syncLaunchSite(client *pbclient.Client, site LaunchSite) string {
+	if site.ID == 0 {
+		return ""
+	}
+
+	data := map[string]interface{}{
+		"api_id":              site.ID,
+		"name":                site.Name,
+		"country_code":        site.CountryCode,
+		"description":         site.Description,
+		"map_image":           site.MapImage,
+		"timezone":            site.TimezoneName,
+		"total_launch_count":  site.TotalLaunchCount,
+		"total_landing_count": site.TotalLandingCount,
+	}
+	// 2.2 locations carry no coordinates; don't wipe ones stored from 2.3
+	if site.Latitude != 0 || site.Longitude != 0 {
+		data["latitude"] = float64(site.Latitude)
+		data["longitude"] = float64(site.Longitude)
+	}
+
+	return upsertLaunchRecord(client, "locations", "api_id", site.ID, site.Name, data)
+}
+
+// syncLaunchPad upserts the pad snapshot embedded in a launch under its launch site,
+// refreshing the launch counts each time the launch is seen
+func syncLaunchPad(client *pbclient.Client, p Pad, siteID string) string {
+	launchCountTotal := p.LaunchCountTotal
+	if launchCountTotal == 0 {
+		launchCountTotal = p.TotalLaunchCount
+	}
+
+	locationName := p.LocationName
+	countryCode := p.CountryCode
+	timezone := ""
+	if p.Location != nil {
+		if locationName == "" {
+			locationName = p.Location.Name
+		}
+		if countryCode == "" {
+			countryCode = p.Location.CountryCode
+		}
+		timezone = p.Location.TimezoneName
+	}
+
+	data := map[string]interface{}{
+		"spacedevs_id":       p.ID,
+		"name":               p.Name,
+		"description":        p.Description,
+		"country_code":       countryCode,
+		"location_name":      locationName,
+		"location":           siteID,
+		"timezone":           timezone,
+		"map_url":            p.MapURL,
+		"wiki_url":           p.WikiURL,
+		"map_image":          p.MapImage,
+		"launch_count_year":  p.LaunchCountYear,
+		"launch_count_total": launchCountTotal,
+	}
+	// A pad without coordinates in this payload keeps the ones already stored
+	if p.Latitude != 0 || p.Longitude != 0 {
+		data["latitude"] = float64(p.Latitude)
+		data["longitude"] = float64(p.Longitude)
+	}
+
+	return upsertLaunchRecord(client, "pads", "spacedevs_id", p.ID, p.Name, data)
+}
+
+// syncLandingZone upserts a booster landing location (a drone ship or landing zone)
+// beneath the launch site it sits in
+func syncLandingZone(client *pbclient.Client, ll LandingLocation) string {
+	if ll.ID == 0 {
+		return ""
+	}
+
+	siteID := ""
+	if ll.Location != nil {
+		siteID = syncLaunchSite(client, *ll.Location)
+	}
+
+	return upsertLaunchRecord(client, "landing_zones", "api_id", ll.ID, ll.Name, map[string]interface{}{
+		"api_id":              ll.ID,
+		"name":                ll.Name,
+		"abbrev":              ll.Abbrev,
+		"description":         ll.Description,
+		"successful_landings": ll.SuccessfulLandings,
+		"location":            siteID,
+	})
+}
+
+// upsertLaunchRecord updates the record whose key field matches id, or creates it
+func upsertLaunchRecord(client *pbclient.Client, collection, field string, id int, name string, data map[string]interface{}) string {
+	record, _ := client.FindRecordByField(collection, field, id)
+	if record != nil {
+		recordID := (*record)["id"].(string)
+		if err := client.UpdateRecord(collection, recordID, data); err != nil {
+			log.Printf("❌ Failed to update %s %s: %v", collection, name, err)
+		}
+		return recordID
+	}
+
+	created, err := client.CreateRecord(collection, data)
+	if err != nil {
+		log.Printf("❌ Failed to insert %s %s: %v", collection, name, err)
+		return ""
+	}
+	recordID, _ := (*created)["id"].(string)
+	return recordID
+}
@@ -0,0 +1,25 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+func TestLaunchPadKeepsStoredCoordinates(t *testing.T) {
+	fake := replay(t)
+	client := pbclient.NewClient(pocketbaseURL)
+
+	pad := Pad{ID: 80, Name: "Launch Complex 39A", Latitude: 28.60822681, Longitude: -80.60428186}
+	syncLaunchPad(client, pad, "")
+
+	// A later payload without coordinates only refreshes the counts
+	syncLaunchPad(client, Pad{ID: 80, Name: "Launch Complex 39A", TotalLaunchCount: 201}, "")
+
+	record := only(t, fake, "pads")
+	expectFields(t, record, map[string]any{
+		"latitude":           28.60822681,
+		"longitude":          -80.60428186,
+		"launch_count_total": float64(201),
+	})
+}
@@ -3,9 +3,10 @@ package sync
 // SyncError is a simple error type for HTTP sync errors
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -13,18 +14,37 @@ import (
 	"github.com/signal-k/notifs/internal/pbclient"
 )
 
+// Agency is a SpaceDevs agency as embedded in launches and listed by /agencies. Fields
+// missing from the 2.2 payloads are left empty.
 type Agency struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Abbrev   string `json:"abbrev"`
-	Type     string `json:"type"`
-	Country  string `json:"country_code"`
-	Desc     string `json:"description"`
-	Founded  string `json:"founding_year"`
-	LogoURL  string `json:"logo_url"`
-	ImageURL string `json:"image_url"`
-	WikiURL  string `json:"wiki_url"`
-	InfoURL  string `json:"info_url"`
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Abbrev        string `json:"abbrev"`
+	Type          string `json:"type"`
+	Country       string `json:"country_code"`
+	Desc          string `json:"description"`
+	Administrator string `json:"administrator"`
+	Founded       string `json:"founding_year"`
+	Launchers     string `json:"launchers"`
+	Spacecraft    string `json:"spacecraft"`
+	Featured      bool   `json:"featured"`
+	URL           string `json:"url"`
+	LogoURL       string `json:"logo_url"`
+	SocialLogoURL string `json:"-"` // 2.3 only
+	ImageURL      string `json:"image_url"`
+	WikiURL       string `json:"wiki_url"`
+	InfoURL       string `json:"info_url"`
+
+	Countries []spaceDevsCountry `json:"-"` // 2.3 only
+}
+
+// Ref returns the minimal reference ensureAgency resolves
+func (a Agency) Ref() AgencyRef {
+	ref := AgencyRef{ID: a.ID, Name: a.Name, Abbrev: a.Abbrev}
+	if a.Type != "" {
+		ref.Type = &FlexibleNameField{Name: a.Type}
+	}
+	return ref
 }
 
 // SyncError is a simple error type for HTTP sync errors
@@ -55,10 +75,10 @@ type Launch struct {
 	Rocket                     Rocket          `json:"rocket"`
 	Pad                        Pad             `json:"pad"`
 	InfoURLs                   []Link          `json:"infoURLs"`
-	VideoURLs                  []Link          `json:"vidURLs"`
+	VideoURLs                  []VidURL        `json:"vidURLs"`
 	WebcastLive                bool            `json:"webcast_live"`
-	Image                      string          `json:"image"`
-	Infographic                string          `json:"infographic"`
+	Image                      string          `json:"image"`       // URL in 2.2, object in 2.3
+	Infographic                string          `json:"infographic"` // URL in 2.2, object in 2.3
 	Timeline                   []TimelineEntry `json:"timeline"`
 	Updates                    []Update        `json:"updates"`
 	Program                    []Program       `json:"program"`
@@ -80,7 +100,7 @@ type Link struct {
 }
 
 type TimelineEntry struct {
-	Time  int    `json:"time"` // seconds before/after T0
+	Time  int    `json:"time"` // seconds before/after T0 (relative_time in 2.3)
 	Event string `json:"event"`
 }
 
@@ -115,22 +135,15 @@ type Program struct {
 	Description    string         `json:"description"`
 	Agencies       []Agency       `json:"agencies"`
 	ImageURL       string         `json:"image_url"`
+	ThumbnailURL   string         `json:"-"` // 2.3 only
 	StartDate      string         `json:"start_date"`
-	EndDate        *string        `json:"end_date"`
-	InfoURL        *string        `json:"info_url"`
+	EndDate        string         `json:"end_date"`
+	InfoURL        string         `json:"info_url"`
 	WikiURL        string         `json:"wiki_url"`
 	MissionPatches []MissionPatch `json:"mission_patches"`
 	Type           ProgramType    `json:"type"`
 }
 
-type MissionPatch struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Priority int    `json:"priority"`
-	ImageURL string `json:"image_url"`
-	Agency   Agency `json:"agency"`
-}
-
 type ProgramType struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
@@ -219,7 +232,7 @@ type LandingLocation struct {
 	Name               string      `json:"name"`
 	Abbrev             string      `json:"abbrev"`
 	Description        string      `json:"description"`
-	Location           interface{} `json:"location"`
+	Location           *LaunchSite `json:"location"`
 	SuccessfulLandings int         `json:"successful_landings"`
 }
 
@@ -324,18 +337,20 @@ type SpacecraftType struct {
 }
 
 type Pad struct {
-	ID               int    `json:"id"`
-	Name             string `json:"name"`
-	Description      string `json:"description"`
-	Latitude         string `json:"latitude"`
-	Longitude        string `json:"longitude"`
-	CountryCode      string `json:"country_code"`
-	LocationName     string `json:"location_name"`
-	MapURL           string `json:"map_url"`
-	WikiURL          string `json:"wiki_url"`
-	MapImage         string `json:"map_image"`
-	LaunchCountYear  int    `json:"launch_count_year"`
-	LaunchCountTotal int    `json:"launch_count_total"`
+	ID               int           `json:"id"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	Latitude         FlexibleFloat `json:"latitude"`
+	Longitude        FlexibleFloat `json:"longitude"`
+	CountryCode      string        `json:"country_code"`
+	LocationName     string        `json:"location_name"`
+	Location         *LaunchSite   `json:"location"`
+	MapURL           string        `json:"map_url"`
+	WikiURL          string        `json:"wiki_url"`
+	MapImage         string        `json:"map_image"`
+	LaunchCountYear  int           `json:"launch_count_year"`
+	LaunchCountTotal int           `json:"launch_count_total"`
+	TotalLaunchCount int           `json:"total_launch_count"`
 }
 
 type LaunchAPIResponse struct {
@@ -364,341 +379,365 @@ func getThrottleDelay(body string) time.Duration {
 	return 60 * time.Second // fallback
 }
 
-func SyncLaunchProvidersAndEvents(client *pbclient.Client) {
-	go func() {
-		offset := 0
-		fetchCount := 0
-		for {
-			log.Printf("📡 Fetching launches (offset %d)...", offset)
-			url := "https://ll.thespacedevs.com/2.2.0/launch/upcoming/?limit=50&mode=detailed&offset=" + strconv.Itoa(offset)
-			resp, err := http.Get(url)
-			if err != nil {
-				log.Printf("❌ Failed to fetch launches: %v", err)
-				time.Sleep(1 * time.Minute)
-				continue
+// retryError is a failed fetch of launches, with how long to wait before trying again
+type retryError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+
+func (e *retryError) Unwrap() error { return e.err }
+
+// RetryAfter is how long to wait before fetching again after err, or 0 when err didn't
+// come from fetching
+func RetryAfter(err error) time.Duration {
+	var retry *retryError
+	if errors.As(err, &retry) {
+		return retry.after
+	}
+	return 0
+}
+
+// SyncLaunchesOnce fetches the page of upcoming launches at offset and syncs it, returning
+// how many launches the page held
+func SyncLaunchesOnce(client *pbclient.Client, offset int) (int, error) {
+	log.Printf("📡 Fetching launches (offset %d)...", offset)
+	resp, err := spaceDevsHTTPClient.Get(launchesURL(LaunchLibraryVersion, offset))
+	if err != nil {
+		return 0, &retryError{fmt.Errorf("fetch launches: %w", err), time.Minute}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 429 {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, &retryError{NewSyncError(resp.StatusCode, string(body)), getThrottleDelay(string(body))}
+	}
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, &retryError{NewSyncError(resp.StatusCode, string(body)), 30 * time.Minute}
+	}
+
+	var result LaunchAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, &retryError{fmt.Errorf("invalid JSON: %w", err), 30 * time.Minute}
+	}
+
+	for _, l := range result.Results {
+		// ...existing sync logic for provider, rocket, pad, mission, event...
+		provider := l.LaunchServiceProvider
+		var providerPBID, rocketPBID, padPBID, missionPBID, landingZonePBID string
+		if provider.ID != 0 && provider.Name != "" {
+			providerPBID = syncLaunchAgency(client, provider, true)
+		}
+
+		// Sync Rocket
+		if r := l.Rocket; r.ID != 0 && r.FullName != "" {
+			rocketRecord, _ := client.FindRecordByField("rockets", "spacedevs_id", r.ID)
+			if rocketRecord == nil {
+				created, err := client.CreateRecord("rockets", map[string]interface{}{
+					"spacedevs_id": r.ID,
+					"name":         r.Name,
+					"full_name":    r.FullName,
+					"variant":      r.Variant,
+					"family":       r.Family,
+					"reusable":     r.Reusable,
+					"description":  r.Description,
+					"launch_mass":  r.LaunchMass,
+					"leo_capacity": r.LEOCapacity,
+					"gto_capacity": r.GTOCapacity,
+					"image_url":    r.ImageURL,
+					"info_url":     r.InfoURL,
+					"wiki_url":     r.WikiURL,
+					"manufacturer": r.Manufacturer.Name,
+				})
+				if err != nil {
+					log.Printf("❌ Failed to insert rocket %s: %v", r.FullName, err)
+				} else {
+					rocketPBID = (*created)["id"].(string)
+				}
+			} else {
+				rocketPBID = (*rocketRecord)["id"].(string)
 			}
+		}
 
-			if resp.StatusCode == 429 {
-				body, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				delay := getThrottleDelay(string(body))
-				log.Printf("❌ Launches: HTTP 429: %s. Retrying in %v...", string(body), delay)
-				time.Sleep(delay)
-				continue
+		// Link the rocket configuration to its programs and manufacturer agency
+		if rocketPBID != "" {
+			manufacturer := l.Rocket.Configuration.Manufacturer
+			if manufacturer.ID == 0 {
+				manufacturer = l.Rocket.Manufacturer
 			}
-			if resp.StatusCode != 200 {
-				body, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				log.Printf("❌ Launches: HTTP %d: %s", resp.StatusCode, string(body))
-				time.Sleep(30 * time.Minute)
-				continue
+			rocketLinks := map[string]interface{}{
+				"programs": syncLaunchPrograms(client, l.Rocket.Configuration.Program),
 			}
-
-			var result LaunchAPIResponse
-			err = json.NewDecoder(resp.Body).Decode(&result)
-			resp.Body.Close()
-			if err != nil {
-				log.Printf("❌ Invalid JSON: %v", err)
-				time.Sleep(30 * time.Minute)
-				continue
+			if manufacturer.ID != 0 {
+				rocketLinks["manufacturer_agency"] = syncLaunchAgency(client, manufacturer, false)
 			}
-
-			if len(result.Results) == 0 {
-				log.Printf("⏭️ No more launches found at offset %d. Resetting to offset 0.", offset)
-				offset = 0
-				fetchCount = 0
-				time.Sleep(30 * time.Minute)
-				continue
+			if err := client.UpdateRecord("rockets", rocketPBID, rocketLinks); err != nil {
+				log.Printf("❌ Failed to link rocket %s: %v", l.Rocket.FullName, err)
 			}
+		}
 
-			for _, l := range result.Results {
-				// ...existing sync logic for provider, rocket, pad, mission, event...
-				provider := l.LaunchServiceProvider
-				var providerPBID, rocketPBID, padPBID, missionPBID string
-				if provider.ID != 0 && provider.Name != "" {
-					providerRecord, _ := client.FindRecordByField("launch_providers", "spacedevs_id", provider.ID)
-					if providerRecord == nil {
-						created, err := client.CreateRecord("launch_providers", map[string]interface{}{
-							"spacedevs_id":  provider.ID,
-							"name":          provider.Name,
-							"abbrev":        provider.Abbrev,
-							"type":          provider.Type,
-							"country_code":  provider.Country,
-							"description":   provider.Desc,
-							"founding_year": provider.Founded,
-							"logo_url":      provider.LogoURL,
-							"image_url":     provider.ImageURL,
-							"wiki_url":      provider.WikiURL,
-							"info_url":      provider.InfoURL,
-						})
-						if err != nil {
-							log.Printf("❌ Failed to insert provider %s: %v", provider.Name, err)
-						} else {
-							providerPBID = (*created)["id"].(string)
-						}
-					} else {
-						providerPBID = (*providerRecord)["id"].(string)
-					}
-				}
+		// Sync Pad beneath its launch site
+		if p := l.Pad; p.ID != 0 && p.Name != "" {
+			siteID := ""
+			if p.Location != nil {
+				siteID = syncLaunchSite(client, *p.Location)
+			}
+			padPBID = syncLaunchPad(client, p, siteID)
+		}
 
-				// Sync Rocket
-				if r := l.Rocket; r.ID != 0 && r.FullName != "" {
-					rocketRecord, _ := client.FindRecordByField("rockets", "spacedevs_id", r.ID)
-					if rocketRecord == nil {
-						created, err := client.CreateRecord("rockets", map[string]interface{}{
-							"spacedevs_id": r.ID,
-							"name":         r.Name,
-							"full_name":    r.FullName,
-							"variant":      r.Variant,
-							"family":       r.Family,
-							"reusable":     r.Reusable,
-							"description":  r.Description,
-							"launch_mass":  r.LaunchMass,
-							"leo_capacity": r.LEOCapacity,
-							"gto_capacity": r.GTOCapacity,
-							"image_url":    r.ImageURL,
-							"info_url":     r.InfoURL,
-							"wiki_url":     r.WikiURL,
-							"manufacturer": r.Manufacturer.Name,
-						})
-						if err != nil {
-							log.Printf("❌ Failed to insert rocket %s: %v", r.FullName, err)
-						} else {
-							rocketPBID = (*created)["id"].(string)
-						}
-					} else {
-						rocketPBID = (*rocketRecord)["id"].(string)
-					}
+		// Sync Mission
+		if m := l.Mission; m != nil && m.ID != 0 && m.Name != "" {
+			missionRecord, _ := client.FindRecordByField("missions", "spacedevs_id", m.ID)
+			if missionRecord == nil {
+				created, err := client.CreateRecord("missions", map[string]interface{}{
+					"spacedevs_id": m.ID,
+					"name":         m.Name,
+					"description":  m.Description,
+					"type":         m.Type,
+					"orbit":        m.Orbit.Name,
+				})
+				if err != nil {
+					log.Printf("❌ Failed to insert mission %s: %v", m.Name, err)
+				} else {
+					missionPBID = (*created)["id"].(string)
 				}
+			} else {
+				missionPBID = (*missionRecord)["id"].(string)
+			}
+		}
 
-				// Sync Pad
-				if p := l.Pad; p.ID != 0 && p.Name != "" {
-					padRecord, _ := client.FindRecordByField("pads", "spacedevs_id", p.ID)
-					if padRecord == nil {
-						created, err := client.CreateRecord("pads", map[string]interface{}{
-							"spacedevs_id":       p.ID,
-							"name":               p.Name,
-							"description":        p.Description,
-							"latitude":           p.Latitude,
-							"longitude":          p.Longitude,
-							"country_code":       p.CountryCode,
-							"location_name":      p.LocationName,
-							"map_url":            p.MapURL,
-							"wiki_url":           p.WikiURL,
-							"map_image":          p.MapImage,
-							"launch_count_year":  p.LaunchCountYear,
-							"launch_count_total": p.LaunchCountTotal,
-						})
-						if err != nil {
-							log.Printf("❌ Failed to insert pad %s: %v", p.Name, err)
-						} else {
-							padPBID = (*created)["id"].(string)
-						}
-					} else {
-						padPBID = (*padRecord)["id"].(string)
-					}
-				}
+		// Programs are shared by the event and its mission
+		programPBIDs := syncLaunchPrograms(client, l.Program)
+		if missionPBID != "" && len(programPBIDs) > 0 {
+			if err := client.UpdateRecord("missions", missionPBID, map[string]interface{}{
+				"programs": programPBIDs,
+			}); err != nil {
+				log.Printf("❌ Failed to link programs to mission %s: %v", l.Mission.Name, err)
+			}
+		}
 
-				// Sync Mission
-				if m := l.Mission; m != nil && m.ID != 0 && m.Name != "" {
-					missionRecord, _ := client.FindRecordByField("missions", "spacedevs_id", m.ID)
-					if missionRecord == nil {
-						created, err := client.CreateRecord("missions", map[string]interface{}{
-							"spacedevs_id": m.ID,
-							"name":         m.Name,
-							"description":  m.Description,
-							"type":         m.Type,
-							"orbit":        m.Orbit.Name,
-						})
-						if err != nil {
-							log.Printf("❌ Failed to insert mission %s: %v", m.Name, err)
-						} else {
-							missionPBID = (*created)["id"].(string)
-						}
-					} else {
-						missionPBID = (*missionRecord)["id"].(string)
-					}
-				}
+		// Sync Event
+		if eventRecord, _ := findLaunchEvent(client, l); eventRecord == nil {
+			launchTime, _ := time.Parse(time.RFC3339, l.Net)
+			windowStart, _ := time.Parse(time.RFC3339, l.WindowStart)
+			windowEnd, _ := time.Parse(time.RFC3339, l.WindowEnd)
+
+			// Classify webcasts (platform, video ID, language...) for vid_urls
+			pbVidURLs := eventVideos(l.VideoURLs)
+
+			// Convert []Link (l.InfoURLs) to []map[string]interface{} for info_urls
+			var pbInfoURLs []map[string]interface{}
+			for _, info := range l.InfoURLs {
+				pbInfoURLs = append(pbInfoURLs, map[string]interface{}{
+					"title":    info.Title,
+					"url":      info.URL,
+					"priority": info.Priority,
+				})
+			}
 
-				// Sync Event
-				if eventRecord, _ := client.FindRecordByField("events", "title", l.Name); eventRecord == nil {
-					launchTime, _ := time.Parse(time.RFC3339, l.Net)
-					windowStart, _ := time.Parse(time.RFC3339, l.WindowStart)
-					windowEnd, _ := time.Parse(time.RFC3339, l.WindowEnd)
-
-					// Convert []Update to []pbclient.Update
-					var pbUpdates []pbclient.Update
-					for _, u := range l.Updates {
-						pbUpdates = append(pbUpdates, pbclient.Update{
-							ID:          strconv.Itoa(u.ID),
-							Title:       u.Comment,
-							Description: u.InfoURL,
-							CreatedAt:   u.CreatedOn,
-						})
-					}
+			// Convert Timeline to []map[string]interface{}
+			var pbTimeline []map[string]interface{}
+			for _, t := range l.Timeline {
+				pbTimeline = append(pbTimeline, map[string]interface{}{
+					"time":  t.Time,
+					"event": t.Event,
+				})
+			}
 
-					// Convert []Link (l.VideoURLs) to []map[string]interface{} for vid_urls
-					var pbVidURLs []map[string]interface{}
-					for _, v := range l.VideoURLs {
-						pbVidURLs = append(pbVidURLs, map[string]interface{}{
-							"title":    v.Title,
-							"url":      v.URL,
-							"priority": v.Priority,
-						})
-					}
+			// Extract rocket configuration details
+			rocketConfigName := ""
+			rocketConfigFullName := ""
+			rocketTotalLaunches := 0
+			rocketSuccessfulLaunches := 0
+			rocketFailedLaunches := 0
+			rocketPendingLaunches := 0
+			if l.Rocket.Configuration.ID != 0 {
+				rocketConfigName = l.Rocket.Configuration.Name
+				rocketConfigFullName = l.Rocket.Configuration.FullName
+				rocketTotalLaunches = l.Rocket.Configuration.TotalLaunchCount
+				rocketSuccessfulLaunches = l.Rocket.Configuration.SuccessfulLaunches
+				rocketFailedLaunches = l.Rocket.Configuration.FailedLaunches
+				rocketPendingLaunches = l.Rocket.Configuration.PendingLaunches
+			}
 
-					// Convert []Link (l.InfoURLs) to []map[string]interface{} for info_urls
-					var pbInfoURLs []map[string]interface{}
-					for _, info := range l.InfoURLs {
-						pbInfoURLs = append(pbInfoURLs, map[string]interface{}{
-							"title":    info.Title,
-							"url":      info.URL,
-							"priority": info.Priority,
-						})
+			// Extract launcher stage details (first stage info)
+			launcherSerialNumber := ""
+			launcherFlightNumber := 0
+			launcherReused := false
+			launcherFlights := 0
+			launcherStatus := ""
+			landingAttempt := false
+			landingSuccess := false
+			landingLocation := ""
+			landingType := ""
+			if len(l.Rocket.LauncherStage) > 0 {
+				stage := l.Rocket.LauncherStage[0]
+				launcherSerialNumber = stage.Launcher.SerialNumber
+				launcherStatus = stage.Launcher.Status
+				if stage.LauncherFlightNumber != nil {
+					launcherFlightNumber = *stage.LauncherFlightNumber
+				}
+				if stage.Reused != nil {
+					launcherReused = *stage.Reused
+				}
+				if stage.Launcher.Flights != nil {
+					launcherFlights = *stage.Launcher.Flights
+				}
+				if stage.Landing != nil {
+					landingAttempt = stage.Landing.Attempt
+					if stage.Landing.Success != nil {
+						landingSuccess = *stage.Landing.Success
 					}
+					landingLocation = stage.Landing.Location.Name
+					landingType = stage.Landing.Type.Name
+					landingZonePBID = syncLandingZone(client, stage.Landing.Location)
+				}
+			}
 
-					// Convert Timeline to []map[string]interface{}
-					var pbTimeline []map[string]interface{}
-					for _, t := range l.Timeline {
-						pbTimeline = append(pbTimeline, map[string]interface{}{
-							"time":  t.Time,
-							"event": t.Event,
-						})
-					}
+			// Extract crew information if spacecraft stage exists
+			var crewMembers []map[string]interface{}
+			if l.Rocket.SpacecraftStage != nil {
+				for _, crew := range l.Rocket.SpacecraftStage.LaunchCrew {
+					crewMembers = append(crewMembers, map[string]interface{}{
+						"astronaut_id":  crew.Astronaut.ID,
+						"name":          crew.Astronaut.Name,
+						"role":          crew.Role.Role,
+						"role_priority": crew.Role.Priority,
+						"nationality":   crew.Astronaut.Nationality,
+						"agency":        crew.Astronaut.Agency.Name,
+						"profile_image": crew.Astronaut.ProfileImageURL,
+					})
+				}
+			}
 
-					// Extract rocket configuration details
-					rocketConfigName := ""
-					rocketConfigFullName := ""
-					rocketTotalLaunches := 0
-					rocketSuccessfulLaunches := 0
-					rocketFailedLaunches := 0
-					rocketPendingLaunches := 0
-					if l.Rocket.Configuration.ID != 0 {
-						rocketConfigName = l.Rocket.Configuration.Name
-						rocketConfigFullName = l.Rocket.Configuration.FullName
-						rocketTotalLaunches = l.Rocket.Configuration.TotalLaunchCount
-						rocketSuccessfulLaunches = l.Rocket.Configuration.SuccessfulLaunches
-						rocketFailedLaunches = l.Rocket.Configuration.FailedLaunches
-						rocketPendingLaunches = l.Rocket.Configuration.PendingLaunches
-					}
+			created, err := client.CreateRecord("events", map[string]interface{}{
+				"title":                         l.Name,
+				"type":                          EventTypeLaunch,
+				"datetime":                      launchTime.Format(time.RFC3339),
+				"window_start":                  windowStart.Format(time.RFC3339),
+				"window_end":                    windowEnd.Format(time.RFC3339),
+				"location":                      l.Pad.Name,
+				"source_url":                    l.URL,
+				"description":                   "Synced from Launch Library",
+				"spacedevs_id":                  l.ID,
+				"provider":                      providerPBID,
+				"rocket_id":                     rocketPBID,
+				"pad_id":                        padPBID,
+				"mission_id":                    missionPBID,
+				"vid_urls":                      pbVidURLs,
+				"info_urls":                     pbInfoURLs,
+				"timeline":                      pbTimeline,
+				"image":                         l.Image,
+				"infographic":                   l.Infographic,
+				"webcast_live":                  l.WebcastLive,
+				"status_abbrev":                 l.Status.Abbrev,
+				"status_description":            l.Status.Description,
+				"rocket_name":                   rocketConfigName,
+				"rocket_full_name":              rocketConfigFullName,
+				"rocket_total_launches":         rocketTotalLaunches,
+				"rocket_successful_launches":    rocketSuccessfulLaunches,
+				"rocket_failed_launches":        rocketFailedLaunches,
+				"rocket_pending_launches":       rocketPendingLaunches,
+				"launcher_serial_number":        launcherSerialNumber,
+				"launcher_flight_number":        launcherFlightNumber,
+				"launcher_reused":               launcherReused,
+				"launcher_flights":              launcherFlights,
+				"launcher_status":               launcherStatus,
+				"landing_attempt":               landingAttempt,
+				"landing_success":               landingSuccess,
+				"landing_location":              landingLocation,
+				"landing_type":                  landingType,
+				"landing_zone":                  landingZonePBID,
+				"programs":                      programPBIDs,
+				"crew_members":                  crewMembers,
+				"orbital_launch_attempt_count":  l.OrbitalLaunchAttemptCount,
+				"location_launch_attempt_count": l.LocationLaunchAttemptCount,
+				"pad_launch_attempt_count":      l.PadLaunchAttemptCount,
+				"agency_launch_attempt_count":   l.AgencyLaunchAttemptCount,
+			})
+			if err != nil {
+				log.Printf("❌ Failed to insert event %s: %v", l.Name, err)
+			} else {
+				log.Printf("✅ Synced event: %s", l.Name)
+				if eventID, _ := (*created)["id"].(string); eventID != "" {
+					syncLaunchUpdates(client, eventID, l.Updates)
+				}
+			}
+		} else {
+			eventID := (*eventRecord)["id"].(string)
+			// NET and status move as a launch approaches; notifications key off them
+			launchTime, _ := time.Parse(time.RFC3339, l.Net)
+			windowStart, _ := time.Parse(time.RFC3339, l.WindowStart)
+			windowEnd, _ := time.Parse(time.RFC3339, l.WindowEnd)
+			if err := client.UpdateRecord("events", eventID, map[string]interface{}{
+				"spacedevs_id":       l.ID,
+				"datetime":           launchTime.Format(time.RFC3339),
+				"window_start":       windowStart.Format(time.RFC3339),
+				"window_end":         windowEnd.Format(time.RFC3339),
+				"status_abbrev":      l.Status.Abbrev,
+				"status_description": l.Status.Description,
+				"programs":           programPBIDs,
+				"vid_urls":           eventVideos(l.VideoURLs),
+				"webcast_live":       l.WebcastLive,
+			}); err != nil {
+				log.Printf("❌ Failed to refresh event %s: %v", l.Name, err)
+			} else {
+				publishLaunchChanges(client, *eventRecord, LaunchSchedule{
+					Net:         launchTime,
+					WindowStart: windowStart,
+					WindowEnd:   windowEnd,
+					Status:      l.Status.Abbrev,
+				})
+			}
+			syncLaunchUpdates(client, eventID, l.Updates)
+			log.Printf("⏭️ Event %s already exists", l.Name)
+		}
+	}
 
-					// Extract launcher stage details (first stage info)
-					launcherSerialNumber := ""
-					launcherFlightNumber := 0
-					launcherReused := false
-					launcherFlights := 0
-					launcherStatus := ""
-					landingAttempt := false
-					landingSuccess := false
-					landingLocation := ""
-					landingType := ""
-					if len(l.Rocket.LauncherStage) > 0 {
-						stage := l.Rocket.LauncherStage[0]
-						launcherSerialNumber = stage.Launcher.SerialNumber
-						launcherStatus = stage.Launcher.Status
-						if stage.LauncherFlightNumber != nil {
-							launcherFlightNumber = *stage.LauncherFlightNumber
-						}
-						if stage.Reused != nil {
-							launcherReused = *stage.Reused
-						}
-						if stage.Launcher.Flights != nil {
-							launcherFlights = *stage.Launcher.Flights
-						}
-						if stage.Landing != nil {
-							landingAttempt = stage.Landing.Attempt
-							if stage.Landing.Success != nil {
-								landingSuccess = *stage.Landing.Success
-							}
-							landingLocation = stage.Landing.Location.Name
-							landingType = stage.Landing.Type.Name
-						}
-					}
+	if len(result.Results) > 0 {
+		log.Println("✅ Launches and related data synced for offset", offset)
+	}
+	return len(result.Results), nil
+}
 
-					// Extract program information
-					var programNames []string
-					var programDescriptions []string
-					var programImageURLs []string
-					for _, prog := range l.Program {
-						programNames = append(programNames, prog.Name)
-						programDescriptions = append(programDescriptions, prog.Description)
-						programImageURLs = append(programImageURLs, prog.ImageURL)
-					}
+// findLaunchEvent returns the event stored for a launch. Events created before
+// spacedevs_id was written are matched by title, as long as they don't already
+// belong to another launch; the refresh then backfills the ID.
+func findLaunchEvent(client *pbclient.Client, l Launch) (*map[string]interface{}, error) {
+	if record, err := client.FindRecordByField("events", "spacedevs_id", l.ID); err != nil || record != nil {
+		return record, err
+	}
 
-					// Extract crew information if spacecraft stage exists
-					var crewMembers []map[string]interface{}
-					if l.Rocket.SpacecraftStage != nil {
-						for _, crew := range l.Rocket.SpacecraftStage.LaunchCrew {
-							crewMembers = append(crewMembers, map[string]interface{}{
-								"astronaut_id":  crew.Astronaut.ID,
-								"name":          crew.Astronaut.Name,
-								"role":          crew.Role.Role,
-								"role_priority": crew.Role.Priority,
-								"nationality":   crew.Astronaut.Nationality,
-								"agency":        crew.Astronaut.Agency.Name,
-								"profile_image": crew.Astronaut.ProfileImageURL,
-							})
-						}
-					}
+	title := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(l.Name)), `"`, `\"`)
+	records, err := client.QueryRecords("events", fmt.Sprintf(`LOWER(TRIM(title))="%s" && spacedevs_id=""`, title), "", "", 1)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return &records[0], nil
+}
 
-					_, err := client.CreateRecord("events", map[string]interface{}{
-						"title":                         l.Name,
-						"type":                          "rocket_launch",
-						"datetime":                      launchTime.Format(time.RFC3339),
-						"window_start":                  windowStart.Format(time.RFC3339),
-						"window_end":                    windowEnd.Format(time.RFC3339),
-						"location":                      l.Pad.Name,
-						"source_url":                    l.URL,
-						"description":                   "Synced from Launch Library",
-						"spacedevs_id":                  providerPBID,
-						"provider":                      providerPBID,
-						"rocket_id":                     rocketPBID,
-						"pad_id":                        padPBID,
-						"mission_id":                    missionPBID,
-						"updates":                       pbUpdates,
-						"vid_urls":                      pbVidURLs,
-						"info_urls":                     pbInfoURLs,
-						"timeline":                      pbTimeline,
-						"image":                         l.Image,
-						"infographic":                   l.Infographic,
-						"webcast_live":                  l.WebcastLive,
-						"status_abbrev":                 l.Status.Abbrev,
-						"status_description":            l.Status.Description,
-						"rocket_name":                   rocketConfigName,
-						"rocket_full_name":              rocketConfigFullName,
-						"rocket_total_launches":         rocketTotalLaunches,
-						"rocket_successful_launches":    rocketSuccessfulLaunches,
-						"rocket_failed_launches":        rocketFailedLaunches,
-						"rocket_pending_launches":       rocketPendingLaunches,
-						"launcher_serial_number":        launcherSerialNumber,
-						"launcher_flight_number":        launcherFlightNumber,
-						"launcher_reused":               launcherReused,
-						"launcher_flights":              launcherFlights,
-						"launcher_status":               launcherStatus,
-						"landing_attempt":               landingAttempt,
-						"landing_success":               landingSuccess,
-						"landing_location":              landingLocation,
-						"landing_type":                  landingType,
-						"program_names":                 strings.Join(programNames, ", "),
-						"program_descriptions":          strings.Join(programDescriptions, " | "),
-						"program_image_urls":            strings.Join(programImageURLs, ", "),
-						"crew_members":                  crewMembers,
-						"orbital_launch_attempt_count":  l.OrbitalLaunchAttemptCount,
-						"location_launch_attempt_count": l.LocationLaunchAttemptCount,
-						"pad_launch_attempt_count":      l.PadLaunchAttemptCount,
-						"agency_launch_attempt_count":   l.AgencyLaunchAttemptCount,
-					})
-					if err != nil {
-						log.Printf("❌ Failed to insert event %s: %v", l.Name, err)
-					} else {
-						log.Printf("✅ Synced event: %s", l.Name)
-					}
-				} else {
-					log.Printf("⏭️ Event %s already exists", l.Name)
-				}
+func SyncLaunchProvidersAndEvents(client *pbclient.Client) {
+	go func() {
+		offset := 0
+		fetchCount := 0
+		for {
+			count, err := SyncLaunchesOnce(client, offset)
+			if err != nil {
+				delay := RetryAfter(err)
+				log.Printf("❌ Launches: %v. Retrying in %v...", err, delay)
+				time.Sleep(delay)
+				continue
+			}
+			if count == 0 {
+				log.Printf("⏭️ No more launches found at offset %d. Resetting to offset 0.", offset)
+				offset = 0
+				fetchCount = 0
+				time.Sleep(30 * time.Minute)
+				continue
 			}
 
-			log.Println("✅ Launches and related data synced for offset", offset)
 			offset += 50
 			fetchCount++
 			// Wait 1 minute after first fetch, then 30 minutes for subsequent fetches
@@ -710,3 +749,85 @@ func SyncLaunchProvidersAndEvents(client *pbclient.Client) {
 		}
 	}()
 }
+
+// syncLaunchPrograms resolves the programs embedded in a launch to program records by
+// SpaceDevs ID, creating a minimal record for any program SyncPrograms hasn't stored yet
+func syncLaunchPrograms(client *pbclient.Client, programs []Program) []string {
+	ids := []string{}
+	for _, prog := range programs {
+		if prog.ID == 0 {
+			continue
+		}
+
+		record, _ := client.FindRecordByField("programs", "api_id", prog.ID)
+		if record != nil {
+			ids = append(ids, (*record)["id"].(string))
+			continue
+		}
+
+		data := map[string]interface{}{
+			"api_id":      prog.ID,
+			"name":        prog.Name,
+			"type":        prog.Type.Name,
+			"description": prog.Description,
+			"start_date":  prog.StartDate,
+			"wiki_url":    prog.WikiURL,
+			"image_url":   prog.ImageURL,
+			"api_url":     prog.URL,
+		}
+		if prog.EndDate != "" {
+			data["end_date"] = prog.EndDate
+		}
+		if prog.InfoURL != "" {
+			data["info_url"] = prog.InfoURL
+		}
+
+		created, err := client.CreateRecord("programs", data)
+		if err != nil {
+			log.Printf("❌ Failed to insert program %s: %v", prog.Name, err)
+			continue
+		}
+		if id, ok := (*created)["id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// syncLaunchAgency resolves a launch's embedded agency to the canonical agencies record by
+// SpaceDevs ID. Missing agencies are created from the launch data; SyncAgencies enriches
+// them later. Launch service providers are flagged so the app can list them.
+func syncLaunchAgency(client *pbclient.Client, a Agency, launchProvider bool) string {
+	record, _ := client.FindRecordByField("agencies", "api_id", a.ID)
+	if record != nil {
+		id := (*record)["id"].(string)
+		if isProvider, _ := (*record)["launch_provider"].(bool); launchProvider && !isProvider {
+			if err := client.UpdateRecord("agencies", id, map[string]interface{}{"launch_provider": true}); err != nil {
+				log.Printf("❌ Failed to flag launch provider %s: %v", a.Name, err)
+			}
+		}
+		return id
+	}
+
+	foundingYear, _ := strconv.Atoi(a.Founded)
+	created, err := client.CreateRecord("agencies", map[string]interface{}{
+		"api_id":          a.ID,
+		"name":            a.Name,
+		"abbrev":          a.Abbrev,
+		"type_name":       a.Type,
+		"country_code":    a.Country,
+		"description":     a.Desc,
+		"founding_year":   foundingYear,
+		"logo_url":        a.LogoURL,
+		"image_url":       a.ImageURL,
+		"wiki_url":        a.WikiURL,
+		"info_url":        a.InfoURL,
+		"launch_provider": launchProvider,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to insert agency %s: %v", a.Name, err)
+		return ""
+	}
+	id, _ := (*created)["id"].(string)
+	return id
+}
@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+func TestSyncLaunchesBackfillsSpaceDevsID(t *testing.T) {
+	fake := replay(t)
+	client := pbclient.NewClient(pocketbaseURL)
+
+	// A launch stored before spacedevs_id was written, and another launch that
+	// happens to share its name
+	legacyID, err := createPBRecord("events", map[string]any{"title": "Falcon 9 Block 5 | Crew-10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherID, err := createPBRecord("events", map[string]any{
+		"title":         "Falcon 9 Block 5 | Crew-10",
+		"spacedevs_id":  "another-launch",
+		"status_abbrev": "Success",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if _, err := SyncLaunchesOnce(client, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := len(fake.Records("events")); n != 2 {
+		t.Fatalf("stored %d events, want the two existing ones", n)
+	}
+	for _, record := range fake.Records("events") {
+		switch record["id"] {
+		case legacyID:
+			expectFields(t, record, map[string]any{
+				"spacedevs_id":  "f5d0e6f4-2a3b-4b8c-9d1e-0a1b2c3d4e5f",
+				"status_abbrev": "Go",
+			})
+		case otherID:
+			expectFields(t, record, map[string]any{
+				"spacedevs_id":  "another-launch",
+				"status_abbrev": "Success",
+			})
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+)
+
+// MissionPatch is a mission patch as embedded in expeditions and programs
+type MissionPatch struct {
+	ID       int        `json:"id"`
+	Name     string     `json:"name"`
+	Priority int        `json:"priority"`
+	ImageURL string     `json:"image_url"`
+	Agency   *AgencyRef `json:"agency"`
+}
+
+// upsertMissionPatches stores each patch keyed by api_id and returns their PocketBase IDs
+// in the order given. Patches that fail to store are logged and left out.
+func upsertMissionPatches(patches []MissionPatch) []string {
+	ids := []string{}
+	for _, patch := range patches {
+		id, err := upsertMissionPatch(patch)
+		if err != nil {
+			log.Printf("❌ Failed to store mission patch %s: %v", patch.Name, err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func upsertMissionPatch(patch MissionPatch) (string, error) {
+	payload := map[string]any{
+		"api_id":    patch.ID,
+		"name":      patch.Name,
+		"priority":  patch.Priority,
+		"image_url": patch.ImageURL,
+	}
+
+	if patch.Agency != nil {
+		payload["agency_name"] = patch.Agency.Name
+		payload["agency_abbrev"] = patch.Agency.Abbrev
+
+		agencyID, err := ensureAgency(*patch.Agency)
+		if err != nil {
+			return "", fmt.Errorf("agency lookup: %w", err)
+		}
+		if agencyID != "" {
+			payload["agency"] = agencyID
+		}
+	}
+
+	id, _, err := upsertPBRecordByAPIID("mission_patches", patch.ID, payload)
+	return id, err
+}
@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+)
+
+// SpaceDevsPayloadFlight is one payload carried on one launch
+type SpaceDevsPayloadFlight struct {
+	ID               int               `json:"id"`
+	URL              string            `json:"url"`
+	Destination      string            `json:"destination"`
+	Amount           *int              `json:"amount"`
+	DeploymentStatus FlexibleNameField `json:"deployment_status"`
+	Payload          *SpaceDevsPayload `json:"payload"`
+	Launch           *LaunchReference  `json:"launch"`
+}
+
+type SpaceDevsPayloadFlightResponse struct {
+	Results []SpaceDevsPayloadFlight `json:"results"`
+	Next    string                   `json:"next"`
+}
+
+// SyncPayloadFlights links payloads to the launches that carried them. Each page of
+// payload flights is upserted by api_id as it arrives, and every launch event it touches
+// gets a payload_flights relation listing its full rideshare manifest.
+func SyncPayloadFlights() error {
+	fmt.Println("📦 Syncing payload flights...")
+
+	pageURL := spaceDevsAPI + "/payload_flights/?limit=100&mode=detailed"
+	count, manifests := 0, 0
+
+	for pageURL != "" {
+		var data SpaceDevsPayloadFlightResponse
+		if err := getSpaceDevsJSON(pageURL, &data); err != nil {
+			return fmt.Errorf("failed to fetch payload flights after %d: %w", count, err)
+		}
+
+		events := make(map[string]bool)
+		for _, flight := range data.Results {
+			_, eventID, err := upsertPayloadFlight(flight)
+			if err != nil {
+				log.Printf("❌ Failed to sync payload flight %d: %v", flight.ID, err)
+				continue
+			}
+			if eventID != "" {
+				events[eventID] = true
+			}
+			count++
+		}
+
+		for eventID := range events {
+			if err := storePayloadManifest(eventID); err != nil {
+				log.Printf("❌ Failed to store manifest for event %s: %v", eventID, err)
+				continue
+			}
+			manifests++
+		}
+
+		pageURL = data.Next
+	}
+
+	fmt.Printf("✅ Synced %d payload flights, updating %d launch manifests.\n", count, manifests)
+	return nil
+}
+
+// storePayloadManifest relates an event to every payload flight stored for its launch,
+// so a manifest split across pages is written whole
+func storePayloadManifest(eventID string) error {
+	records, err := listPBRecords("payload_flights", fmt.Sprintf(`launch_event="%s"`, eventID))
+	if err != nil {
+		return err
+	}
+	flightIDs := make([]string, 0, len(records))
+	for _, record := range records {
+		if id, _ := record["id"].(string); id != "" {
+			flightIDs = append(flightIDs, id)
+		}
+	}
+	return updatePBRecord("events", eventID, map[string]any{"payload_flights": flightIDs})
+}
+
+// upsertPayloadFlight stores a payload flight and returns its record ID and the ID of the
+// launch event it belongs to, if that launch has been synced
+func upsertPayloadFlight(flight SpaceDevsPayloadFlight) (string, string, error) {
+	payload := map[string]any{
+		"api_id":            flight.ID,
+		"url":               flight.URL,
+		"destination":       flight.Destination,
+		"deployment_status": flight.DeploymentStatus.Name,
+	}
+	if flight.Amount != nil {
+		payload["amount"] = *flight.Amount
+	}
+
+	if flight.Payload != nil {
+		payloadID, err := findPBRecordID("payloads", fmt.Sprintf("api_id=%d", flight.Payload.ID))
+		if err != nil {
+			return "", "", fmt.Errorf("lookup payload: %w", err)
+		}
+		if payloadID == "" {
+			payloadID, _, err = upsertPayloadInPocketbase(*flight.Payload)
+			if err != nil {
+				return "", "", fmt.Errorf("store payload %s: %w", flight.Payload.Name, err)
+			}
+		}
+		payload["payload"] = payloadID
+		payload["payload_name"] = flight.Payload.Name
+	}
+
+	eventID := ""
+	if flight.Launch != nil {
+		payload["launch_api_id"] = flight.Launch.ID
+		payload["launch_name"] = flight.Launch.Name
+
+		var err error
+		eventID, err = findPBRecordID("events", fmt.Sprintf(`spacedevs_id="%s"`, flight.Launch.ID))
+		if err != nil {
+			return "", "", fmt.Errorf("lookup launch event: %w", err)
+		}
+		if eventID != "" {
+			payload["launch_event"] = eventID
+		}
+	}
+
+	id, _, err := upsertPBRecordByAPIID("payload_flights", flight.ID, payload)
+	return id, eventID, err
+}
@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/signal-k/notifs/internal/fixtures"
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+// spaceDevsPages serves SpaceDevs responses by URL and passes PocketBase requests to
+// the fake. URLs it doesn't know get a 503.
+type spaceDevsPages struct {
+	pages map[string]string
+	pb    http.RoundTripper
+}
+
+func (s spaceDevsPages) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "ll.thespacedevs.com" {
+		return s.pb.RoundTrip(req)
+	}
+	body, ok := s.pages[req.URL.String()]
+	status := http.StatusOK
+	if !ok {
+		status, body = http.StatusServiceUnavailable, `{"detail":"unavailable"}`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestSyncPayloadFlightsStoresEachPage(t *testing.T) {
+	fake := pbfake.New()
+	first := spaceDevsAPI + "/payload_flights/?limit=100&mode=detailed"
+	second := first + "&offset=100"
+	orig := http.DefaultTransport
+	http.DefaultTransport = spaceDevsPages{
+		pages: map[string]string{
+			first:  `{"next":"` + second + `","results":[{"id":1,"launch":{"id":"rideshare","name":"Transporter-12"}}]}`,
+			second: `{"next":"` + first + `&offset=200","results":[{"id":2,"launch":{"id":"rideshare","name":"Transporter-12"}}]}`,
+		},
+		pb: &fixtures.Transport{Mode: fixtures.Replay, Dir: "testdata/fixtures", PocketBase: fake},
+	}
+	t.Cleanup(func() { http.DefaultTransport = orig })
+
+	eventID, err := createPBRecord("events", map[string]any{"title": "Transporter-12", "spacedevs_id": "rideshare"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The third page fails; the two before it are already stored
+	if err := SyncPayloadFlights(); err == nil {
+		t.Error("a failed page didn't fail the sync")
+	}
+	if n := len(fake.Records("payload_flights")); n != 2 {
+		t.Fatalf("stored %d payload flights, want 2", n)
+	}
+	manifest, _ := only(t, fake, "events")["payload_flights"].([]any)
+	if len(manifest) != 2 {
+		t.Errorf("manifest of %s = %v, want both flights across the pages", eventID, manifest)
+	}
+}
@@ -1,7 +1,6 @@
 package sync
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -132,12 +131,17 @@ func SyncPayloads() error {
 		}
 
 		for _, payload := range data.Results {
-			if err := createPayloadInPocketbase(payload); err != nil {
-				log.Printf("❌ Failed to insert payload '%s': %v", payload.Name, err)
-			} else {
+			_, created, err := upsertPayloadInPocketbase(payload)
+			if err != nil {
+				log.Printf("❌ Failed to sync payload '%s': %v", payload.Name, err)
+				continue
+			}
+			if created {
 				log.Printf("✅ Inserted payload: %s", payload.Name)
-				count++
+			} else {
+				log.Printf("🔁 Updated payload: %s", payload.Name)
 			}
+			count++
 		}
 
 		pageURL = data.Next
@@ -147,9 +151,9 @@ func SyncPayloads() error {
 	return nil
 }
 
-func createPayloadInPocketbase(payload SpaceDevsPayload) error {
-	pbURL := "http://127.0.0.1:8080/api/collections/payloads/records"
-
+// upsertPayloadInPocketbase stores a payload keyed by api_id and returns its record ID
+// and whether it was newly created
+func upsertPayloadInPocketbase(payload SpaceDevsPayload) (string, bool, error) {
 	nationality := ""
 	if len(payload.Nationalities) > 0 {
 		nationality = payload.Nationalities[0].Name
@@ -241,28 +245,33 @@ func createPayloadInPocketbase(payload SpaceDevsPayload) error {
 		"cost":                     cost,
 	}
 
-	body, err := json.Marshal(payloadBody)
+	manufacturerID, err := ensureAgency(AgencyRef{ID: payload.Manufacturer.ID, Name: payload.Manufacturer.Name, Abbrev: payload.Manufacturer.Abbrev})
 	if err != nil {
-		return err
+		return "", false, fmt.Errorf("manufacturer lookup: %w", err)
 	}
-
-	req, err := http.NewRequest("POST", pbURL, bytes.NewBuffer(body))
-	if err != nil {
-		return err
+	if manufacturerID != "" {
+		payloadBody["manufacturer"] = manufacturerID
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	res, err := client.Do(req)
+	operatorID, err := ensureAgency(AgencyRef{ID: payload.Operator.ID, Name: payload.Operator.Name, Abbrev: payload.Operator.Abbrev})
 	if err != nil {
-		return err
+		return "", false, fmt.Errorf("operator lookup: %w", err)
+	}
+	if operatorID != "" {
+		payloadBody["operator"] = operatorID
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("PocketBase HTTP %d: %s", res.StatusCode, string(body))
+	programIDs := []string{}
+	for _, prog := range payload.Program {
+		id, err := findPBRecordID("programs", fmt.Sprintf("api_id=%d", prog.ID))
+		if err != nil {
+			return "", false, fmt.Errorf("program lookup: %w", err)
+		}
+		if id != "" {
+			programIDs = appendUnique(programIDs, id)
+		}
 	}
+	payloadBody["programs"] = programIDs
 
-	return nil
+	return upsertPBRecordByAPIID("payloads", payload.ID, payloadBody)
 }
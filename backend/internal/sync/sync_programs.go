@@ -1,66 +1,39 @@
 package sync
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"time"
 )
 
-type SpaceDevsProgram struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Type        struct {
-		Name string `json:"name"`
-	} `json:"type"`
-	StartDate string `json:"start_date"`
-	EndDate   string `json:"end_date"`
-	InfoURL   string `json:"info_url"`
-	WikiURL   string `json:"wiki_url"`
-	URL       string `json:"url"`
-	Image     struct {
-		ImageURL     string `json:"image_url"`
-		ThumbnailURL string `json:"thumbnail_url"`
-	} `json:"image"`
-}
-
 type SpaceDevsResponseProgram struct {
-	Results []SpaceDevsProgram `json:"results"`
+	Results []Program `json:"results"`
+	Next    string    `json:"next"`
 }
 
 func SyncPrograms() error {
 	fmt.Println("🚀 Fetching latest space programs...")
 
-	apiURL := "https://ll.thespacedevs.com/2.2.0/program/?limit=3000&ordering=-start_date&mode=normal"
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch programs: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
+	pageURL := spaceDevsAPI + "/programs/?limit=100&ordering=-start_date&mode=detailed"
 
-	var data SpaceDevsResponseProgram
-	if err := json.Unmarshal(body, &data); err != nil {
-		return fmt.Errorf("failed to parse JSON: %w", err)
+	var programs []Program
+	for pageURL != "" {
+		var data SpaceDevsResponseProgram
+		if err := getSpaceDevsJSON(pageURL, &data); err != nil {
+			return fmt.Errorf("failed to fetch programs: %w", err)
+		}
+		programs = append(programs, data.Results...)
+		pageURL = data.Next
 	}
 
-	fmt.Printf("✅ Successfully fetched %d programs\n", len(data.Results))
+	fmt.Printf("✅ Successfully fetched %d programs\n", len(programs))
 
 	var errors []error
-	for _, prog := range data.Results {
-		if err := createProgramInPocketbase(prog); err != nil {
-			log.Printf("❌ Error inserting %s: %v", prog.Name, err)
-			errors = append(errors, fmt.Errorf("failed to insert %s: %w", prog.Name, err))
+	for _, prog := range programs {
+		if _, err := upsertProgramInPocketbase(prog); err != nil {
+			log.Printf("❌ Error syncing %s: %v", prog.Name, err)
+			errors = append(errors, fmt.Errorf("failed to sync %s: %w", prog.Name, err))
 		} else {
-			log.Printf("✅ Inserted program: %s", prog.Name)
+			log.Printf("✅ Synced program: %s", prog.Name)
 		}
 	}
 
@@ -71,8 +44,19 @@ func SyncPrograms() error {
 	return nil
 }
 
-func createProgramInPocketbase(prog SpaceDevsProgram) error {
-	pbURL := "http://127.0.0.1:8080/api/collections/programs/records"
+// upsertProgramInPocketbase stores a program keyed by api_id together with its agencies
+// and mission patches, and returns the program record ID
+func upsertProgramInPocketbase(prog Program) (string, error) {
+	agencyIDs := []string{}
+	for _, agency := range prog.Agencies {
+		id, err := ensureAgency(agency.Ref())
+		if err != nil {
+			return "", fmt.Errorf("agency lookup: %w", err)
+		}
+		if id != "" {
+			agencyIDs = appendUnique(agencyIDs, id)
+		}
+	}
 
 	payload := map[string]any{
 		"api_id":          prog.ID,
@@ -83,33 +67,13 @@ func createProgramInPocketbase(prog SpaceDevsProgram) error {
 		"end_date":        prog.EndDate,
 		"info_url":        prog.InfoURL,
 		"wiki_url":        prog.WikiURL,
-		"image_url":       prog.Image.ImageURL,
-		"image_thumb_url": prog.Image.ThumbnailURL,
+		"image_url":       prog.ImageURL,
+		"image_thumb_url": prog.ThumbnailURL,
 		"api_url":         prog.URL,
+		"agencies":        agencyIDs,
+		"mission_patches": upsertMissionPatches(prog.MissionPatches),
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("POST", pbURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	res, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode >= 400 {
-		body, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("HTTP %d: %s", res.StatusCode, body)
-	}
-
-	return nil
+	id, _, err := upsertPBRecordByAPIID("programs", prog.ID, payload)
+	return id, err
 }
@@ -1,14 +1,11 @@
 package sync
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"time"
+
+	"github.com/signal-k/notifs/internal/dryrun"
 )
 
 // SpaceDevsSpacewalk defines the structure of a spacewalk from SpaceDevs API
@@ -22,11 +19,26 @@ type SpaceDevsSpacewalk struct {
 	EndTime   string `json:"end"`
 	Duration  string `json:"duration"`
 
+	Crew []FlightCrewMember `json:"crew"`
+
 	Expedition *struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"expedition"`
 
+	Spacestation *struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"spacestation"`
+
+	SpacecraftFlight *struct {
+		ID         int `json:"id"`
+		Spacecraft struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"spacecraft"`
+	} `json:"spacecraft_flight"`
+
 	Event *struct {
 		ID int `json:"id"`
 	} `json:"event"`
@@ -38,65 +50,49 @@ type SpaceDevsSpacewalkResponse struct {
 	Results []SpaceDevsSpacewalk `json:"results"`
 }
 
-// SyncSpacewalks fetches and syncs all spacewalks from TSD into Pocketbase
+// SyncSpacewalks fetches and syncs all spacewalks from TSD into Pocketbase.
+// Spacewalks whose expedition or event isn't in Pocketbase yet are still stored and
+// marked pending, and their relations are retried at the start of every run.
 func SyncSpacewalks() error {
 	fmt.Println("🚀 Syncing spacewalks from TSD...")
 
-	url := "https://ll.thespacedevs.com/2.3.0/spacewalks/?limit=5000&format=json&ordering=-start"
-	res, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to fetch spacewalks: %w", err)
-	}
-	defer res.Body.Close()
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read spacewalk response: %w", err)
+	if resolved, err := retryPendingSpacewalks(); err != nil {
+		log.Printf("⚠️ Could not retry pending spacewalks: %v", err)
+	} else if resolved > 0 {
+		log.Printf("🔗 Resolved relations for %d previously pending spacewalks", resolved)
 	}
 
+	url := spaceDevsAPI + "/spacewalks/?limit=5000&format=json&ordering=-start&mode=detailed"
 	var data SpaceDevsSpacewalkResponse
-	if err := json.Unmarshal(body, &data); err != nil {
-		return fmt.Errorf("failed to unmarshal spacewalk JSON: %w", err)
+	if err := getSpaceDevsJSON(url, &data); err != nil {
+		return fmt.Errorf("failed to fetch spacewalks: %w", err)
 	}
 
 	success := 0
-	skipped := 0
+	pending := 0
 
 	for _, sw := range data.Results {
-		if err := createSpacewalkInPocketbase(sw); err != nil {
-			if err.Error() == "skip" {
-				skipped++
-				continue
-			}
-			log.Printf("❌ Failed to insert spacewalk: %s — %v", sw.Name, err)
+		isPending, err := upsertSpacewalkInPocketbase(sw)
+		if err != nil {
+			log.Printf("❌ Failed to sync spacewalk: %s — %v", sw.Name, err)
+			continue
+		}
+		success++
+		if isPending {
+			pending++
+			log.Printf("⏳ Synced spacewalk %s with pending relations", sw.Name)
 		} else {
-			success++
-			log.Printf("✅ Inserted spacewalk: %s", sw.Name)
+			log.Printf("✅ Synced spacewalk: %s", sw.Name)
 		}
 	}
 
-	fmt.Printf("✅ Done syncing spacewalks: %d inserted, %d skipped\n", success, skipped)
+	fmt.Printf("✅ Done syncing spacewalks: %d synced, %d waiting on parent records\n", success, pending)
 	return nil
 }
 
-// createSpacewalkInPocketbase inserts a spacewalk into Pocketbase
-func createSpacewalkInPocketbase(sw SpaceDevsSpacewalk) error {
-	pbURL := "http://127.0.0.1:8080/api/collections/spacewalks/records"
-
-	// Resolve expedition Pocketbase ID
-	var expeditionPBID string
-	if sw.Expedition != nil {
-		id, err := getPocketbaseExpeditionID(sw.Expedition.ID)
-		if err != nil {
-			return fmt.Errorf("lookup expedition: %w", err)
-		}
-		if id == "" {
-			log.Printf("⚠️ Skipping spacewalk %q: expedition %d not found in PB", sw.Name, sw.Expedition.ID)
-			return fmt.Errorf("skip")
-		}
-		expeditionPBID = id
-	}
-
+// upsertSpacewalkInPocketbase stores a spacewalk keyed by api_id and reports whether
+// any parent relation (expedition, event) could not be resolved yet
+func upsertSpacewalkInPocketbase(sw SpaceDevsSpacewalk) (bool, error) {
 	payload := map[string]interface{}{
 		"api_id":     sw.ID,
 		"name":       sw.Name,
@@ -106,75 +102,154 @@ func createSpacewalkInPocketbase(sw SpaceDevsSpacewalk) error {
 		"start_time": sw.StartTime,
 		"end_time":   sw.EndTime,
 		"duration":   sw.Duration,
-		"event_id":   sw.Event.ID,
 	}
 
-	// Only include expedition_name if Expedition is not nil
+	if seconds, ok := spacewalkDurationSeconds(sw); ok {
+		payload["duration_seconds"] = seconds
+	}
+
 	if sw.Expedition != nil {
 		payload["expedition_name"] = sw.Expedition.Name
+		payload["expedition_api_id"] = sw.Expedition.ID
+	}
+	if sw.Event != nil {
+		payload["event_id"] = sw.Event.ID
 	}
 
-	if expeditionPBID != "" {
-		payload["expedition"] = expeditionPBID
+	// Where the EVA happened: a station, or a free-flying spacecraft
+	if sw.Spacestation != nil {
+		stationID, err := findPBRecordID("stations", fmt.Sprintf("api_id=%d", sw.Spacestation.ID))
+		if err != nil {
+			return false, fmt.Errorf("lookup station: %w", err)
+		}
+		if stationID != "" {
+			payload["station"] = stationID
+		}
+	}
+	if sw.SpacecraftFlight != nil {
+		payload["spacecraft_flight_api_id"] = sw.SpacecraftFlight.ID
+		payload["spacecraft_name"] = sw.SpacecraftFlight.Spacecraft.Name
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("marshal error: %w", err)
+	crewIDs := []string{}
+	for _, member := range sw.Crew {
+		id, err := resolveAstronautID(member.Astronaut.ID, member.Astronaut.Name)
+		if err != nil {
+			return false, fmt.Errorf("lookup astronaut %s: %w", member.Astronaut.Name, err)
+		}
+		if id == "" {
+			log.Printf("⚠️ Astronaut %s not synced yet for spacewalk %s", member.Astronaut.Name, sw.Name)
+			dryrun.NoteUnresolved("spacewalks", "crew", "astronaut "+member.Astronaut.Name, "not synced yet")
+			continue
+		}
+		crewIDs = appendUnique(crewIDs, id)
 	}
+	payload["crew"] = crewIDs
 
-	req, err := http.NewRequest("POST", pbURL, bytes.NewBuffer(jsonData))
+	pending, err := resolveSpacewalkParents(payload, expeditionAPIID(sw), eventAPIID(sw))
 	if err != nil {
-		return fmt.Errorf("request error: %w", err)
+		return false, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("http error: %w", err)
+	_, _, err = upsertPBRecordByAPIID("spacewalks", sw.ID, payload)
+	return pending, err
+}
+
+// resolveSpacewalkParents fills the expedition and event relations on payload when
+// those records exist, and sets pending_relations for the ones that don't
+func resolveSpacewalkParents(payload map[string]interface{}, expeditionAPIID, eventAPIID int) (bool, error) {
+	pending := false
+
+	if expeditionAPIID != 0 {
+		id, err := findPBRecordID("expeditions", fmt.Sprintf("api_id=%d", expeditionAPIID))
+		if err != nil {
+			return false, fmt.Errorf("lookup expedition: %w", err)
+		}
+		if id != "" {
+			payload["expedition"] = id
+		} else {
+			pending = true
+			dryrun.NoteUnresolved("spacewalks", "expedition", fmt.Sprintf("expedition api_id=%d", expeditionAPIID), "not synced yet")
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("PB HTTP %d: %s", resp.StatusCode, body)
+	if eventAPIID != 0 {
+		// Events stored before event_api_id existed are found by their SpaceDevs URL
+		id, err := findPBRecordID("events", fmt.Sprintf(`event_api_id=%d || source_url~"/events/%d/"`, eventAPIID, eventAPIID))
+		if err != nil {
+			return false, fmt.Errorf("lookup event: %w", err)
+		}
+		if id != "" {
+			payload["event"] = id
+		} else {
+			pending = true
+			dryrun.NoteUnresolved("spacewalks", "event", fmt.Sprintf("event event_api_id=%d", eventAPIID), "not synced yet")
+		}
 	}
 
-	return nil
+	payload["pending_relations"] = pending
+	return pending, nil
 }
 
-// getPocketbaseExpeditionID resolves a TSD API expedition ID to its PB record ID
-func getPocketbaseExpeditionID(apiID int) (string, error) {
-	filter := url.QueryEscape(fmt.Sprintf("api_id=%d", apiID))
-	endpoint := fmt.Sprintf("http://127.0.0.1:8080/api/collections/expeditions/records?filter=%s", filter)
-
-	res, err := http.Get(endpoint)
+// retryPendingSpacewalks re-resolves parents for spacewalks stored before their
+// expedition or event existed and returns how many are now complete. A record that
+// fails is logged and retried on the next run.
+func retryPendingSpacewalks() (int, error) {
+	records, err := listPBRecords("spacewalks", "pending_relations=true")
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return "", fmt.Errorf("lookup failed (status %d)", res.StatusCode)
+	resolved := 0
+	for _, record := range records {
+		id, _ := record["id"].(string)
+		expeditionID, _ := record["expedition_api_id"].(float64)
+		eventID, _ := record["event_id"].(float64)
+
+		payload := map[string]interface{}{}
+		pending, err := resolveSpacewalkParents(payload, int(expeditionID), int(eventID))
+		if err != nil {
+			log.Printf("❌ Failed to resolve spacewalk %s: %v", id, err)
+			continue
+		}
+		if err := updatePBRecord("spacewalks", id, payload); err != nil {
+			log.Printf("❌ Failed to update spacewalk %s: %v", id, err)
+			continue
+		}
+		if !pending {
+			resolved++
+		}
 	}
+	return resolved, nil
+}
 
-	var result struct {
-		Items []struct {
-			ID string `json:"id"`
-		} `json:"items"`
+// spacewalkDurationSeconds prefers the API's ISO 8601 duration and falls back to end minus start
+func spacewalkDurationSeconds(sw SpaceDevsSpacewalk) (int, bool) {
+	if d, err := parseISODuration(sw.Duration); err == nil {
+		return int(d / time.Second), true
 	}
 
-	body, err := io.ReadAll(res.Body)
+	start, err := parseDate(sw.StartTime)
 	if err != nil {
-		return "", err
+		return 0, false
 	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+	end, err := parseDate(sw.EndTime)
+	if err != nil || end.Before(start) {
+		return 0, false
 	}
+	return int(end.Sub(start) / time.Second), true
+}
+
+func expeditionAPIID(sw SpaceDevsSpacewalk) int {
+	if sw.Expedition == nil {
+		return 0
+	}
+	return sw.Expedition.ID
+}
 
-	if len(result.Items) == 0 {
-		return "", nil // Not found
+func eventAPIID(sw SpaceDevsSpacewalk) int {
+	if sw.Event == nil {
+		return 0
 	}
-	return result.Items[0].ID, nil
+	return sw.Event.ID
 }
@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/signal-k/notifs/internal/fixtures"
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+func TestRetryPendingSpacewalksFindsEventsByURL(t *testing.T) {
+	fake := replay(t)
+
+	// An event stored before event_api_id existed
+	eventID, err := createPBRecord("events", map[string]any{
+		"title":      "EVA 300",
+		"source_url": "https://ll.thespacedevs.com/2.3.0/events/900/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sw := range []map[string]any{
+		{"api_id": 300, "event_id": 900, "pending_relations": true},
+		{"api_id": 301, "event_id": 90, "pending_relations": true},
+	} {
+		if _, err := createPBRecord("spacewalks", sw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolved, err := retryPendingSpacewalks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != 1 {
+		t.Errorf("resolved %d spacewalks, want 1", resolved)
+	}
+	expectFields(t, byAPIID(t, fake, "spacewalks", 300), map[string]any{"event": eventID, "pending_relations": false})
+	// Event 90 isn't a prefix match for event 900
+	expectFields(t, byAPIID(t, fake, "spacewalks", 301), map[string]any{"pending_relations": true})
+}
+
+func TestSyncSpacewalksFailsOnErrorStatus(t *testing.T) {
+	fake := pbfake.New()
+	orig := http.DefaultTransport
+	http.DefaultTransport = spaceDevsPages{
+		pb: &fixtures.Transport{Mode: fixtures.Replay, Dir: "testdata/fixtures", PocketBase: fake},
+	}
+	t.Cleanup(func() { http.DefaultTransport = orig })
+
+	// A 503 body isn't an empty page of spacewalks
+	if err := SyncSpacewalks(); err == nil {
+		t.Error("a 503 from SpaceDevs didn't fail the sync")
+	}
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/signal-k/notifs/internal/dryrun"
+)
+
+// LaunchWebcasts is an upcoming launch with its classified webcasts
+type LaunchWebcasts struct {
+	ID          string
+	Name        string
+	Net         time.Time
+	URL         string
+	WebcastLive bool
+	Videos      []EventVideo
+}
+
+type spaceDevsWebcastLaunch struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Net         string   `json:"net"`
+	URL         string   `json:"url"`
+	WebcastLive bool     `json:"webcast_live"`
+	VidURLs     []VidURL `json:"vid_urls"`
+}
+
+// FetchUpcomingWebcasts returns up to limit upcoming launches with their videos from
+// the 2.3 API. A non-zero window only includes launches with a NET inside it.
+func FetchUpcomingWebcasts(window time.Duration, limit int) ([]LaunchWebcasts, error) {
+	apiURL := fmt.Sprintf("%s/launches/upcoming/?limit=%d&mode=detailed", spaceDevsAPI, limit)
+	if window > 0 {
+		apiURL += "&net__lte=" + url.QueryEscape(time.Now().Add(window).UTC().Format(time.RFC3339))
+	}
+
+	var data struct {
+		Results []spaceDevsWebcastLaunch `json:"results"`
+	}
+	if err := getSpaceDevsJSON(apiURL, &data); err != nil {
+		return nil, fmt.Errorf("failed to fetch upcoming launches: %w", err)
+	}
+
+	launches := make([]LaunchWebcasts, 0, len(data.Results))
+	for _, l := range data.Results {
+		net, _ := time.Parse(time.RFC3339, l.Net)
+		launches = append(launches, LaunchWebcasts{
+			ID:          l.ID,
+			Name:        l.Name,
+			Net:         net,
+			URL:         l.URL,
+			WebcastLive: l.WebcastLive,
+			Videos:      eventVideos(l.VidURLs),
+		})
+	}
+	return launches, nil
+}
+
+// SyncUpcomingWebcasts refreshes vid_urls and webcast_live on the launch events due
+// within window. It returns how long until the nearest of those launches, or window
+// when none is scheduled, so callers can poll faster as a launch approaches.
+func SyncUpcomingWebcasts(window time.Duration) (time.Duration, error) {
+	launches, err := FetchUpcomingWebcasts(window, 50)
+	if err != nil {
+		return window, err
+	}
+
+	now := time.Now()
+	nearest := window
+	for _, l := range launches {
+		if until := l.Net.Sub(now); !l.Net.IsZero() && until < nearest {
+			nearest = until
+		}
+
+		eventID, err := findPBRecordID("events", fmt.Sprintf(`spacedevs_id="%s"`, l.ID))
+		if err != nil {
+			log.Printf("❌ Failed to look up event for %s: %v", l.Name, err)
+			continue
+		}
+		if eventID == "" {
+			log.Printf("⏭️ Launch %s not synced yet", l.Name)
+			dryrun.NoteUnresolved("events", "vid_urls", "launch "+l.Name, "not synced yet")
+			continue
+		}
+
+		if err := updatePBRecord("events", eventID, map[string]any{
+			"vid_urls":     l.Videos,
+			"webcast_live": l.WebcastLive || anyLive(l.Videos),
+		}); err != nil {
+			log.Printf("❌ Failed to refresh webcasts for %s: %v", l.Name, err)
+			continue
+		}
+		log.Printf("📺 Refreshed %d webcasts for %s", len(l.Videos), l.Name)
+	}
+
+	return nearest, nil
+}
+
+// WebcastRefreshInterval is how long to wait before refreshing webcasts again, given
+// the time until the next launch. Streams are usually added in the final hours.
+func WebcastRefreshInterval(untilLaunch time.Duration) time.Duration {
+	switch {
+	case untilLaunch < 2*time.Hour:
+		return 5 * time.Minute
+	case untilLaunch < 24*time.Hour:
+		return 30 * time.Minute
+	default:
+		return 3 * time.Hour
+	}
+}
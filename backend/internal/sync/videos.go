@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Video platforms stored on EventVideo.Platform
+const (
+	VideoPlatformYouTube  = "youtube"
+	VideoPlatformX        = "x"
+	VideoPlatformTwitch   = "twitch"
+	VideoPlatformOfficial = "official"
+	VideoPlatformOther    = "other"
+)
+
+// officialVideoHosts are agency and provider sites that stream their own webcasts
+var officialVideoHosts = []string{
+	"nasa.gov",
+	"spacex.com",
+	"blueorigin.com",
+	"rocketlabusa.com",
+	"ulalaunch.com",
+	"esa.int",
+	"arianespace.com",
+	"jaxa.jp",
+	"isro.gov.in",
+	"firefly.com",
+}
+
+// VidURL is a webcast link as served by Launch Library, under vidURLs in 2.2 and
+// vid_urls in 2.3. Live is only populated by 2.3.
+type VidURL struct {
+	Priority     int    `json:"priority"`
+	Source       string `json:"source"`
+	Publisher    string `json:"publisher"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	FeatureImage string `json:"feature_image"`
+	URL          string `json:"url"`
+	Type         *struct {
+		Name string `json:"name"`
+	} `json:"type"`
+	Language *struct {
+		Name string `json:"name"`
+		Code string `json:"code"`
+	} `json:"language"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Live      bool   `json:"live"`
+}
+
+// EventVideo is a classified webcast as stored in events.vid_urls. It keeps the
+// title, url and priority keys the app already reads.
+type EventVideo struct {
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	Priority     int    `json:"priority"`
+	Platform     string `json:"platform"`
+	VideoID      string `json:"video_id,omitempty"`
+	Source       string `json:"source,omitempty"`
+	Publisher    string `json:"publisher,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Language     string `json:"language,omitempty"`
+	FeatureImage string `json:"feature_image,omitempty"`
+	StartTime    string `json:"start_time,omitempty"`
+	EndTime      string `json:"end_time,omitempty"`
+	Live         bool   `json:"live"`
+}
+
+// NewEventVideo classifies a Launch Library video link
+func NewEventVideo(v VidURL) EventVideo {
+	platform, videoID := ClassifyVideoURL(v.URL)
+	video := EventVideo{
+		Title:        v.Title,
+		URL:          v.URL,
+		Priority:     v.Priority,
+		Platform:     platform,
+		VideoID:      videoID,
+		Source:       v.Source,
+		Publisher:    v.Publisher,
+		FeatureImage: v.FeatureImage,
+		StartTime:    v.StartTime,
+		EndTime:      v.EndTime,
+		Live:         v.Live,
+	}
+	if v.Type != nil {
+		video.Type = v.Type.Name
+	}
+	if v.Language != nil {
+		video.Language = v.Language.Code
+	}
+	return video
+}
+
+// eventVideos classifies a launch's links, dropping duplicates and ordering by priority
+// (lower first, as Launch Library ranks them)
+func eventVideos(vids []VidURL) []EventVideo {
+	videos := []EventVideo{}
+	seen := make(map[string]bool)
+	for _, v := range vids {
+		if v.URL == "" || seen[v.URL] {
+			continue
+		}
+		seen[v.URL] = true
+		videos = append(videos, NewEventVideo(v))
+	}
+
+	sort.SliceStable(videos, func(i, j int) bool {
+		return videos[i].Priority < videos[j].Priority
+	})
+	return videos
+}
+
+// anyLive reports whether Launch Library marks any of the videos as streaming now
+func anyLive(videos []EventVideo) bool {
+	for _, v := range videos {
+		if v.Live {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyVideoURL returns the platform a video link points at and, where the
+// platform has one, the video or broadcast ID
+func ClassifyVideoURL(rawURL string) (string, string) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return VideoPlatformOther, ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+
+	switch host {
+	case "youtube.com", "youtube-nocookie.com":
+		if id := u.Query().Get("v"); id != "" {
+			return VideoPlatformYouTube, id
+		}
+		// /live/<id>, /embed/<id>, /shorts/<id>
+		if len(segments) >= 2 && (segments[0] == "live" || segments[0] == "embed" || segments[0] == "shorts") {
+			return VideoPlatformYouTube, segments[1]
+		}
+		return VideoPlatformYouTube, ""
+	case "youtu.be":
+		if len(segments) >= 1 {
+			return VideoPlatformYouTube, segments[0]
+		}
+		return VideoPlatformYouTube, ""
+	case "x.com", "twitter.com":
+		// /<user>/status/<id> and /i/broadcasts/<id>
+		for i := 0; i+1 < len(segments); i++ {
+			if segments[i] == "status" || segments[i] == "broadcasts" {
+				return VideoPlatformX, segments[i+1]
+			}
+		}
+		return VideoPlatformX, ""
+	case "twitch.tv":
+		if len(segments) >= 1 {
+			return VideoPlatformTwitch, segments[0]
+		}
+		return VideoPlatformTwitch, ""
+	}
+
+	for _, official := range officialVideoHosts {
+		if host == official || strings.HasSuffix(host, "."+official) {
+			return VideoPlatformOfficial, ""
+		}
+	}
+	return VideoPlatformOther, ""
+}
@@ -0,0 +1,202 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Context is who a template renders for: their language, their timezone and the time
+// relative times count from
+type Context struct {
+	Locale   string
+	Location *time.Location
+	Now      time.Time
+}
+
+func (c Context) withDefaults() Context {
+	if c.Locale == "" {
+		c.Locale = DefaultLocale
+	}
+	if c.Location == nil {
+		c.Location = time.UTC
+	}
+	if c.Now.IsZero() {
+		c.Now = time.Now()
+	}
+	return c
+}
+
+// Funcs returns the helpers templates can call, bound to ctx:
+//
+//	t key [name value]...  the string for key in the user's language
+//	countdown t            time left until t, e.g. "2 hours"
+//	relative t             t relative to now, e.g. "in 2 hours" or "5 minutes ago"
+//	duration d             a duration in words; d is a time.Duration or seconds
+//	local t                t in the user's timezone, e.g. "Tue 21 Oct 14:30 CEST"
+//	short t                a shorter local time, e.g. "Oct 21 14:30 CEST"
+//	day t                  the local date, e.g. "Tue 21 Oct"
+//	utc t                  the UTC time of day, e.g. "12:30 UTC"
+//	clock t                the local time of day, e.g. "18:42"
+//	compass degrees        a compass direction from an azimuth, e.g. "SW"
+//	zone                   the user's timezone name
+//	abbrev provider        a provider's abbreviation, e.g. "SpaceX" or "NASA"
+//	when value             a time.Time from a time or a stored timestamp string
+//
+// Times may be a time.Time or a timestamp string as stored in records or job details.
+func (s *Set) Funcs(ctx Context) map[string]any {
+	ctx = ctx.withDefaults()
+	t := func(key string, args ...any) string {
+		return s.Translate(ctx.Locale, key, args...)
+	}
+
+	return map[string]any{
+		"t": t,
+		"countdown": func(v any) string {
+			left := when(v).Sub(ctx.Now)
+			if left < 0 {
+				left = 0
+			}
+			return s.duration(ctx.Locale, left)
+		},
+		"relative": func(v any) string {
+			d := when(v).Sub(ctx.Now)
+			switch {
+			case d.Abs() < time.Minute:
+				return t("relative.now")
+			case d > 0:
+				return t("relative.future", "time", s.duration(ctx.Locale, d))
+			}
+			return t("relative.past", "time", s.duration(ctx.Locale, -d))
+		},
+		"duration": func(v any) string {
+			return s.duration(ctx.Locale, toDuration(v).Abs())
+		},
+		"local": func(v any) string {
+			return s.formatTime(ctx.Locale, "format.datetime", when(v).In(ctx.Location))
+		},
+		"short": func(v any) string {
+			return s.formatTime(ctx.Locale, "format.short", when(v).In(ctx.Location))
+		},
+		"day": func(v any) string {
+			return s.formatTime(ctx.Locale, "format.date", when(v).In(ctx.Location))
+		},
+		"utc": func(v any) string {
+			return when(v).UTC().Format("15:04 UTC")
+		},
+		"clock": func(v any) string {
+			return s.formatTime(ctx.Locale, "format.time", when(v).In(ctx.Location))
+		},
+		"compass": func(v any) string {
+			return s.compass(ctx.Locale, toFloat(v))
+		},
+		"zone": func() string {
+			return ctx.Location.String()
+		},
+		"abbrev": s.Abbrev,
+		"when":   when,
+	}
+}
+
+// duration rounds d to the unit that matters at its size: minutes under an hour, hours
+// under two days, days beyond
+func (s *Set) duration(locale string, d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch {
+	case d < time.Hour:
+		return s.Translate(locale, "duration.minutes", "n", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return s.Translate(locale, "duration.hours", "n", int(d.Round(time.Hour).Hours()))
+	}
+	return s.Translate(locale, "duration.days", "n", int(d.Round(24*time.Hour).Hours()/24))
+}
+
+// compass names the nearest of the bundle's eight compass points to an azimuth in
+// degrees clockwise from north
+func (s *Set) compass(locale string, azimuth float64) string {
+	points := strings.Split(s.Translate(locale, "names.compass"), ",")
+	if len(points) != 8 {
+		points = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	}
+	i := int(math.Round(math.Mod(math.Mod(azimuth, 360)+360, 360)/45)) % 8
+	return strings.TrimSpace(points[i])
+}
+
+// formatTime fills a bundle's date format. Formats use {weekday}, {day}, {month}
+// (a name from the bundle's months list), {month_number}, {year}, {hh}, {mm} and
+// {zone}, so each language orders and names the parts its own way.
+func (s *Set) formatTime(locale, format string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	weekdays := strings.Split(s.Translate(locale, "names.weekdays"), ",")
+	months := strings.Split(s.Translate(locale, "names.months"), ",")
+	weekday, month := t.Weekday().String()[:3], t.Month().String()[:3]
+	if len(weekdays) == 7 {
+		weekday = strings.TrimSpace(weekdays[t.Weekday()])
+	}
+	if len(months) == 12 {
+		month = strings.TrimSpace(months[t.Month()-1])
+	}
+
+	return strings.NewReplacer(
+		"{weekday}", weekday,
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", month,
+		"{month_number}", strconv.Itoa(int(t.Month())),
+		"{year}", strconv.Itoa(t.Year()),
+		"{hh}", fmt.Sprintf("%02d", t.Hour()),
+		"{mm}", fmt.Sprintf("%02d", t.Minute()),
+		"{zone}", t.Format("MST"),
+	).Replace(s.Translate(locale, format))
+}
+
+// when reads a time from a time.Time or a stored timestamp, returning the zero time
+// for anything else
+func when(v any) time.Time {
+	switch value := v.(type) {
+	case time.Time:
+		return value
+	case *time.Time:
+		if value != nil {
+			return *value
+		}
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.000Z", "2006-01-02 15:04:05Z"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// toFloat reads a number as stored in job details
+func toFloat(v any) float64 {
+	switch value := v.(type) {
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case float64:
+		return value
+	}
+	return 0
+}
+
+// toDuration reads a time.Duration, or seconds as stored in job details
+func toDuration(v any) time.Duration {
+	switch value := v.(type) {
+	case time.Duration:
+		return value
+	case int:
+		return time.Duration(value) * time.Second
+	case int64:
+		return time.Duration(value) * time.Second
+	case float64:
+		return time.Duration(value) * time.Second
+	}
+	return 0
+}
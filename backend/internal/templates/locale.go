@@ -0,0 +1,163 @@
+package templates
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// DefaultLocale is used for users without a language and for strings a bundle lacks
+const DefaultLocale = "en"
+
+// Bundle is one language's strings by key. Values hold {name} placeholders filled from
+// the arguments to Translate. A key with plural forms is stored as key.one and
+// key.other, chosen by the "n" argument.
+type Bundle map[string]string
+
+// loadBundles reads every <locale>.json in fsys's dir
+func loadBundles(fsys fs.FS, dir string, into map[string]Bundle) error {
+	files, err := fs.Glob(fsys, dir+"/*.json")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		bundle := Bundle{}
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return fmt.Errorf("locale %s: %w", file, err)
+		}
+
+		locale := NormalizeLocale(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if into[locale] == nil {
+			into[locale] = Bundle{}
+		}
+		// Bundles on disk override single strings of the built in ones
+		for key, value := range bundle {
+			into[locale][key] = value
+		}
+	}
+	return nil
+}
+
+// loadDirBundles adds the bundles in dir/locales, if there are any
+func loadDirBundles(dir string, into map[string]Bundle) error {
+	if dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, "locales")); err != nil {
+		return nil
+	}
+	return loadBundles(os.DirFS(dir), "locales", into)
+}
+
+// NormalizeLocale lowercases a locale and uses "-" between its parts, so "pt_BR" and
+// "pt-br" name the same bundle
+func NormalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// localeChain lists the locales tried for a string, most specific first: "es-MX"
+// tries es-mx, then es, then the default locale
+func localeChain(locale string) []string {
+	locale = NormalizeLocale(locale)
+	var chain []string
+	if locale != "" {
+		chain = append(chain, locale)
+		if lang, _, ok := strings.Cut(locale, "-"); ok {
+			chain = append(chain, lang)
+		}
+	}
+	return append(chain, DefaultLocale)
+}
+
+// Locales returns the locales that have a bundle
+func (s *Set) Locales() []string {
+	locales := make([]string, 0, len(s.bundles))
+	for locale := range s.bundles {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Translate returns the string for key in locale, falling back to the language and
+// then to English. Args are name, value pairs filling the string's placeholders; an
+// "n" argument also picks the plural form. A key no bundle has is returned as is, so
+// a missing translation shows up plainly instead of as an empty notification.
+func (s *Set) Translate(locale, key string, args ...any) string {
+	values := map[string]string{}
+	n, hasCount := 0, false
+	for i := 0; i+1 < len(args); i += 2 {
+		name := fmt.Sprint(args[i])
+		values[name] = fmt.Sprint(args[i+1])
+		if name == "n" {
+			n, hasCount = count(args[i+1])
+		}
+	}
+
+	text, ok := "", false
+	for _, candidate := range localeChain(locale) {
+		bundle := s.bundles[candidate]
+		if hasCount {
+			if text, ok = bundle[key+"."+pluralForm(candidate, n)]; ok {
+				break
+			}
+			if text, ok = bundle[key+".other"]; ok {
+				break
+			}
+		}
+		if text, ok = bundle[key]; ok {
+			break
+		}
+	}
+	if !ok {
+		return key
+	}
+
+	for name, value := range values {
+		text = strings.ReplaceAll(text, "{"+name+"}", value)
+	}
+	return text
+}
+
+// pluralForm is the CLDR plural category of n for the languages the bundles cover.
+// Languages without plurals, like Japanese, only need key.other.
+func pluralForm(locale string, n int) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	switch lang {
+	case "ja", "zh", "ko":
+		return "other"
+	case "fr", "pt":
+		if n == 0 || n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+func count(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
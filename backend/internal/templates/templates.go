@@ -0,0 +1,286 @@
+// Package templates renders the wording of notifications, so a launch reads the same
+// in a push, an email and a webhook post. Templates are chosen per channel and
+// trigger and use Go's text/template, with html/template for HTML email. The words
+// themselves come from locale bundles through the t helper, so one template serves
+// every language.
+//
+// A template is looked up in the notification_templates collection, then in the
+// template directory as <channel>/<trigger>.tmpl, then among the built in ones. Each
+// source is tried for the user's locale, then their language, then without a locale,
+// first for the delivery channel and then for the "default" channel. A template
+// defines "title", "body" and optionally "html":
+//
+//	{{define "title"}}{{.Event.Title}}{{end}}
+//	{{define "body"}}{{t "launch.in" "time" (countdown .Event.Net)}}{{end}}
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+//go:embed defaults
+var defaultFiles embed.FS
+
+// DefaultChannel holds the templates shared by every channel without its own
+const DefaultChannel = "default"
+
+// ErrNoTemplate is returned when no source has a template for a channel and trigger
+var ErrNoTemplate = errors.New("no template")
+
+// Text is rendered notification wording. HTML is only set when the template defines
+// an "html" part.
+type Text struct {
+	Title string
+	Body  string
+	HTML  string
+}
+
+// Notification is what notification templates render: the job's trigger, the event it
+// is about and the trigger's detail, such as the from and to of a status change
+type Notification struct {
+	Trigger string
+	Event   Event
+	Detail  map[string]any
+}
+
+// Event is the launch or other event a notification is about. Provider is the
+// provider's name where known, otherwise its record ID; abbrev handles both.
+type Event struct {
+	ID         string
+	Title      string
+	Type       string
+	Net        time.Time
+	Status     string
+	Provider   string
+	Location   string
+	WebcastURL string
+}
+
+// Set is a source of templates and locale bundles
+type Set struct {
+	// Dir holds templates and locales/<locale>.json bundles overriding the built in
+	// ones. Templates are read on every render, so edits apply without a restart.
+	Dir string
+
+	bundles map[string]Bundle
+
+	mu        sync.RWMutex
+	stored    map[string]string
+	providers map[string]string
+}
+
+// Default is the set of built in templates and bundles
+var Default = mustSet("")
+
+// NewSet returns the built in templates and bundles, overridden by those in dir
+func NewSet(dir string) (*Set, error) {
+	s := &Set{Dir: dir, bundles: map[string]Bundle{}}
+	if err := loadBundles(localeFiles, "locales", s.bundles); err != nil {
+		return nil, err
+	}
+	if err := loadDirBundles(dir, s.bundles); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func mustSet(dir string) *Set {
+	s, err := NewSet(dir)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// LoadPocketBase reads the templates stored in notification_templates and the
+// provider abbreviations from agencies, replacing those loaded before
+func (s *Set) LoadPocketBase(client *pbclient.Client) error {
+	records, err := client.ListAllRecords("notification_templates", "", "")
+	if err != nil {
+		return fmt.Errorf("list notification templates: %w", err)
+	}
+	stored := make(map[string]string, len(records))
+	for _, record := range records {
+		channel, _ := record["channel"].(string)
+		trigger, _ := record["trigger"].(string)
+		locale, _ := record["locale"].(string)
+		if channel == "" || trigger == "" {
+			continue
+		}
+		stored[templateKey(channel, trigger, NormalizeLocale(locale))] = storedSource(record)
+	}
+
+	agencies, err := client.ListAllRecords("agencies", "", "")
+	if err != nil {
+		return fmt.Errorf("list agencies: %w", err)
+	}
+	providers := make(map[string]string, 2*len(agencies))
+	for _, agency := range agencies {
+		abbrev, _ := agency["abbrev"].(string)
+		if abbrev == "" {
+			continue
+		}
+		if id, _ := agency["id"].(string); id != "" {
+			providers[id] = abbrev
+		}
+		if name, _ := agency["name"].(string); name != "" {
+			providers[name] = abbrev
+		}
+	}
+
+	s.mu.Lock()
+	s.stored, s.providers = stored, providers
+	s.mu.Unlock()
+	return nil
+}
+
+// storedSource turns a notification_templates record into the define blocks a
+// template file would hold
+func storedSource(record map[string]interface{}) string {
+	var src strings.Builder
+	for _, part := range []string{"title", "body", "html"} {
+		if text, _ := record[part].(string); text != "" {
+			fmt.Fprintf(&src, `{{define "%s"}}%s{{end}}`, part, text)
+		}
+	}
+	return src.String()
+}
+
+// Abbrev returns a provider's abbreviation from its name or record ID, or the name
+// itself when it has none
+func (s *Set) Abbrev(provider string) string {
+	s.mu.RLock()
+	abbrev, ok := s.providers[provider]
+	s.mu.RUnlock()
+	if ok {
+		return abbrev
+	}
+	if abbrev, ok := knownProviders[provider]; ok {
+		return abbrev
+	}
+	return provider
+}
+
+// Render renders the template for channel and trigger with data, for the user
+// described by ctx
+func (s *Set) Render(channel, trigger string, ctx Context, data any) (Text, error) {
+	src, name, err := s.lookup(channel, trigger, ctx.Locale)
+	if err != nil {
+		return Text{}, err
+	}
+	funcs := s.Funcs(ctx)
+
+	tmpl, err := texttemplate.New(name).Funcs(funcs).Parse(src)
+	if err != nil {
+		return Text{}, err
+	}
+	var text Text
+	for part, into := range map[string]*string{"title": &text.Title, "body": &text.Body} {
+		if tmpl.Lookup(part) == nil {
+			continue
+		}
+		var out bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&out, part, data); err != nil {
+			return Text{}, err
+		}
+		*into = strings.TrimSpace(out.String())
+	}
+
+	if tmpl.Lookup("html") != nil {
+		// Parsed again so html/template escapes what it inserts
+		page, err := htmltemplate.New(name).Funcs(funcs).Parse(src)
+		if err != nil {
+			return Text{}, err
+		}
+		var out bytes.Buffer
+		if err := page.ExecuteTemplate(&out, "html", data); err != nil {
+			return Text{}, err
+		}
+		text.HTML = out.String()
+	}
+	return text, nil
+}
+
+// lookup finds the source of the most specific template for channel, trigger and
+// locale, and a name for it in errors
+func (s *Set) lookup(channel, trigger, locale string) (string, string, error) {
+	locales := localeChain(locale)
+	// The last entry of the chain is the default locale; templates without a locale
+	// are tried in its place, as the built in ones have none
+	locales[len(locales)-1] = ""
+
+	channels := []string{channel}
+	if channel != DefaultChannel {
+		channels = append(channels, DefaultChannel)
+	}
+
+	s.mu.RLock()
+	stored := s.stored
+	s.mu.RUnlock()
+
+	for _, ch := range channels {
+		for _, loc := range locales {
+			if src, ok := stored[templateKey(ch, trigger, loc)]; ok {
+				return src, "notification_templates " + templateKey(ch, trigger, loc), nil
+			}
+			file := templateFile(ch, trigger, loc)
+			if s.Dir != "" {
+				path := filepath.Join(s.Dir, file)
+				if data, err := os.ReadFile(path); err == nil {
+					return string(data), path, nil
+				} else if !errors.Is(err, fs.ErrNotExist) {
+					log.Printf("⚠️ Skipping template %s: %v", path, err)
+				}
+			}
+			if data, err := fs.ReadFile(defaultFiles, "defaults/"+file); err == nil {
+				return string(data), file, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("%w for %s %s", ErrNoTemplate, channel, trigger)
+}
+
+func templateKey(channel, trigger, locale string) string {
+	return channel + "/" + trigger + "/" + locale
+}
+
+// templateFile is a template's path under a template directory
+func templateFile(channel, trigger, locale string) string {
+	if locale != "" {
+		return channel + "/" + trigger + "." + locale + ".tmpl"
+	}
+	return channel + "/" + trigger + ".tmpl"
+}
+
+// knownProviders abbreviates common providers before agencies are loaded
+var knownProviders = map[string]string{
+	"National Aeronautics and Space Administration": "NASA",
+	"SpaceX":                               "SpaceX",
+	"Space Exploration Technologies Corp.": "SpaceX",
+	"United Launch Alliance":               "ULA",
+	"Rocket Lab":                           "RL",
+	"Rocket Lab Ltd":                       "RL",
+	"Blue Origin":                          "BO",
+	"Arianespace":                          "ASA",
+	"European Space Agency":                "ESA",
+	"Japan Aerospace Exploration Agency":   "JAXA",
+	"Mitsubishi Heavy Industries":          "MHI",
+	"Indian Space Research Organization":   "ISRO",
+	"China Aerospace Science and Technology Corporation": "CASC",
+	"Russian Federal Space Agency (ROSCOSMOS)":           "RFSA",
+	"Northrop Grumman Space Systems":                     "NGSS",
+}
@@ -1,61 +1,42 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
+	"time"
 
-	// "log"
-	"net/http"
+	"github.com/signal-k/notifs/internal/sync"
 )
 
-type LaunchAPIResponse struct {
-	Results []Launch `json:"results"`
-}
-
-type Launch struct {
-	ID      string   `json:"id"`
-	Name    string   `json:"name"`
-	Net     string   `json:"net"`
-	URL     string   `json:"url"`
-	VidURLs []VidURL `json:"vidURLs"`
-}
-
-type VidURL struct {
-	URL       string `json:"url"`
-	Title     string `json:"title"`
-	Source    string `json:"source"`
-	Publisher string `json:"publisher"`
-}
-
+// ListLaunchesWithVidURLs prints the classified webcasts of the next upcoming launches
 func ListLaunchesWithVidURLs() error {
-	apiURL := "https://ll.thespacedevs.com/2.2.0/launch/upcoming/?limit=15&mode=detailed"
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch launches: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	launches, err := sync.FetchUpcomingWebcasts(0, 15)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %v", err)
-	}
-
-	var result LaunchAPIResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return fmt.Errorf("failed to parse JSON: %v", err)
+		return err
 	}
 
 	count := 0
-	for _, launch := range result.Results {
-		if len(launch.VidURLs) > 0 {
-			count++
-			fmt.Printf("Launch: %s\nID: %s\nDate: %s\nURL: %s\nVideo URLs:\n", launch.Name, launch.ID, launch.Net, launch.URL)
-			for _, v := range launch.VidURLs {
-				fmt.Printf("  - %s (%s) [%s] %s\n", v.Title, v.Publisher, v.Source, v.URL)
+	for _, launch := range launches {
+		if len(launch.Videos) == 0 {
+			continue
+		}
+		count++
+		fmt.Printf("Launch: %s\nID: %s\nDate: %s\nURL: %s\nVideo URLs:\n", launch.Name, launch.ID, launch.Net.UTC().Format(time.RFC3339), launch.URL)
+		for _, v := range launch.Videos {
+			live := ""
+			if v.Live {
+				live = " 🔴 LIVE"
+			}
+			id := ""
+			if v.VideoID != "" {
+				id = " id=" + v.VideoID
+			}
+			language := ""
+			if v.Language != "" {
+				language = " lang=" + v.Language
 			}
-			fmt.Println("--------------------------------------------------")
+			fmt.Printf("  - %s (%s) [%s%s%s]%s %s\n", v.Title, v.Publisher, v.Platform, id, language, live, v.URL)
 		}
+		fmt.Println("--------------------------------------------------")
 	}
 	fmt.Printf("Total launches with video URLs: %d\n", count)
 	return nil
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// LaunchUpdate is one entry of the cross-launch updates feed
+type LaunchUpdate struct {
+	ID        string    `json:"id"`
+	EventID   string    `json:"event_id"`
+	Event     string    `json:"event"`
+	Author    string    `json:"author"`
+	Comment   string    `json:"comment"`
+	InfoURL   string    `json:"info_url,omitempty"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+// LatestLaunchUpdates returns the newest updates posted across all events, optionally
+// only those posted after since
+func LatestLaunchUpdates(client *pbclient.Client, limit int, since time.Time) ([]LaunchUpdate, error) {
+	filter := ""
+	if !since.IsZero() {
+		filter = fmt.Sprintf(`created_on>"%s"`, since.UTC().Format("2006-01-02 15:04:05.000Z"))
+	}
+
+	records, err := client.QueryRecords("event_updates", filter, "-created_on", "event", limit)
+	if err != nil {
+		return nil, fmt.Errorf("list event updates: %w", err)
+	}
+
+	updates := make([]LaunchUpdate, 0, len(records))
+	for _, record := range records {
+		update := LaunchUpdate{}
+		update.ID, _ = record["id"].(string)
+		update.EventID, _ = record["event"].(string)
+		update.Author, _ = record["author"].(string)
+		update.Comment, _ = record["comment"].(string)
+		update.InfoURL, _ = record["info_url"].(string)
+		update.CreatedOn, _ = parseRecordTime(record["created_on"])
+		if expand, ok := record["expand"].(map[string]interface{}); ok {
+			if event, ok := expand["event"].(map[string]interface{}); ok {
+				update.Event, _ = event["title"].(string)
+			}
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// PrintLatestLaunchUpdates logs the newest updates, most recent first
+func PrintLatestLaunchUpdates(client *pbclient.Client, limit int) error {
+	updates, err := LatestLaunchUpdates(client, limit, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	if len(updates) == 0 {
+		fmt.Println("No launch updates stored yet")
+		return nil
+	}
+
+	for _, u := range updates {
+		fmt.Printf("📰 %s  %s\n", u.CreatedOn.UTC().Format(time.RFC3339), u.Event)
+		fmt.Printf("   %s — %s\n", u.Comment, u.Author)
+		if u.InfoURL != "" {
+			fmt.Printf("   🔗 %s\n", u.InfoURL)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// outboxIcons marks each delivery status in listings
+var outboxIcons = map[string]string{
+	notify.OutboxQueued:    "⏳",
+	notify.OutboxSending:   "📤",
+	notify.OutboxDelivered: "✅",
+	notify.OutboxFailed:    "🔁",
+	notify.OutboxDead:      "💀",
+	notify.OutboxSkipped:   "➖",
+}
+
+// PrintOutbox lists the newest notification deliveries with the given comma separated
+// statuses, or every status for "all"
+func PrintOutbox(client *pbclient.Client, statuses string, limit int) error {
+	var filter []string
+	if statuses != "all" {
+		for _, status := range strings.Split(statuses, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter = append(filter, status)
+			}
+		}
+	}
+
+	entries, err := notify.ListOutbox(client, limit, filter...)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No %s deliveries\n", statuses)
+		return nil
+	}
+
+	for _, e := range entries {
+		fmt.Printf("%s %s  %-8s %-9s attempts=%d  %s\n", outboxIcons[e.Status], e.ID, e.Channel, e.Status, e.Attempts, e.IdempotencyKey)
+		if e.Status == notify.OutboxFailed {
+			fmt.Printf("   next attempt %s\n", e.NextAttempt.UTC().Format(time.RFC3339))
+		}
+		if e.LastError != "" {
+			fmt.Printf("   %s\n", e.LastError)
+		}
+	}
+	return nil
+}
+
+// RequeueOutbox puts deliveries back in the queue: one delivery by record ID, or every
+// delivery that is "failed" or "dead"
+func RequeueOutbox(client *pbclient.Client, target string) (int, error) {
+	var entries []notify.OutboxEntry
+	switch target {
+	case notify.OutboxFailed, notify.OutboxDead:
+		all, err := notify.ListOutbox(client, 0, target)
+		if err != nil {
+			return 0, err
+		}
+		entries = all
+	default:
+		records, err := client.QueryRecords("notification_outbox", fmt.Sprintf(`id="%s"`, target), "", "", 1)
+		if err != nil {
+			return 0, fmt.Errorf("find delivery %s: %w", target, err)
+		}
+		if len(records) == 0 {
+			return 0, fmt.Errorf("no delivery with ID %s", target)
+		}
+		entries = []notify.OutboxEntry{notify.OutboxEntryFromRecord(records[0])}
+	}
+
+	requeued := 0
+	for _, e := range entries {
+		if err := notify.Requeue(client, e); err != nil {
+			return requeued, err
+		}
+		log.Printf("🔁 Requeued %s delivery %s", e.Channel, e.IdempotencyKey)
+		requeued++
+	}
+	return requeued, nil
+}
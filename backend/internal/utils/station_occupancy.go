@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// PortOccupancy describes one docking port of a station at a point in time
+type PortOccupancy struct {
+	Port              string     `json:"port"`
+	PortID            string     `json:"port_id"`
+	Occupied          bool       `json:"occupied"`
+	Vehicle           string     `json:"vehicle,omitempty"`
+	DockingEventID    string     `json:"docking_event_id,omitempty"`
+	DockedAt          *time.Time `json:"docked_at,omitempty"`
+	ExpectedDeparture *time.Time `json:"expected_departure,omitempty"`
+}
+
+// StationOccupancy returns every docking port of a station (matched by record ID or name)
+// and the vehicle attached to it at the given time
+func StationOccupancy(client *pbclient.Client, station string, at time.Time) ([]PortOccupancy, error) {
+	stationRecord, err := findStation(client, station)
+	if err != nil {
+		return nil, err
+	}
+	stationID, _ := stationRecord["id"].(string)
+
+	ports, err := client.ListAllRecords("docking_locations", fmt.Sprintf(`station="%s"`, stationID), "")
+	if err != nil {
+		return nil, fmt.Errorf("list docking locations: %w", err)
+	}
+
+	dockings, err := client.ListAllRecords("docking_events", fmt.Sprintf(`station="%s"`, stationID), "chaser_spacecraft_flight")
+	if err != nil {
+		return nil, fmt.Errorf("list docking events: %w", err)
+	}
+
+	occupancy := make([]PortOccupancy, 0, len(ports))
+	byID := make(map[string]int)
+	byName := make(map[string]int)
+	for _, port := range ports {
+		id, _ := port["id"].(string)
+		name, _ := port["name"].(string)
+		byID[id] = len(occupancy)
+		byName[strings.ToLower(name)] = len(occupancy)
+		occupancy = append(occupancy, PortOccupancy{Port: name, PortID: id})
+	}
+
+	for _, docking := range dockings {
+		dockedAt, err := parseRecordTime(docking["docking_time"])
+		if err != nil || dockedAt.After(at) {
+			continue
+		}
+		departure, depErr := parseRecordTime(docking["departure_time"])
+		if depErr == nil && !departure.After(at) {
+			continue
+		}
+
+		// Prefer the port relation, falling back to the flattened location name
+		portID, _ := docking["docking_location"].(string)
+		locationName, _ := docking["location_name"].(string)
+		idx, ok := byID[portID]
+		if !ok {
+			idx, ok = byName[strings.ToLower(locationName)]
+		}
+		if !ok {
+			idx = len(occupancy)
+			byName[strings.ToLower(locationName)] = idx
+			occupancy = append(occupancy, PortOccupancy{Port: locationName, PortID: portID})
+		}
+
+		entry := &occupancy[idx]
+		// Two overlapping records for one port: keep the most recent docking
+		if entry.Occupied && entry.DockedAt != nil && entry.DockedAt.After(dockedAt) {
+			continue
+		}
+
+		entry.Occupied = true
+		entry.Vehicle = dockingVehicleName(docking)
+		entry.DockingEventID, _ = docking["id"].(string)
+		entry.DockedAt = &dockedAt
+		entry.ExpectedDeparture = nil
+		if depErr == nil {
+			entry.ExpectedDeparture = &departure
+		}
+	}
+
+	sort.SliceStable(occupancy, func(i, j int) bool {
+		return occupancy[i].Port < occupancy[j].Port
+	})
+	return occupancy, nil
+}
+
+// PrintStationOccupancy logs which vehicles are attached to which ports of a station
+func PrintStationOccupancy(client *pbclient.Client, station string, at time.Time) error {
+	occupancy, err := StationOccupancy(client, station, at)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🛰️  %s at %s\n", station, at.UTC().Format(time.RFC3339))
+	occupied := 0
+	for _, port := range occupancy {
+		if !port.Occupied {
+			fmt.Printf("  ⬜ %-30s free\n", port.Port)
+			continue
+		}
+		occupied++
+		departure := "no departure scheduled"
+		if port.ExpectedDeparture != nil {
+			departure = "departs " + port.ExpectedDeparture.UTC().Format(time.RFC3339)
+		}
+		fmt.Printf("  🟩 %-30s %s (docked %s, %s)\n", port.Port, port.Vehicle, port.DockedAt.UTC().Format(time.RFC3339), departure)
+	}
+	fmt.Printf("Occupied ports: %d/%d\n", occupied, len(occupancy))
+	return nil
+}
+
+func findStation(client *pbclient.Client, station string) (map[string]interface{}, error) {
+	escaped := strings.ReplaceAll(station, `"`, `\"`)
+	records, err := client.ListAllRecords("stations", fmt.Sprintf(`id="%s" || name~"%s"`, escaped, escaped), "")
+	if err != nil {
+		return nil, fmt.Errorf("lookup station: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("station %q not found", station)
+	}
+
+	// Prefer an exact name match when the search is ambiguous (e.g. "Tiangong")
+	for _, record := range records {
+		if name, _ := record["name"].(string); strings.EqualFold(name, station) {
+			return record, nil
+		}
+	}
+	return records[0], nil
+}
+
+// dockingVehicleName picks the best available label for the chaser vehicle
+func dockingVehicleName(docking map[string]interface{}) string {
+	if expand, ok := docking["expand"].(map[string]interface{}); ok {
+		if flight, ok := expand["chaser_spacecraft_flight"].(map[string]interface{}); ok {
+			if name, _ := flight["spacecraft_name"].(string); name != "" {
+				return name
+			}
+		}
+	}
+	for _, field := range []string{"chaser_payload_name", "chaser_launch_name"} {
+		if name, _ := docking[field].(string); name != "" {
+			return name
+		}
+	}
+	return "Unknown vehicle"
+}
+
+// parseRecordTime parses the RFC 3339 and PocketBase datetime formats stored in records
+func parseRecordTime(value interface{}) (time.Time, error) {
+	s, _ := value.(string)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.000Z", "2006-01-02 15:04:05Z"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time format: %s", s)
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/pbfake"
+)
+
+func create(t *testing.T, client *pbclient.Client, collection string, data map[string]interface{}) string {
+	t.Helper()
+	record, err := client.CreateRecord(collection, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return (*record)["id"].(string)
+}
+
+func TestStationOccupancy(t *testing.T) {
+	srv := httptest.NewServer(pbfake.New())
+	t.Cleanup(srv.Close)
+	client := pbclient.NewClient(srv.URL)
+
+	iss := create(t, client, "stations", map[string]interface{}{"name": "International Space Station"})
+	create(t, client, "stations", map[string]interface{}{"name": "Tiangong"})
+	forward := create(t, client, "docking_locations", map[string]interface{}{"station": iss, "name": "Harmony forward"})
+	zenith := create(t, client, "docking_locations", map[string]interface{}{"station": iss, "name": "Harmony zenith"})
+	poisk := create(t, client, "docking_locations", map[string]interface{}{"station": iss, "name": "Poisk zenith"})
+
+	dock := func(port, location, flightName string, extra map[string]interface{}) {
+		data := map[string]interface{}{"station": iss, "docking_location": port, "location_name": location}
+		if flightName != "" {
+			data["chaser_spacecraft_flight"] = create(t, client, "spacecraft_flights", map[string]interface{}{"spacecraft_name": flightName})
+		}
+		for k, v := range extra {
+			data[k] = v
+		}
+		create(t, client, "docking_events", data)
+	}
+	// Crew-8 was still on record at Harmony forward when Crew-9 arrived there
+	dock(forward, "Harmony forward", "Dragon Endeavour", map[string]interface{}{
+		"docking_time": "2024-03-05 07:28:00.000Z", "departure_time": "2024-10-23 11:05:00.000Z",
+	})
+	dock(forward, "Harmony forward", "Dragon Freedom", map[string]interface{}{
+		"docking_time": "2024-09-29 21:30:00.000Z", "departure_time": "2025-03-18 10:00:00.000Z",
+	})
+	// Crew-10 arrived two days before Crew-9 left
+	dock(zenith, "Harmony zenith", "Dragon Endurance", map[string]interface{}{
+		"docking_time": "2025-03-16 04:04:00.000Z",
+	})
+	// Cargo with no crew flight behind it, and a Soyuz only known by location name
+	dock(poisk, "Poisk zenith", "", map[string]interface{}{
+		"chaser_payload_name": "Cygnus NG-21",
+		"docking_time":        "2024-08-06 07:52:00.000Z", "departure_time": "2025-03-28 11:00:00.000Z",
+	})
+	dock("", "Rassvet nadir", "", map[string]interface{}{
+		"chaser_launch_name": "Soyuz MS-26",
+		"docking_time":       "2024-09-11 19:32:00.000Z", "departure_time": "2025-04-20 01:57:00.000Z",
+	})
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want map[string]string // port -> vehicle, "" for a free port
+	}{
+		{
+			name: "before Crew-9",
+			at:   time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			want: map[string]string{"Harmony forward": "Dragon Endeavour", "Harmony zenith": "", "Poisk zenith": "Cygnus NG-21"},
+		},
+		{
+			name: "two records on one port",
+			at:   time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+			want: map[string]string{"Harmony forward": "Dragon Freedom", "Harmony zenith": "", "Poisk zenith": "Cygnus NG-21", "Rassvet nadir": "Soyuz MS-26"},
+		},
+		{
+			name: "crew handover",
+			at:   time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC),
+			want: map[string]string{"Harmony forward": "Dragon Freedom", "Harmony zenith": "Dragon Endurance", "Poisk zenith": "Cygnus NG-21", "Rassvet nadir": "Soyuz MS-26"},
+		},
+		{
+			name: "at Crew-9 undocking",
+			at:   time.Date(2025, 3, 18, 10, 0, 0, 0, time.UTC),
+			want: map[string]string{"Harmony forward": "", "Harmony zenith": "Dragon Endurance", "Poisk zenith": "Cygnus NG-21", "Rassvet nadir": "Soyuz MS-26"},
+		},
+	}
+
+	for _, tt := range tests {
+		occupancy, err := StationOccupancy(client, "International Space Station", tt.at)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := map[string]string{}
+		for i, port := range occupancy {
+			if i > 0 && occupancy[i-1].Port > port.Port {
+				t.Errorf("%s: ports out of order: %s before %s", tt.name, occupancy[i-1].Port, port.Port)
+			}
+			if port.Occupied != (port.Vehicle != "") {
+				t.Errorf("%s: %s occupied=%v with vehicle %q", tt.name, port.Port, port.Occupied, port.Vehicle)
+			}
+			got[port.Port] = port.Vehicle
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: ports = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for port, vehicle := range tt.want {
+			if got[port] != vehicle {
+				t.Errorf("%s: %s = %q, want %q", tt.name, port, got[port], vehicle)
+			}
+		}
+	}
+
+	// Crew-10 has no departure scheduled yet
+	occupancy, err := StationOccupancy(client, "international space station", time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, port := range occupancy {
+		if port.Port == "Harmony zenith" && port.ExpectedDeparture != nil {
+			t.Errorf("Crew-10 expected departure = %s, want none", port.ExpectedDeparture)
+		}
+		if port.Port == "Harmony forward" && (port.ExpectedDeparture == nil || !port.ExpectedDeparture.Equal(time.Date(2025, 3, 18, 10, 0, 0, 0, time.UTC))) {
+			t.Errorf("Crew-9 expected departure = %v", port.ExpectedDeparture)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// templateChannel is the channel webhook templates are looked up under
+const templateChannel = "webhook"
+
+// Discord embed colours per change kind
+var discordColors = map[string]int{
+	notify.ChangeLaunchCreated: 0x3498DB,
+	notify.ChangeNetChanged:    0xF39C12,
+	notify.ChangeStatusChanged: 0xF1C40F,
+	notify.ChangeWebcastLive:   0x9B59B6,
+	notify.ChangeLaunchSuccess: 0x2ECC71,
+	notify.ChangeLaunchFailure: 0xE74C3C,
+}
+
+// message is a change worded for one webhook
+type message struct {
+	templates.Text
+	set    *templates.Set
+	locale string
+}
+
+// label is a field name in the webhook's language
+func (m message) label(key string) string {
+	return m.set.Translate(m.locale, key)
+}
+
+// Render builds the request body for a change in the webhook's format, worded by the
+// webhook templates in its language
+func Render(set *templates.Set, hook Webhook, event notify.Event, change notify.Change) (map[string]any, error) {
+	if set == nil {
+		set = templates.Default
+	}
+	provider := event.Provider
+	if event.ProviderName != "" {
+		provider = event.ProviderName
+	}
+	text, err := set.Render(templateChannel, change.Kind, templates.Context{Locale: hook.Locale}, templates.Notification{
+		Trigger: change.Kind,
+		Detail:  change.Detail,
+		Event: templates.Event{
+			ID:         event.ID,
+			Title:      event.Title,
+			Type:       event.Type,
+			Net:        event.Net,
+			Status:     event.Status,
+			Provider:   provider,
+			Location:   event.Location,
+			WebcastURL: event.WebcastURL,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", change.Kind, err)
+	}
+	if text.Title == "" {
+		text.Title = event.Title
+	}
+	msg := message{Text: text, set: set, locale: hook.Locale}
+
+	switch hook.Format {
+	case FormatJSON, "":
+		return renderJSON(event, change, msg), nil
+	case FormatDiscord:
+		return renderDiscord(event, change, msg), nil
+	case FormatSlack:
+		return renderSlack(event, msg), nil
+	}
+	return nil, fmt.Errorf("unknown webhook format %q", hook.Format)
+}
+
+func renderJSON(event notify.Event, change notify.Change, msg message) map[string]any {
+	payload := map[string]any{
+		"type": change.Kind,
+		"event": map[string]any{
+			"id":           event.ID,
+			"title":        event.Title,
+			"type":         event.Type,
+			"net":          formatNet(event.Net),
+			"status":       event.Status,
+			"location":     event.Location,
+			"webcast_live": event.WebcastLive,
+			"webcast_url":  event.WebcastURL,
+		},
+		"message":   map[string]any{"title": msg.Title, "body": msg.Body},
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	if len(change.Detail) > 0 {
+		payload["detail"] = change.Detail
+	}
+	return payload
+}
+
+func renderDiscord(event notify.Event, change notify.Change, msg message) map[string]any {
+	fields := []map[string]any{}
+	if !event.Net.IsZero() {
+		fields = append(fields, map[string]any{
+			"name":   msg.label("label.net"),
+			"value":  fmt.Sprintf("<t:%d:F> (<t:%d:R>)", event.Net.Unix(), event.Net.Unix()),
+			"inline": true,
+		})
+	}
+	if event.Status != "" {
+		fields = append(fields, map[string]any{"name": msg.label("label.status"), "value": event.Status, "inline": true})
+	}
+	if event.Location != "" {
+		fields = append(fields, map[string]any{"name": msg.label("label.pad"), "value": event.Location})
+	}
+
+	embed := map[string]any{
+		"title":     msg.Title,
+		"color":     discordColors[change.Kind],
+		"fields":    fields,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	if msg.Body != "" {
+		embed["description"] = msg.Body
+	}
+	if event.WebcastURL != "" {
+		embed["url"] = event.WebcastURL
+	}
+	return map[string]any{"embeds": []map[string]any{embed}}
+}
+
+func renderSlack(event notify.Event, msg message) map[string]any {
+	blocks := []map[string]any{
+		{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": msg.Title},
+		},
+	}
+	if msg.Body != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": msg.Body},
+		})
+	}
+
+	context := []map[string]any{}
+	if !event.Net.IsZero() {
+		// Slack renders the date in each reader's own timezone
+		context = append(context, map[string]any{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("%s <!date^%d^{date_short_pretty} {time}|%s>", msg.label("label.net"), event.Net.Unix(), formatNet(event.Net)),
+		})
+	}
+	if event.Location != "" {
+		context = append(context, map[string]any{"type": "mrkdwn", "text": "📍 " + event.Location})
+	}
+	if len(context) > 0 {
+		blocks = append(blocks, map[string]any{"type": "context", "elements": context})
+	}
+
+	if event.WebcastURL != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "actions",
+			"elements": []map[string]any{{
+				"type": "button",
+				"text": map[string]any{"type": "plain_text", "text": msg.label("label.watch")},
+				"url":  event.WebcastURL,
+			}},
+		})
+	}
+
+	return map[string]any{"text": msg.Title, "blocks": blocks}
+}
+
+func formatNet(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
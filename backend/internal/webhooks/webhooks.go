@@ -0,0 +1,167 @@
+// Package webhooks posts launch changes to community servers. The notification engine
+// hands every detected change to a Queue, which stores one delivery per matching
+// webhook; a Worker then signs and sends them, retrying with exponential backoff and
+// disabling webhooks that keep failing.
+package webhooks
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/pbclient"
+	"github.com/signal-k/notifs/internal/templates"
+)
+
+// Payload formats
+const (
+	FormatJSON    = "json"
+	FormatDiscord = "discord"
+	FormatSlack   = "slack"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is an endpoint that wants some kinds of launch changes. Empty Events or
+// Providers means no filter.
+type Webhook struct {
+	ID     string
+	Name   string
+	URL    string
+	Format string
+	Secret string
+	// Locale is the language posts are written in
+	Locale    string
+	Events    []string
+	Providers []string
+	Failures  int
+	Disabled  bool
+}
+
+// Wants reports whether the webhook asked for this change to this event
+func (w Webhook) Wants(event notify.Event, change notify.Change) bool {
+	if len(w.Events) > 0 && !slices.Contains(w.Events, change.Kind) {
+		return false
+	}
+	if len(w.Providers) > 0 && !slices.Contains(w.Providers, event.Provider) {
+		return false
+	}
+	return true
+}
+
+// Queue stores a pending delivery for every enabled webhook that wants a change. It is
+// a notify.ChangeListener.
+type Queue struct {
+	Client *pbclient.Client
+	// Templates word the posts; nil uses the built in ones
+	Templates *templates.Set
+}
+
+// OnChange queues the change for each matching webhook. Deliveries are keyed by
+// webhook, event and change, so a repeated pass never queues one twice.
+func (q *Queue) OnChange(event notify.Event, change notify.Change) error {
+	webhooks, err := LoadWebhooks(q.Client)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range webhooks {
+		if !hook.Wants(event, change) {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s:%s", hook.ID, event.ID, change.Kind)
+		if change.Instance != "" {
+			key += ":" + change.Instance
+		}
+		existing, err := q.Client.QueryRecords("webhook_deliveries", fmt.Sprintf(`dedupe_key="%s"`, key), "", "", 1)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			continue
+		}
+
+		body, err := Render(q.Templates, hook, event, change)
+		if err != nil {
+			return err
+		}
+		created, err := q.Client.CreateRecord("webhook_deliveries", map[string]interface{}{
+			"webhook":      hook.ID,
+			"dedupe_key":   key,
+			"kind":         change.Kind,
+			"event":        event.ID,
+			"body":         body,
+			"status":       DeliveryPending,
+			"attempts":     0,
+			"next_attempt": formatTime(time.Now()),
+		})
+		if err != nil {
+			return err
+		}
+		if id, _ := (*created)["id"].(string); id == "" {
+			return fmt.Errorf("queue webhook delivery: %v", (*created)["message"])
+		}
+		log.Printf("🪝 Queued %s for %s to %s", change.Kind, event.Title, hook.Name)
+	}
+	return nil
+}
+
+// LoadWebhooks returns every enabled webhook
+func LoadWebhooks(client *pbclient.Client) ([]Webhook, error) {
+	records, err := client.ListAllRecords("webhooks", "enabled=true", "")
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+
+	webhooks := make([]Webhook, 0, len(records))
+	for _, record := range records {
+		webhooks = append(webhooks, webhookFromRecord(record))
+	}
+	return webhooks, nil
+}
+
+func webhookFromRecord(record map[string]interface{}) Webhook {
+	hook := Webhook{}
+	hook.ID, _ = record["id"].(string)
+	hook.Name, _ = record["name"].(string)
+	hook.URL, _ = record["url"].(string)
+	hook.Format, _ = record["format"].(string)
+	hook.Secret, _ = record["secret"].(string)
+	hook.Locale, _ = record["locale"].(string)
+	hook.Events = stringList(record["events"])
+	hook.Providers = stringList(record["providers"])
+	if failures, ok := record["consecutive_failures"].(float64); ok {
+		hook.Failures = int(failures)
+	}
+	if hook.Name == "" {
+		hook.Name = hook.URL
+	}
+	return hook
+}
+
+func stringList(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	case []interface{}:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// Worker sends due deliveries
+type Worker struct {
+	Client *pbclient.Client
+	HTTP   *http.Client
+	// MaxAttempts is how often a delivery is tried before it is marked failed
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; each later retry waits twice as long
+	BaseDelay time.Duration
+	// DisableAfter is how many failed attempts in a row disable a webhook
+	DisableAfter int
+}
+
+// NewWorker returns a worker retrying 6 times over about half an hour and disabling a
+// webhook after 10 failures in a row
+func NewWorker(client *pbclient.Client) *Worker {
+	return &Worker{
+		Client:       client,
+		HTTP:         &http.Client{Timeout: 15 * time.Second},
+		MaxAttempts:  6,
+		BaseDelay:    30 * time.Second,
+		DisableAfter: 10,
+	}
+}
+
+// Sign returns the X-Webhook-Signature value for a body sent at timestamp. Receivers
+// recompute it over "<timestamp>.<body>" with their copy of the secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliverDue sends up to limit deliveries whose next attempt is due and returns how
+// many succeeded
+func (w *Worker) DeliverDue(limit int) (int, error) {
+	now := time.Now().UTC()
+	filter := fmt.Sprintf(`status="%s" && next_attempt<="%s"`, DeliveryPending, formatTime(now))
+	records, err := w.Client.QueryRecords("webhook_deliveries", filter, "next_attempt", "webhook", limit)
+	if err != nil {
+		return 0, fmt.Errorf("list due webhook deliveries: %w", err)
+	}
+
+	// Failure counts move as the batch is sent, so track them here rather than trusting
+	// the expanded copy on each delivery
+	hooks := map[string]*Webhook{}
+
+	delivered := 0
+	for _, record := range records {
+		id, _ := record["id"].(string)
+		kind, _ := record["kind"].(string)
+		attempts := 0
+		if n, ok := record["attempts"].(float64); ok {
+			attempts = int(n)
+		}
+
+		hookRecord, _ := expanded(record, "webhook")
+		if hookRecord != nil && hookRecord["enabled"] == true {
+			hookID, _ := hookRecord["id"].(string)
+			if hooks[hookID] == nil {
+				hook := webhookFromRecord(hookRecord)
+				hooks[hookID] = &hook
+			}
+		}
+		hookID, _ := record["webhook"].(string)
+		hook := hooks[hookID]
+		if hook == nil || hook.Disabled {
+			w.update("webhook_deliveries", id, map[string]interface{}{
+				"status":     DeliveryFailed,
+				"last_error": "webhook deleted or disabled",
+			})
+			continue
+		}
+
+		body, err := json.Marshal(record["body"])
+		if err != nil {
+			return delivered, err
+		}
+
+		attempts++
+		status, retryAfter, err := w.send(*hook, id, kind, body)
+		if err == nil {
+			delivered++
+			w.update("webhook_deliveries", id, map[string]interface{}{
+				"status":       DeliveryDelivered,
+				"attempts":     attempts,
+				"last_status":  status,
+				"last_error":   "",
+				"delivered_at": time.Now().UTC().Format(time.RFC3339),
+			})
+			if hook.Failures > 0 {
+				hook.Failures = 0
+				w.update("webhooks", hook.ID, map[string]interface{}{"consecutive_failures": 0})
+			}
+			continue
+		}
+
+		log.Printf("❌ Webhook %s delivery %s attempt %d failed: %v", hook.Name, id, attempts, err)
+		update := map[string]interface{}{
+			"attempts":    attempts,
+			"last_status": status,
+			"last_error":  err.Error(),
+		}
+		if attempts >= w.MaxAttempts || permanent(status) {
+			update["status"] = DeliveryFailed
+		} else {
+			delay := w.BaseDelay << (attempts - 1)
+			if retryAfter > delay {
+				delay = retryAfter
+			}
+			update["next_attempt"] = formatTime(time.Now().Add(delay))
+		}
+		w.update("webhook_deliveries", id, update)
+		w.recordFailure(hook, err)
+	}
+	return delivered, nil
+}
+
+// send posts one delivery and returns the response status and any Retry-After delay
+func (w *Worker) send(hook Webhook, deliveryID, kind string, body []byte) (int, time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "station98-webhooks")
+	req.Header.Set("X-Webhook-Id", deliveryID)
+	req.Header.Set("X-Webhook-Event", kind)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	if hook.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", Sign(hook.Secret, timestamp, body))
+	}
+
+	res, err := w.HTTP.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res.StatusCode, 0, nil
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	detail, _ := io.ReadAll(io.LimitReader(res.Body, 256))
+	return res.StatusCode, retryAfter, fmt.Errorf("%s %s", res.Status, bytes.TrimSpace(detail))
+}
+
+// recordFailure counts a failed attempt against the webhook and disables it once
+// DisableAfter attempts in a row have failed
+func (w *Worker) recordFailure(hook *Webhook, cause error) {
+	hook.Failures++
+	update := map[string]interface{}{"consecutive_failures": hook.Failures}
+	if hook.Failures >= w.DisableAfter {
+		hook.Disabled = true
+		update["enabled"] = false
+		update["disabled_reason"] = fmt.Sprintf("%d failed deliveries in a row, last: %v", hook.Failures, cause)
+		log.Printf("⛔ Disabled webhook %s after %d failed deliveries", hook.Name, hook.Failures)
+	}
+	w.update("webhooks", hook.ID, update)
+}
+
+func (w *Worker) update(collection, id string, data map[string]interface{}) {
+	if err := w.Client.UpdateRecord(collection, id, data); err != nil {
+		log.Printf("❌ Failed to update %s %s: %v", collection, id, err)
+	}
+}
+
+// permanent reports whether retrying a response status is pointless, such as a
+// webhook URL that no longer exists
+func permanent(status int) bool {
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// formatTime writes times the way PocketBase stores them, so next_attempt filters
+// compare like for like
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000Z")
+}
+
+func expanded(record map[string]interface{}, field string) (map[string]interface{}, bool) {
+	expand, ok := record["expand"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := expand[field].(map[string]interface{})
+	return value, ok
+}
@@ -0,0 +1,109 @@
+package webpush
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/signal-k/notifs/internal/notify"
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// defaultTTL is how long a push service holds alerts that have no natural deadline
+const defaultTTL = 24 * time.Hour
+
+// Channel delivers notification jobs to every browser a user subscribed
+type Channel struct {
+	Sender *Sender
+	PB     *pbclient.Client
+}
+
+func (c *Channel) Name() string {
+	return "webpush"
+}
+
+// Deliver pushes the job to the user's browsers, removing subscriptions that expired
+// or that the push service dropped. It succeeds if at least one browser got it.
+func (c *Channel) Deliver(job notify.Job) error {
+	subscriptions, err := UserSubscriptions(c.PB, job.User)
+	if err != nil {
+		return err
+	}
+
+	msg, err := JobMessage(job, time.Now())
+	if err != nil {
+		// A payload too large to encrypt won't fit on a retry either
+		return notify.Permanent(err)
+	}
+
+	delivered := 0
+	var lastErr error
+	for _, sub := range subscriptions {
+		if sub.Expired(time.Now()) {
+			log.Printf("🧹 Removing expired web push subscription for user %s", job.User)
+			c.remove(sub)
+			continue
+		}
+
+		err := c.Sender.Send(sub, msg)
+		switch {
+		case err == nil:
+			delivered++
+		case errors.Is(err, ErrGone):
+			log.Printf("🧹 Removing web push subscription the push service dropped for user %s", job.User)
+			c.remove(sub)
+		default:
+			lastErr = err
+		}
+	}
+
+	if delivered > 0 {
+		return nil
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	return notify.ErrNoRecipient
+}
+
+func (c *Channel) remove(sub Subscription) {
+	if err := RemoveSubscription(c.PB, sub.ID); err != nil {
+		log.Printf("❌ Failed to remove web push subscription %s: %v", sub.ID, err)
+	}
+}
+
+// JobMessage builds the push for a job. The payload is the JSON the dashboard's service
+// worker shows; the topic lets a newer alert for the same event replace an older one
+// still waiting at the push service.
+func JobMessage(job notify.Job, now time.Time) (Message, error) {
+	title, body := job.Message()
+	payload, err := json.Marshal(map[string]string{
+		"title":   title,
+		"body":    body,
+		"event":   job.Event,
+		"trigger": job.Trigger,
+		"tag":     job.Thread(),
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{
+		Payload: payload,
+		TTL:     defaultTTL,
+		Urgency: UrgencyNormal,
+		Topic:   job.Thread(),
+	}
+	if job.TimeSensitive() {
+		msg.Urgency = UrgencyHigh
+	}
+
+	// A countdown reminder is pointless once the launch time has passed
+	if net, ok := job.Detail["net"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, net); err == nil && t.After(now) {
+			msg.TTL = t.Sub(now)
+		}
+	}
+	return msg, nil
+}
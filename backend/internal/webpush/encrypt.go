@@ -0,0 +1,109 @@
+// Package webpush sends launch alerts to browsers with the Web Push protocol: payloads
+// are encrypted for the subscription (RFC 8291) and requests are signed with the
+// application server's VAPID key (RFC 8292).
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// recordSize is the aes128gcm record size. Push services accept a single record of at
+// most 4096 bytes, which leaves 3993 bytes for the payload.
+const recordSize = 4096
+
+// MaxPayload is the largest payload that fits in one record
+const MaxPayload = recordSize - 86 - 16 - 1
+
+// Encrypt encrypts a payload for a subscription's p256dh key and auth secret with the
+// aes128gcm content coding
+func Encrypt(payload []byte, p256dh, auth string) ([]byte, error) {
+	uaPublic, err := decodeKey(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+	authSecret, err := decodeKey(auth)
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encrypt(payload, uaPublic, authSecret, asPrivate, salt)
+}
+
+func encrypt(payload, uaPublicBytes, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	if len(payload) > MaxPayload {
+		return nil, fmt.Errorf("payload is %d bytes, the limit is %d", len(payload), MaxPayload)
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+
+	// RFC 8291 section 3.4: mix the auth secret and both public keys into the IKM
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 8188 section 2.2: derive the content encryption key and nonce
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single record: the payload followed by the 0x02 last-record delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	header := make([]byte, 0, 21+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// decodeKey decodes the base64url keys browsers hand out, with or without padding
+func decodeKey(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	if key, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return key, nil
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
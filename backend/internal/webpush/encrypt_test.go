@@ -0,0 +1,113 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+)
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := decodeKey(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// RFC 8291 Appendix A
+func TestEncryptRFC8291Vector(t *testing.T) {
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPublic := mustDecode(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4")
+	authSecret := mustDecode(t, "BTBZMqHH6r4Tts7J_aSIgg")
+	salt := mustDecode(t, "DGv6ra1nlYgDCS1FRnbzlw")
+
+	body, err := encrypt([]byte("When I grow up, I want to be a watermelon"), uaPublic, authSecret, asPrivate, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := base64.RawURLEncoding.EncodeToString(body); got != want {
+		t.Errorf("body = %s\nwant %s", got, want)
+	}
+}
+
+// decrypt is the user agent's side of RFC 8291
+func decrypt(t *testing.T, body []byte, uaPrivate *ecdh.PrivateKey, authSecret []byte) []byte {
+	t.Helper()
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Fatalf("record size = %d, want %d", rs, recordSize)
+	}
+	idLen := int(body[20])
+	asPublicBytes := body[21 : 21+idLen]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyInfo := "WebPush: info\x00" + string(uaPrivate.PublicKey().Bytes()) + string(asPublicBytes)
+	ikm, _ := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, body[21+idLen:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("record doesn't end with the last-record delimiter: %x", plaintext)
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	uaPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := mustDecode(t, "BTBZMqHH6r4Tts7J_aSIgg")
+	// Browsers may hand the keys out padded
+	p256dh := base64.URLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes())
+	auth := base64.URLEncoding.EncodeToString(authSecret)
+
+	payload := []byte(`{"title":"Crew-10","body":"Liftoff in 10 minutes"}`)
+	body, err := Encrypt(payload, p256dh, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decrypt(t, body, uaPrivate, authSecret); !bytes.Equal(got, payload) {
+		t.Errorf("decrypted %q, want %q", got, payload)
+	}
+
+	// Each message gets a fresh salt and key
+	again, err := Encrypt(payload, p256dh, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(body[:16], again[:16]) {
+		t.Error("two messages share a salt")
+	}
+
+	if _, err := Encrypt(make([]byte, MaxPayload+1), p256dh, auth); err == nil {
+		t.Error("encrypted a payload over the record limit")
+	}
+	if _, err := Encrypt(payload, "not-a-key", auth); err == nil {
+		t.Error("encrypted for an invalid p256dh key")
+	}
+}
@@ -0,0 +1,92 @@
+package webpush
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Urgency values (RFC 8030 section 5.3)
+const (
+	UrgencyLow    = "low"
+	UrgencyNormal = "normal"
+	UrgencyHigh   = "high"
+)
+
+// maxTopic is the longest Topic header push services accept
+const maxTopic = 32
+
+// ErrGone means the push service no longer knows the subscription; it answers 404 or
+// 410 and the subscription should be removed
+var ErrGone = errors.New("push subscription is gone")
+
+// Message is one push to one subscription
+type Message struct {
+	Payload []byte
+	// TTL is how long the push service keeps the message for an offline browser
+	TTL     time.Duration
+	Urgency string
+	// Topic makes a later message replace an undelivered earlier one
+	Topic string
+}
+
+// Sender encrypts and posts messages to push services
+type Sender struct {
+	VAPID *VAPID
+	HTTP  *http.Client
+}
+
+// NewSender returns a sender signing with the given VAPID key pair
+func NewSender(vapid *VAPID) *Sender {
+	return &Sender{VAPID: vapid, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Send delivers a message. It returns ErrGone when the subscription has expired or
+// been revoked.
+func (s *Sender) Send(sub Subscription, msg Message) error {
+	body, err := Encrypt(msg.Payload, sub.P256dh, sub.Auth)
+	if err != nil {
+		return err
+	}
+	auth, err := s.VAPID.Authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(msg.TTL.Seconds())))
+	if msg.Urgency != "" {
+		req.Header.Set("Urgency", msg.Urgency)
+	}
+	if msg.Topic != "" {
+		if len(msg.Topic) > maxTopic {
+			return fmt.Errorf("topic %q is longer than %d characters", msg.Topic, maxTopic)
+		}
+		req.Header.Set("Topic", msg.Topic)
+	}
+
+	res, err := s.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("send web push: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrGone
+	}
+	detail, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("push service rejected message: %s %s", res.Status, bytes.TrimSpace(detail))
+}
@@ -0,0 +1,94 @@
+package webpush
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/signal-k/notifs/internal/pbclient"
+)
+
+// Subscription is a browser PushSubscription stored for a user
+type Subscription struct {
+	ID       string
+	User     string
+	Endpoint string
+	P256dh   string
+	Auth     string
+	// Expires is the subscription's expirationTime; zero when the browser set none
+	Expires time.Time
+}
+
+// Expired reports whether the push service will no longer accept the subscription
+func (s Subscription) Expired(now time.Time) bool {
+	return !s.Expires.IsZero() && !now.Before(s.Expires)
+}
+
+// RegisterSubscription stores a browser subscription for a user, keyed by endpoint.
+// expirationTime is the PushSubscription's value in milliseconds, or 0 for none.
+func RegisterSubscription(client *pbclient.Client, user, endpoint, p256dh, auth string, expirationTime int64) (string, error) {
+	data := map[string]interface{}{
+		"user":     user,
+		"endpoint": endpoint,
+		"p256dh":   p256dh,
+		"auth":     auth,
+		"expires":  "",
+	}
+	if expirationTime > 0 {
+		data["expires"] = time.UnixMilli(expirationTime).UTC().Format(time.RFC3339)
+	}
+
+	existing, err := client.QueryRecords("web_push_subscriptions", fmt.Sprintf(`endpoint="%s"`, endpoint), "", "", 1)
+	if err != nil {
+		return "", err
+	}
+	if len(existing) > 0 {
+		id, _ := existing[0]["id"].(string)
+		return id, client.UpdateRecord("web_push_subscriptions", id, data)
+	}
+
+	created, err := client.CreateRecord("web_push_subscriptions", data)
+	if err != nil {
+		return "", err
+	}
+	id, _ := (*created)["id"].(string)
+	if id == "" {
+		return "", fmt.Errorf("register web push subscription: %v", (*created)["message"])
+	}
+	return id, nil
+}
+
+// UserSubscriptions returns every browser subscription of a user
+func UserSubscriptions(client *pbclient.Client, user string) ([]Subscription, error) {
+	records, err := client.ListAllRecords("web_push_subscriptions", fmt.Sprintf(`user="%s"`, user), "")
+	if err != nil {
+		return nil, fmt.Errorf("list web push subscriptions: %w", err)
+	}
+
+	subscriptions := make([]Subscription, 0, len(records))
+	for _, record := range records {
+		sub := Subscription{}
+		sub.ID, _ = record["id"].(string)
+		sub.User, _ = record["user"].(string)
+		sub.Endpoint, _ = record["endpoint"].(string)
+		sub.P256dh, _ = record["p256dh"].(string)
+		sub.Auth, _ = record["auth"].(string)
+		sub.Expires = parseTime(record["expires"])
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions, nil
+}
+
+// RemoveSubscription deletes a subscription that expired or the push service dropped
+func RemoveSubscription(client *pbclient.Client, id string) error {
+	return client.DeleteRecord("web_push_subscriptions", id)
+}
+
+func parseTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.000Z", "2006-01-02 15:04:05Z"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
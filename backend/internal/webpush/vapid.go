@@ -0,0 +1,99 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// vapidLifetime is how long a VAPID token is valid. RFC 8292 caps it at 24 hours.
+const vapidLifetime = 12 * time.Hour
+
+// VAPID identifies this server to push services. The public key is the
+// applicationServerKey the browser subscribed with.
+type VAPID struct {
+	// Subject is a mailto: or https: contact for the push service operator
+	Subject   string
+	PublicKey string
+	key       *ecdsa.PrivateKey
+}
+
+// NewVAPID loads a key pair in the base64url form GenerateVAPIDKeys prints
+func NewVAPID(subject, publicKey, privateKey string) (*VAPID, error) {
+	raw, err := decodeKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("VAPID private key: %w", err)
+	}
+	private, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("VAPID private key: %w", err)
+	}
+
+	public := private.PublicKey().Bytes()
+	if publicKey != "" && base64.RawURLEncoding.EncodeToString(public) != strings.TrimRight(publicKey, "=") {
+		return nil, fmt.Errorf("VAPID public key doesn't match the private key")
+	}
+
+	// The uncompressed public key is 0x04 || X || Y
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(public[1:33]),
+			Y:     new(big.Int).SetBytes(public[33:]),
+		},
+		D: new(big.Int).SetBytes(raw),
+	}
+
+	return &VAPID{
+		Subject:   subject,
+		PublicKey: base64.RawURLEncoding.EncodeToString(public),
+		key:       key,
+	}, nil
+}
+
+// GenerateVAPIDKeys returns a new base64url encoded public and private key pair
+func GenerateVAPIDKeys() (string, string, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// Authorization returns the Authorization header value for a push to endpoint
+func (v *VAPID) Authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidLifetime).Unix(),
+		"sub": v.Subject,
+	})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, v.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign VAPID token: %w", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, v.PublicKey), nil
+}
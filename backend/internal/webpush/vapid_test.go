@@ -0,0 +1,130 @@
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The Authorization header carries a JWT the push service verifies against the
+// applicationServerKey (RFC 8292 sections 2 and 3)
+func TestVAPIDAuthorizationRoundTrip(t *testing.T) {
+	public, private, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vapid, err := NewVAPID("mailto:ops@station98.app", public, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	header, err := vapid.Authorization("https://fcm.googleapis.com/fcm/send/abc123?x=1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, ok := strings.CutPrefix(header, "vapid ")
+	if !ok {
+		t.Fatalf("Authorization = %q, want the vapid scheme", header)
+	}
+	var token, key string
+	for _, param := range strings.Split(params, ", ") {
+		name, value, _ := strings.Cut(param, "=")
+		switch name {
+		case "t":
+			token = value
+		case "k":
+			key = value
+		}
+	}
+	if key != public {
+		t.Errorf("k = %q, want the public key %q", key, public)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token %q isn't a JWS", token)
+	}
+	var jwtHeader map[string]string
+	decodeJSON(t, parts[0], &jwtHeader)
+	if jwtHeader["alg"] != "ES256" || jwtHeader["typ"] != "JWT" {
+		t.Errorf("JWT header = %v", jwtHeader)
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	decodeJSON(t, parts[1], &claims)
+	if claims.Aud != "https://fcm.googleapis.com" {
+		t.Errorf("aud = %q, want the push service origin", claims.Aud)
+	}
+	if exp := time.Unix(claims.Exp, 0); !exp.After(now) || exp.Sub(now) > 24*time.Hour {
+		t.Errorf("exp = %s, want within 24 hours of %s", exp, now)
+	}
+	if claims.Sub != "mailto:ops@station98.app" {
+		t.Errorf("sub = %q", claims.Sub)
+	}
+
+	// The push service's side: verify the signature with the k parameter
+	keyBytes, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil || len(keyBytes) != 65 {
+		t.Fatalf("k isn't an uncompressed P-256 point: %v", err)
+	}
+	verifier := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(keyBytes[1:33]),
+		Y:     new(big.Int).SetBytes(keyBytes[33:]),
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("signature isn't a 64 byte ES256 signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(verifier, digest[:], r, s) {
+		t.Error("the VAPID signature doesn't verify")
+	}
+}
+
+func TestNewVAPIDRejectsMismatchedKeys(t *testing.T) {
+	_, private, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVAPID("mailto:ops@station98.app", other, private); err == nil {
+		t.Error("accepted a public key from another pair")
+	}
+
+	// Without a public key it is derived from the private key
+	vapid, err := NewVAPID("mailto:ops@station98.app", "", private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vapid.PublicKey) != 87 {
+		t.Errorf("derived public key %q isn't 65 bytes", vapid.PublicKey)
+	}
+}
+
+func decodeJSON(t *testing.T, segment string, v any) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
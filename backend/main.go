@@ -1,15 +1,23 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"time"
 
+	"github.com/signal-k/notifs/internal/cli"
 	"github.com/signal-k/notifs/internal/config"
 	"github.com/signal-k/notifs/internal/pbclient"
 	"github.com/signal-k/notifs/internal/sync"
 )
 
 func main() {
+	opts := cli.Flags()
+	flag.Parse()
+	if err := opts.Install(); err != nil {
+		log.Fatal(err)
+	}
+
 	log.Println("🚀 Go starting...")
 
 	cfg := config.Load()
@@ -32,6 +40,16 @@ func main() {
 		log.Fatalf("Admin login failed after retries: %v", err)
 	}
 
+	// A dry run plans a single pass and exits
+	if opts.DryRun.Enabled {
+		if _, err := sync.SyncLaunchesOnce(client, 0); err != nil {
+			log.Printf("❌ Launches: %v", err)
+		}
+		opts.Report()
+		return
+	}
+	defer opts.Report()
+
 	go func() {
 		for {
 			sync.SyncLaunchProvidersAndEvents(client)
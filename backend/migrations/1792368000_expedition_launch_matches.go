@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("expeditions")
+		if err != nil {
+			return err
+		}
+
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		// Launches that started or ended the expedition
+		collection.Fields.Add(
+			&core.RelationField{
+				Name:         "start_launches",
+				CollectionId: events.Id,
+				MaxSelect:    10,
+			},
+			&core.RelationField{
+				Name:         "end_launches",
+				CollectionId: events.Id,
+				MaxSelect:    10,
+			},
+		)
+
+		// Raw match details, including launches not yet present in events
+		collection.Fields.Add(
+			&core.JSONField{
+				Name: "launch_matches",
+			},
+			&core.BoolField{
+				Name: "launch_match_review",
+			},
+		)
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("expeditions")
+		if err != nil {
+			return err
+		}
+
+		for _, fieldName := range []string{"start_launches", "end_launches", "launch_matches", "launch_match_review"} {
+			collection.Fields.RemoveByName(fieldName)
+		}
+
+		return app.Save(collection)
+	})
+}
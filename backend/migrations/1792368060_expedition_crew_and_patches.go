@@ -0,0 +1,147 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		expeditions, err := app.FindCollectionByNameOrId("expeditions")
+		if err != nil {
+			return err
+		}
+
+		astronauts, err := app.FindCollectionByNameOrId("astronauts")
+		if err != nil {
+			return err
+		}
+
+		agencies, err := app.FindCollectionByNameOrId("agencies")
+		if err != nil {
+			return err
+		}
+
+		// SpaceDevs IDs so expeditions and astronauts can be upserted and resolved reliably
+		for _, collection := range []*core.Collection{expeditions, astronauts} {
+			if collection.Fields.GetByName("api_id") == nil {
+				collection.Fields.Add(&core.NumberField{
+					Name:    "api_id",
+					OnlyInt: true,
+				})
+			}
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		// Mission patches with their agencies
+		patches := core.NewBaseCollection("mission_patches")
+		patches.Fields.Add(
+			&core.NumberField{
+				Name:     "api_id",
+				Required: true,
+				OnlyInt:  true,
+			},
+			&core.TextField{
+				Name: "name",
+				Max:  200,
+			},
+			&core.NumberField{
+				Name: "priority",
+				Min:  types.Pointer(0.0),
+			},
+			&core.URLField{
+				Name: "image_url",
+			},
+			&core.RelationField{
+				Name:         "agency",
+				CollectionId: agencies.Id,
+				MaxSelect:    1,
+			},
+			&core.TextField{
+				Name: "agency_name",
+				Max:  200,
+			},
+			&core.TextField{
+				Name: "agency_abbrev",
+				Max:  50,
+			},
+		)
+		patches.AddIndex("idx_mission_patches_api_id", true, "api_id", "")
+		if err := app.Save(patches); err != nil {
+			return err
+		}
+
+		expeditions.Fields.Add(
+			&core.RelationField{
+				Name:         "mission_patches",
+				CollectionId: patches.Id,
+				MaxSelect:    20,
+			},
+		)
+		if err := app.Save(expeditions); err != nil {
+			return err
+		}
+
+		// One record per crew member with their expedition role
+		crew := core.NewBaseCollection("expedition_crew")
+		crew.Fields.Add(
+			&core.NumberField{
+				Name:     "api_id",
+				Required: true,
+				OnlyInt:  true,
+			},
+			&core.RelationField{
+				Name:          "expedition",
+				CollectionId:  expeditions.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:         "astronaut",
+				CollectionId: astronauts.Id,
+				MaxSelect:    1,
+			},
+			&core.NumberField{
+				Name:    "astronaut_api_id",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "astronaut_name",
+				Max:  200,
+			},
+			&core.TextField{
+				Name: "role",
+				Max:  100,
+			},
+			&core.NumberField{
+				Name: "role_priority",
+				Min:  types.Pointer(0.0),
+			},
+		)
+		crew.AddIndex("idx_expedition_crew_api_id", true, "api_id", "")
+
+		return app.Save(crew)
+	}, func(app core.App) error {
+		for _, name := range []string{"expedition_crew", "mission_patches"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+
+		expeditions, err := app.FindCollectionByNameOrId("expeditions")
+		if err != nil {
+			return err
+		}
+		expeditions.Fields.RemoveByName("mission_patches")
+
+		return app.Save(expeditions)
+	})
+}
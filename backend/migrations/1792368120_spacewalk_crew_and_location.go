@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		spacewalks, err := app.FindCollectionByNameOrId("spacewalks")
+		if err != nil {
+			return err
+		}
+
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		astronauts, err := app.FindCollectionByNameOrId("astronauts")
+		if err != nil {
+			return err
+		}
+
+		stations, err := app.FindCollectionByNameOrId("stations")
+		if err != nil {
+			return err
+		}
+
+		// Launch Library event ID, used to relate non-launch records to events
+		if events.Fields.GetByName("event_api_id") == nil {
+			events.Fields.Add(&core.NumberField{
+				Name:    "event_api_id",
+				OnlyInt: true,
+			})
+			if err := app.Save(events); err != nil {
+				return err
+			}
+		}
+
+		// EVA crew and location
+		spacewalks.Fields.Add(
+			&core.RelationField{
+				Name:         "crew",
+				CollectionId: astronauts.Id,
+				MaxSelect:    10,
+			},
+			&core.RelationField{
+				Name:         "station",
+				CollectionId: stations.Id,
+				MaxSelect:    1,
+			},
+			&core.NumberField{
+				Name:    "spacecraft_flight_api_id",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "spacecraft_name",
+				Max:  200,
+			},
+			&core.NumberField{
+				Name:    "duration_seconds",
+				Min:     types.Pointer(0.0),
+				OnlyInt: true,
+			},
+		)
+
+		// Parent relations that may be resolved on a later run
+		spacewalks.Fields.Add(
+			&core.RelationField{
+				Name:         "event",
+				CollectionId: events.Id,
+				MaxSelect:    1,
+			},
+			&core.NumberField{
+				Name:    "expedition_api_id",
+				OnlyInt: true,
+			},
+			&core.BoolField{
+				Name: "pending_relations",
+			},
+		)
+
+		return app.Save(spacewalks)
+	}, func(app core.App) error {
+		spacewalks, err := app.FindCollectionByNameOrId("spacewalks")
+		if err != nil {
+			return err
+		}
+
+		fieldsToRemove := []string{
+			"crew", "station", "spacecraft_flight_api_id", "spacecraft_name",
+			"duration_seconds", "event", "expedition_api_id", "pending_relations",
+		}
+		for _, fieldName := range fieldsToRemove {
+			spacewalks.Fields.RemoveByName(fieldName)
+		}
+
+		return app.Save(spacewalks)
+	})
+}
@@ -0,0 +1,115 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		stations, err := app.FindCollectionByNameOrId("stations")
+		if err != nil {
+			return err
+		}
+
+		dockingLocations, err := app.FindCollectionByNameOrId("docking_locations")
+		if err != nil {
+			return err
+		}
+
+		dockingEvents, err := app.FindCollectionByNameOrId("docking_events")
+		if err != nil {
+			return err
+		}
+
+		// Spacecraft flights, populated from the flights embedded in docking events
+		flights := core.NewBaseCollection("spacecraft_flights")
+		flights.Fields.Add(
+			&core.NumberField{
+				Name:     "api_id",
+				Required: true,
+				OnlyInt:  true,
+			},
+			&core.NumberField{
+				Name:    "spacecraft_api_id",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "spacecraft_name",
+				Max:  200,
+			},
+			&core.TextField{
+				Name: "destination",
+				Max:  200,
+			},
+			&core.DateField{
+				Name: "mission_end",
+			},
+			&core.TextField{
+				Name: "launch_api_id",
+				Max:  64,
+			},
+			&core.TextField{
+				Name: "launch_name",
+				Max:  255,
+			},
+			&core.RelationField{
+				Name:         "launch_event",
+				CollectionId: events.Id,
+				MaxSelect:    1,
+			},
+		)
+		flights.AddIndex("idx_spacecraft_flights_api_id", true, "api_id", "")
+		if err := app.Save(flights); err != nil {
+			return err
+		}
+
+		dockingEvents.Fields.Add(
+			&core.RelationField{
+				Name:         "station",
+				CollectionId: stations.Id,
+				MaxSelect:    1,
+			},
+			&core.RelationField{
+				Name:         "docking_location",
+				CollectionId: dockingLocations.Id,
+				MaxSelect:    1,
+			},
+			&core.RelationField{
+				Name:         "chaser_spacecraft_flight",
+				CollectionId: flights.Id,
+				MaxSelect:    1,
+			},
+			&core.RelationField{
+				Name:         "chaser_launch_event",
+				CollectionId: events.Id,
+				MaxSelect:    1,
+			},
+		)
+
+		return app.Save(dockingEvents)
+	}, func(app core.App) error {
+		dockingEvents, err := app.FindCollectionByNameOrId("docking_events")
+		if err != nil {
+			return err
+		}
+
+		for _, fieldName := range []string{"station", "docking_location", "chaser_spacecraft_flight", "chaser_launch_event"} {
+			dockingEvents.Fields.RemoveByName(fieldName)
+		}
+		if err := app.Save(dockingEvents); err != nil {
+			return err
+		}
+
+		flights, err := app.FindCollectionByNameOrId("spacecraft_flights")
+		if err != nil {
+			return err
+		}
+		return app.Delete(flights)
+	})
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Read-only view of the vehicles docked right now, for the app.
+		// Historical lookups go through utils.StationOccupancy instead.
+		view := core.NewViewCollection("station_occupancy")
+		view.ViewQuery = `
+			SELECT
+				de.id,
+				de.station,
+				de.docking_location,
+				COALESCE(NULLIF(dl.name, ''), de.location_name) AS port_name,
+				COALESCE(NULLIF(sf.spacecraft_name, ''), NULLIF(de.chaser_payload_name, ''), de.chaser_launch_name) AS vehicle_name,
+				de.chaser_spacecraft_flight,
+				de.chaser_launch_event,
+				de.docking_time AS docked_at,
+				de.departure_time AS expected_departure
+			FROM docking_events de
+			LEFT JOIN docking_locations dl ON dl.id = de.docking_location
+			LEFT JOIN spacecraft_flights sf ON sf.id = de.chaser_spacecraft_flight
+			WHERE datetime(de.docking_time) <= datetime('now')
+				AND (de.departure_time = '' OR de.departure_time IS NULL OR datetime(de.departure_time) > datetime('now'))
+		`
+		view.ListRule = types.Pointer("")
+		view.ViewRule = types.Pointer("")
+
+		return app.Save(view)
+	}, func(app core.App) error {
+		view, err := app.FindCollectionByNameOrId("station_occupancy")
+		if err != nil {
+			return err
+		}
+		return app.Delete(view)
+	})
+}
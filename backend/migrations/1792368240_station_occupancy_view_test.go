@@ -0,0 +1,104 @@
+package migrations
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func TestStationOccupancyView(t *testing.T) {
+	app := newTestApp(t)
+
+	locations := saveCollection(t, app, "docking_locations", &core.TextField{Name: "name"})
+	flights := saveCollection(t, app, "spacecraft_flights", &core.TextField{Name: "spacecraft_name"})
+	saveCollection(t, app, "docking_events",
+		&core.TextField{Name: "station"},
+		&core.RelationField{Name: "docking_location", CollectionId: locations.Id, MaxSelect: 1},
+		&core.TextField{Name: "location_name"},
+		&core.RelationField{Name: "chaser_spacecraft_flight", CollectionId: flights.Id, MaxSelect: 1},
+		&core.TextField{Name: "chaser_launch_event"},
+		&core.TextField{Name: "chaser_payload_name"},
+		&core.TextField{Name: "chaser_launch_name"},
+		&core.DateField{Name: "docking_time"},
+		&core.DateField{Name: "departure_time"},
+	)
+
+	zenith := saveRecord(t, app, "docking_locations", map[string]any{"name": "Harmony zenith"})
+	endurance := saveRecord(t, app, "spacecraft_flights", map[string]any{"spacecraft_name": "Dragon Endurance"})
+	now := time.Now().UTC()
+	day := 24 * time.Hour
+	at := func(d time.Duration) types.DateTime {
+		dt, _ := types.ParseDateTime(now.Add(d))
+		return dt
+	}
+
+	crew := saveRecord(t, app, "docking_events", map[string]any{
+		"station":                  "iss",
+		"docking_location":         zenith.Id,
+		"location_name":            "Harmony zenith (old name)",
+		"chaser_spacecraft_flight": endurance.Id,
+		"chaser_launch_name":       "Falcon 9 Block 5 | Crew-10",
+		"docking_time":             at(-day),
+	})
+	// A crew handover: the outgoing vehicle left an hour ago
+	saveRecord(t, app, "docking_events", map[string]any{
+		"station":            "iss",
+		"location_name":      "Harmony forward",
+		"chaser_launch_name": "Falcon 9 Block 5 | Crew-9",
+		"docking_time":       at(-170 * day),
+		"departure_time":     at(-time.Hour),
+	})
+	// Cargo with no crew flight, known only by its location name
+	cargo := saveRecord(t, app, "docking_events", map[string]any{
+		"station":             "iss",
+		"location_name":       "Poisk zenith",
+		"chaser_payload_name": "Cygnus NG-21",
+		"chaser_launch_name":  "Falcon 9 Block 5 | NG-21",
+		"docking_time":        at(-200 * day),
+		"departure_time":      at(10 * day),
+	})
+	// Not docked yet
+	saveRecord(t, app, "docking_events", map[string]any{
+		"station":            "iss",
+		"location_name":      "Rassvet nadir",
+		"chaser_launch_name": "Soyuz MS-27",
+		"docking_time":       at(2 * day),
+	})
+
+	migrate(t, app, "1792368240_station_occupancy_view.go", true)
+
+	records, err := app.FindAllRecords("station_occupancy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]*core.Record{}
+	for _, record := range records {
+		got[record.Id] = record
+	}
+	if len(got) != 2 || got[crew.Id] == nil || got[cargo.Id] == nil {
+		t.Fatalf("station_occupancy = %v, want the Crew-10 and Cygnus dockings", got)
+	}
+	for id, want := range map[string][2]string{
+		crew.Id:  {"Harmony zenith", "Dragon Endurance"},
+		cargo.Id: {"Poisk zenith", "Cygnus NG-21"},
+	} {
+		if port, vehicle := viewString(got[id], "port_name"), viewString(got[id], "vehicle_name"); port != want[0] || vehicle != want[1] {
+			t.Errorf("%s = %s / %s, want %s / %s", id, port, vehicle, want[0], want[1])
+		}
+	}
+
+	migrate(t, app, "1792368240_station_occupancy_view.go", false)
+	if _, err := app.FindCollectionByNameOrId("station_occupancy"); err == nil {
+		t.Error("station_occupancy still exists after rollback")
+	}
+}
+
+// viewString reads a view column computed by an expression, which PocketBase serves as JSON
+func viewString(record *core.Record, field string) string {
+	var s string
+	json.Unmarshal([]byte(record.GetString(field)), &s)
+	return s
+}
@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		payloads, err := app.FindCollectionByNameOrId("payloads")
+		if err != nil {
+			return err
+		}
+
+		// One record per payload carried on a launch
+		flights := core.NewBaseCollection("payload_flights")
+		flights.Fields.Add(
+			&core.NumberField{
+				Name:     "api_id",
+				Required: true,
+				OnlyInt:  true,
+			},
+			&core.URLField{
+				Name: "url",
+			},
+			&core.RelationField{
+				Name:         "payload",
+				CollectionId: payloads.Id,
+				MaxSelect:    1,
+			},
+			&core.TextField{
+				Name: "payload_name",
+				Max:  255,
+			},
+			&core.RelationField{
+				Name:         "launch_event",
+				CollectionId: events.Id,
+				MaxSelect:    1,
+			},
+			&core.TextField{
+				Name: "launch_api_id",
+				Max:  64,
+			},
+			&core.TextField{
+				Name: "launch_name",
+				Max:  255,
+			},
+			&core.TextField{
+				Name: "destination",
+				Max:  200,
+			},
+			&core.TextField{
+				Name: "deployment_status",
+				Max:  100,
+			},
+			&core.NumberField{
+				Name:    "amount",
+				Min:     types.Pointer(0.0),
+				OnlyInt: true,
+			},
+		)
+		flights.AddIndex("idx_payload_flights_api_id", true, "api_id", "")
+		if err := app.Save(flights); err != nil {
+			return err
+		}
+
+		// Rideshare manifest
+		events.Fields.Add(
+			&core.RelationField{
+				Name:         "payload_flights",
+				CollectionId: flights.Id,
+				MaxSelect:    200,
+			},
+		)
+
+		return app.Save(events)
+	}, func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+		events.Fields.RemoveByName("payload_flights")
+		if err := app.Save(events); err != nil {
+			return err
+		}
+
+		flights, err := app.FindCollectionByNameOrId("payload_flights")
+		if err != nil {
+			return err
+		}
+		return app.Delete(flights)
+	})
+}
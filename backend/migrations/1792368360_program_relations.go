@@ -0,0 +1,198 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		programs, err := app.FindCollectionByNameOrId("programs")
+		if err != nil {
+			return err
+		}
+
+		agencies, err := app.FindCollectionByNameOrId("agencies")
+		if err != nil {
+			return err
+		}
+
+		patches, err := app.FindCollectionByNameOrId("mission_patches")
+		if err != nil {
+			return err
+		}
+
+		// Program agencies and patches
+		programs.Fields.Add(
+			&core.RelationField{
+				Name:         "agencies",
+				CollectionId: agencies.Id,
+				MaxSelect:    50,
+			},
+			&core.RelationField{
+				Name:         "mission_patches",
+				CollectionId: patches.Id,
+				MaxSelect:    50,
+			},
+		)
+		if err := app.Save(programs); err != nil {
+			return err
+		}
+
+		// Everything a program can be attached to
+		for _, name := range []string{"events", "missions", "rockets", "payloads"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.Fields.Add(&core.RelationField{
+				Name:         "programs",
+				CollectionId: programs.Id,
+				MaxSelect:    20,
+			})
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		// The joined program strings on events are replaced by the relation. Stored
+		// names are matched to program records; a name no program matches gets a
+		// minimal record from the joined strings, which SyncPrograms fills in later.
+		programRecords, err := app.FindAllRecords(programs)
+		if err != nil {
+			return err
+		}
+		programByName := make(map[string]string, len(programRecords))
+		for _, program := range programRecords {
+			programByName[strings.ToLower(strings.TrimSpace(program.GetString("name")))] = program.Id
+		}
+
+		eventRecords, err := app.FindAllRecords("events")
+		if err != nil {
+			return err
+		}
+		for _, event := range eventRecords {
+			names := event.GetString("program_names")
+			if names == "" {
+				continue
+			}
+			descriptions := strings.Split(event.GetString("program_descriptions"), " | ")
+			imageURLs := strings.Split(event.GetString("program_image_urls"), ", ")
+
+			ids := []string{}
+			for i, name := range strings.Split(names, ", ") {
+				key := strings.ToLower(strings.TrimSpace(name))
+				if key == "" {
+					continue
+				}
+				id, ok := programByName[key]
+				if !ok {
+					program := core.NewRecord(programs)
+					program.Set("name", strings.TrimSpace(name))
+					if i < len(descriptions) {
+						program.Set("description", descriptions[i])
+					}
+					if i < len(imageURLs) {
+						program.Set("image_url", imageURLs[i])
+					}
+					if err := app.Save(program); err != nil {
+						return err
+					}
+					id = program.Id
+					programByName[key] = id
+				}
+				ids = append(ids, id)
+			}
+			event.Set("programs", ids)
+			if err := app.Save(event); err != nil {
+				return err
+			}
+		}
+
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+		for _, fieldName := range []string{"program_names", "program_descriptions", "program_image_urls"} {
+			events.Fields.RemoveByName(fieldName)
+		}
+		return app.Save(events)
+	}, func(app core.App) error {
+		// Restore the joined program strings from the relation before dropping it
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+		if events.Fields.GetByName("program_names") == nil {
+			events.Fields.Add(
+				&core.TextField{
+					Name: "program_names",
+					Max:  500,
+				},
+				&core.TextField{
+					Name: "program_descriptions",
+					Max:  2000,
+				},
+				&core.TextField{
+					Name: "program_image_urls",
+					Max:  1000,
+				},
+			)
+			if err := app.Save(events); err != nil {
+				return err
+			}
+		}
+
+		eventRecords, err := app.FindAllRecords("events")
+		if err != nil {
+			return err
+		}
+		for _, event := range eventRecords {
+			programIDs := event.GetStringSlice("programs")
+			if len(programIDs) == 0 {
+				continue
+			}
+			programRecords, err := app.FindRecordsByIds("programs", programIDs)
+			if err != nil {
+				return err
+			}
+
+			var names, descriptions, imageURLs []string
+			for _, program := range programRecords {
+				names = append(names, program.GetString("name"))
+				descriptions = append(descriptions, program.GetString("description"))
+				imageURLs = append(imageURLs, program.GetString("image_url"))
+			}
+			event.Set("program_names", strings.Join(names, ", "))
+			event.Set("program_descriptions", strings.Join(descriptions, " | "))
+			event.Set("program_image_urls", strings.Join(imageURLs, ", "))
+			if err := app.Save(event); err != nil {
+				return err
+			}
+		}
+
+		for _, name := range []string{"events", "missions", "rockets", "payloads"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.Fields.RemoveByName("programs")
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		programs, err := app.FindCollectionByNameOrId("programs")
+		if err != nil {
+			return err
+		}
+		programs.Fields.RemoveByName("agencies")
+		programs.Fields.RemoveByName("mission_patches")
+
+		return app.Save(programs)
+	})
+}
@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const programRelations = "1792368360_program_relations.go"
+
+func TestProgramRelations(t *testing.T) {
+	app := newTestApp(t)
+
+	saveCollection(t, app, "programs",
+		&core.NumberField{Name: "api_id", OnlyInt: true},
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "description"},
+		&core.URLField{Name: "image_url"},
+	)
+	saveCollection(t, app, "agencies", &core.TextField{Name: "name"})
+	saveCollection(t, app, "mission_patches", &core.TextField{Name: "name"})
+	saveCollection(t, app, "events",
+		&core.TextField{Name: "title"},
+		&core.TextField{Name: "program_names"},
+		&core.TextField{Name: "program_descriptions"},
+		&core.TextField{Name: "program_image_urls"},
+	)
+	for _, name := range []string{"missions", "rockets", "payloads"} {
+		saveCollection(t, app, name, &core.TextField{Name: "name"})
+	}
+
+	iss := saveRecord(t, app, "programs", map[string]any{"api_id": 17, "name": "International Space Station"})
+	crew10 := saveRecord(t, app, "events", map[string]any{
+		"title":                "Crew-10",
+		"program_names":        "International Space Station, Commercial Crew Program",
+		"program_descriptions": "The ISS | NASA's crew program",
+		"program_image_urls":   "https://example.com/iss.png, https://example.com/ccp.png",
+	})
+	starlink := saveRecord(t, app, "events", map[string]any{"title": "Starlink 12-1"})
+
+	migrate(t, app, programRelations, true)
+
+	// The legacy fields go whether or not every name matched a program
+	events, err := app.FindCollectionByNameOrId("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"program_names", "program_descriptions", "program_image_urls"} {
+		if events.Fields.GetByName(field) != nil {
+			t.Errorf("events.%s wasn't dropped", field)
+		}
+	}
+
+	ccp, err := app.FindFirstRecordByData("programs", "name", "Commercial Crew Program")
+	if err != nil {
+		t.Fatal("no program was created for the unmatched name")
+	}
+	if ccp.GetString("description") != "NASA's crew program" || ccp.GetString("image_url") != "https://example.com/ccp.png" {
+		t.Errorf("created program = %v", ccp.FieldsData())
+	}
+	if got := findRecord(t, app, "events", crew10.Id).GetStringSlice("programs"); !slices.Equal(got, []string{iss.Id, ccp.Id}) {
+		t.Errorf("Crew-10 programs = %v, want ISS and Commercial Crew", got)
+	}
+	if got := findRecord(t, app, "events", starlink.Id).GetStringSlice("programs"); len(got) != 0 {
+		t.Errorf("Starlink programs = %v, want none", got)
+	}
+
+	migrate(t, app, programRelations, false)
+
+	event := findRecord(t, app, "events", crew10.Id)
+	if got := event.GetString("program_names"); got != "International Space Station, Commercial Crew Program" {
+		t.Errorf("program_names = %q after rollback", got)
+	}
+	if events, _ := app.FindCollectionByNameOrId("events"); events.Fields.GetByName("programs") != nil {
+		t.Error("events.programs wasn't dropped on rollback")
+	}
+}
@@ -0,0 +1,388 @@
+package migrations
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type agencyField struct{ collection, field string }
+
+// agencyRelations are the fields that point at agencies once launch providers are merged
+var agencyRelations = []agencyField{
+	{"events", "provider"},
+	{"astronauts", "agency"},
+	{"payloads", "manufacturer"},
+	{"payloads", "operator"},
+	{"rockets", "manufacturer_agency"},
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		agencies, err := app.FindCollectionByNameOrId("agencies")
+		if err != nil {
+			return err
+		}
+
+		// Fields only the launch provider records carried, plus the full country list
+		newFields := []core.Field{
+			&core.BoolField{Name: "launch_provider"},
+			&core.URLField{Name: "wiki_url"},
+			&core.URLField{Name: "info_url"},
+			&core.JSONField{Name: "countries"},
+		}
+		for _, field := range newFields {
+			if agencies.Fields.GetByName(field.GetName()) == nil {
+				agencies.Fields.Add(field)
+			}
+		}
+		if err := app.Save(agencies); err != nil {
+			return err
+		}
+
+		lookup, err := newAgencyLookup(app, agencies)
+		if err != nil {
+			return err
+		}
+
+		// Copy every launch provider into agencies, keyed by SpaceDevs ID. Existing
+		// agencies keep their values and only have the blanks filled in.
+		providers, err := app.FindAllRecords("launch_providers")
+		if err != nil {
+			return err
+		}
+
+		providerToAgency := make(map[string]string, len(providers))
+		for _, provider := range providers {
+			agency, err := app.FindFirstRecordByFilter("agencies", "api_id = {:id}", dbx.Params{"id": provider.GetInt("spacedevs_id")})
+			if err != nil {
+				agency = core.NewRecord(agencies)
+				agency.Set("api_id", provider.GetInt("spacedevs_id"))
+			}
+			foundingYear, _ := strconv.Atoi(provider.GetString("founding_year"))
+			fill := map[string]any{
+				"name":          provider.GetString("name"),
+				"abbrev":        provider.GetString("abbrev"),
+				"type_name":     provider.GetString("type"),
+				"country_code":  provider.GetString("country_code"),
+				"description":   provider.GetString("description"),
+				"founding_year": foundingYear,
+				"logo_url":      provider.GetString("logo_url"),
+				"image_url":     provider.GetString("image_url"),
+				"wiki_url":      provider.GetString("wiki_url"),
+				"info_url":      provider.GetString("info_url"),
+			}
+			for key, value := range fill {
+				if agency.GetString(key) == "" || agency.GetString(key) == "0" {
+					agency.Set(key, value)
+				}
+			}
+			agency.Set("launch_provider", true)
+			if err := app.Save(agency); err != nil {
+				return err
+			}
+			providerToAgency[provider.Id] = agency.Id
+			lookup.add(agency)
+		}
+
+		launchProviders, err := app.FindCollectionByNameOrId("launch_providers")
+		if err != nil {
+			return err
+		}
+
+		// Every relation that pointed at launch providers, plus the agency references
+		// other collections kept as text, now points at agencies
+		fields := append([]agencyField{}, agencyRelations...)
+		collections, err := app.FindAllCollections()
+		if err != nil {
+			return err
+		}
+		for _, collection := range collections {
+			for _, field := range collection.Fields {
+				if relation, ok := field.(*core.RelationField); ok && relation.CollectionId == launchProviders.Id {
+					fields = append(fields, agencyField{collection.Name, relation.Name})
+				}
+			}
+		}
+		for _, f := range fields {
+			if err := relateToAgencies(app, f.collection, f.field, agencies, lookup, providerToAgency); err != nil {
+				return err
+			}
+		}
+
+		return app.Delete(launchProviders)
+	}, func(app core.App) error {
+		agencies, err := app.FindCollectionByNameOrId("agencies")
+		if err != nil {
+			return err
+		}
+
+		// Split the launch providers back out of agencies
+		providers := core.NewBaseCollection("launch_providers")
+		providers.ListRule = types.Pointer("")
+		providers.ViewRule = types.Pointer("")
+		providers.Fields.Add(
+			&core.NumberField{
+				Name:    "spacedevs_id",
+				OnlyInt: true,
+			},
+			&core.TextField{Name: "name", Max: 200},
+			&core.TextField{Name: "abbrev", Max: 50},
+			&core.TextField{Name: "type", Max: 100},
+			&core.TextField{Name: "country_code", Max: 100},
+			&core.TextField{Name: "description", Max: 5000},
+			&core.TextField{Name: "founding_year", Max: 10},
+			&core.URLField{Name: "logo_url"},
+			&core.URLField{Name: "image_url"},
+			&core.URLField{Name: "wiki_url"},
+			&core.URLField{Name: "info_url"},
+		)
+		if err := app.Save(providers); err != nil {
+			return err
+		}
+
+		providerAgencies, err := app.FindAllRecords("agencies", dbx.HashExp{"launch_provider": true})
+		if err != nil {
+			return err
+		}
+		agencyToProvider := make(map[string]string, len(providerAgencies))
+		for _, agency := range providerAgencies {
+			provider := core.NewRecord(providers)
+			provider.Set("spacedevs_id", agency.GetInt("api_id"))
+			provider.Set("name", agency.GetString("name"))
+			provider.Set("abbrev", agency.GetString("abbrev"))
+			provider.Set("type", agency.GetString("type_name"))
+			provider.Set("country_code", agency.GetString("country_code"))
+			provider.Set("description", agency.GetString("description"))
+			if year := agency.GetInt("founding_year"); year != 0 {
+				provider.Set("founding_year", strconv.Itoa(year))
+			}
+			provider.Set("logo_url", agency.GetString("logo_url"))
+			provider.Set("image_url", agency.GetString("image_url"))
+			provider.Set("wiki_url", agency.GetString("wiki_url"))
+			provider.Set("info_url", agency.GetString("info_url"))
+			if err := app.Save(provider); err != nil {
+				return err
+			}
+			agencyToProvider[agency.Id] = provider.Id
+		}
+
+		// Events point at launch providers again
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+		eventRecords, err := app.FindAllRecords(events)
+		if err != nil {
+			return err
+		}
+		eventProviders := map[string]string{}
+		for _, event := range eventRecords {
+			if agencyID := event.GetString("provider"); agencyID != "" {
+				eventProviders[event.Id] = agencyToProvider[agencyID]
+			}
+		}
+		if err := replaceRelation(app, events, &core.RelationField{
+			Name:         "provider",
+			CollectionId: providers.Id,
+			MaxSelect:    1,
+		}); err != nil {
+			return err
+		}
+		if err := setRecordValues(app, events, "provider", eventProviders); err != nil {
+			return err
+		}
+
+		// The other references go back to the agency names the syncers stored
+		for _, f := range agencyRelations[1:] {
+			collection, err := app.FindCollectionByNameOrId(f.collection)
+			if err != nil {
+				return err
+			}
+			records, err := app.FindAllRecords(collection)
+			if err != nil {
+				return err
+			}
+			names := make(map[string]string, len(records))
+			for _, record := range records {
+				agencyID := record.GetString(f.field)
+				if agencyID == "" {
+					continue
+				}
+				if agency, err := app.FindRecordById(agencies, agencyID); err == nil {
+					names[record.Id] = agency.GetString("name")
+				}
+			}
+
+			collection.Fields.RemoveByName(f.field)
+			collection.Fields.Add(&core.TextField{
+				Name: f.field,
+				Max:  200,
+			})
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+			if err := setRecordValues(app, collection, f.field, names); err != nil {
+				return err
+			}
+		}
+
+		// The fields added to agencies stay; SyncAgencies fills them
+		return nil
+	})
+}
+
+// agencyLookup resolves the agency references stored before the merge (record IDs,
+// SpaceDevs IDs, names or abbreviations) to agency records
+type agencyLookup struct {
+	app      core.App
+	agencies *core.Collection
+	byKey    map[string]string
+}
+
+func newAgencyLookup(app core.App, agencies *core.Collection) (*agencyLookup, error) {
+	records, err := app.FindAllRecords(agencies)
+	if err != nil {
+		return nil, err
+	}
+	lookup := &agencyLookup{app: app, agencies: agencies, byKey: map[string]string{}}
+	for _, record := range records {
+		lookup.add(record)
+	}
+	return lookup, nil
+}
+
+func (l *agencyLookup) add(agency *core.Record) {
+	l.byKey[agency.Id] = agency.Id
+	if apiID := agency.GetInt("api_id"); apiID != 0 {
+		l.byKey[strconv.Itoa(apiID)] = agency.Id
+	}
+	for _, key := range []string{"name", "abbrev"} {
+		if value := strings.ToLower(strings.TrimSpace(agency.GetString(key))); value != "" {
+			if _, taken := l.byKey[value]; !taken {
+				l.byKey[value] = agency.Id
+			}
+		}
+	}
+}
+
+// resolve returns the agency a stored reference names. A name no agency matches gets
+// a minimal record, as the syncers do for agencies SyncAgencies hasn't stored yet.
+func (l *agencyLookup) resolve(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return "", nil
+	}
+	if id, ok := l.byKey[value]; ok {
+		return id, nil
+	}
+	if id, ok := l.byKey[strings.ToLower(value)]; ok {
+		return id, nil
+	}
+
+	agency := core.NewRecord(l.agencies)
+	agency.Set("name", value)
+	if err := l.app.Save(agency); err != nil {
+		return "", err
+	}
+	l.add(agency)
+	return agency.Id, nil
+}
+
+// relateToAgencies replaces a collection field with a relation to agencies, carrying
+// over the stored values. Relations to launch providers are mapped through the merged
+// records; relations elsewhere and text fields are resolved by ID or name.
+func relateToAgencies(app core.App, collectionName, fieldName string, agencies *core.Collection, lookup *agencyLookup, providerToAgency map[string]string) error {
+	collection, err := app.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return err
+	}
+	records, err := app.FindAllRecords(collection)
+	if err != nil {
+		return err
+	}
+
+	mapped := make(map[string][]string, len(records))
+	maxSelect := 1
+	switch field := collection.Fields.GetByName(fieldName).(type) {
+	case nil:
+	case *core.RelationField:
+		if field.CollectionId == agencies.Id {
+			return nil
+		}
+		maxSelect = field.MaxSelect
+		for _, record := range records {
+			ids := record.GetStringSlice(fieldName)
+			if len(ids) == 0 {
+				continue
+			}
+			// References that no longer resolve are cleared rather than left dangling
+			mapped[record.Id] = []string{}
+			for _, id := range ids {
+				agencyID, ok := providerToAgency[id]
+				if !ok {
+					target, err := app.FindRecordById(field.CollectionId, id)
+					if err != nil {
+						continue
+					}
+					if agencyID, err = lookup.resolve(target.GetString("name")); err != nil {
+						return err
+					}
+				}
+				mapped[record.Id] = append(mapped[record.Id], agencyID)
+			}
+		}
+	default:
+		for _, record := range records {
+			agencyID, err := lookup.resolve(record.GetString(fieldName))
+			if err != nil {
+				return err
+			}
+			if agencyID != "" {
+				mapped[record.Id] = []string{agencyID}
+			}
+		}
+	}
+
+	if err := replaceRelation(app, collection, &core.RelationField{
+		Name:         fieldName,
+		CollectionId: agencies.Id,
+		MaxSelect:    maxSelect,
+	}); err != nil {
+		return err
+	}
+
+	return setRecordValues(app, collection, fieldName, mapped)
+}
+
+// replaceRelation swaps a collection field for the given relation. PocketBase won't
+// point an existing relation at another collection, and a field added under the same
+// name gets the same ID, so the old field is dropped and saved first.
+func replaceRelation(app core.App, collection *core.Collection, relation *core.RelationField) error {
+	collection.Fields.RemoveByName(relation.Name)
+	if err := app.Save(collection); err != nil {
+		return err
+	}
+	collection.Fields.Add(relation)
+	return app.Save(collection)
+}
+
+// setRecordValues stores a value per record ID. The records are loaded again so they
+// carry the collection's new schema.
+func setRecordValues[T any](app core.App, collection *core.Collection, fieldName string, values map[string]T) error {
+	for id, value := range values {
+		record, err := app.FindRecordById(collection, id)
+		if err != nil {
+			return err
+		}
+		record.Set(fieldName, value)
+		if err := app.Save(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
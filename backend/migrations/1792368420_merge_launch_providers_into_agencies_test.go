@@ -0,0 +1,128 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const mergeLaunchProviders = "1792368420_merge_launch_providers_into_agencies.go"
+
+func TestMergeLaunchProvidersIntoAgencies(t *testing.T) {
+	app := newTestApp(t)
+
+	saveCollection(t, app, "agencies",
+		&core.NumberField{Name: "api_id", OnlyInt: true},
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "abbrev"},
+		&core.TextField{Name: "type_name"},
+		&core.TextField{Name: "country_code"},
+		&core.TextField{Name: "description"},
+		&core.NumberField{Name: "founding_year", OnlyInt: true},
+		&core.URLField{Name: "logo_url"},
+		&core.URLField{Name: "image_url"},
+	)
+	providers := saveCollection(t, app, "launch_providers",
+		&core.NumberField{Name: "spacedevs_id", OnlyInt: true},
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "abbrev"},
+		&core.TextField{Name: "type"},
+		&core.TextField{Name: "country_code"},
+		&core.TextField{Name: "description"},
+		&core.TextField{Name: "founding_year"},
+		&core.URLField{Name: "logo_url"},
+		&core.URLField{Name: "image_url"},
+		&core.URLField{Name: "wiki_url"},
+		&core.URLField{Name: "info_url"},
+	)
+	saveCollection(t, app, "events",
+		&core.TextField{Name: "title"},
+		&core.RelationField{Name: "provider", CollectionId: providers.Id, MaxSelect: 1},
+	)
+	saveCollection(t, app, "astronauts", &core.TextField{Name: "name"}, &core.TextField{Name: "agency"})
+	saveCollection(t, app, "payloads", &core.TextField{Name: "manufacturer"}, &core.TextField{Name: "operator"})
+	saveCollection(t, app, "rockets", &core.TextField{Name: "manufacturer_agency"})
+
+	nasa := saveRecord(t, app, "agencies", map[string]any{"api_id": 44, "name": "National Aeronautics and Space Administration", "abbrev": "NASA"})
+	spacexProvider := saveRecord(t, app, "launch_providers", map[string]any{
+		"spacedevs_id":  121,
+		"name":          "SpaceX",
+		"abbrev":        "SpX",
+		"type":          "Commercial",
+		"founding_year": "2002",
+		"wiki_url":      "https://en.wikipedia.org/wiki/SpaceX",
+	})
+	nasaProvider := saveRecord(t, app, "launch_providers", map[string]any{
+		"spacedevs_id": 44,
+		"name":         "National Aeronautics and Space Administration",
+		"info_url":     "https://www.nasa.gov",
+	})
+	crew10 := saveRecord(t, app, "events", map[string]any{"title": "Crew-10", "provider": spacexProvider.Id})
+	artemis := saveRecord(t, app, "events", map[string]any{"title": "Artemis II", "provider": nasaProvider.Id})
+	koch := saveRecord(t, app, "astronauts", map[string]any{"name": "Christina Koch", "agency": "NASA"})
+	kononenko := saveRecord(t, app, "astronauts", map[string]any{"name": "Oleg Kononenko", "agency": "Roscosmos"})
+	dragon := saveRecord(t, app, "payloads", map[string]any{"manufacturer": "spacex", "operator": "44"})
+
+	migrate(t, app, mergeLaunchProviders, true)
+
+	if _, err := app.FindCollectionByNameOrId("launch_providers"); err == nil {
+		t.Error("launch_providers still exists")
+	}
+	for _, f := range agencyRelations {
+		if target := relationTarget(t, app, f.collection, f.field); target != "agencies" {
+			t.Errorf("%s.%s relates to %s, want agencies", f.collection, f.field, target)
+		}
+	}
+
+	spacex, err := app.FindFirstRecordByData("agencies", "api_id", 121)
+	if err != nil {
+		t.Fatal("SpaceX wasn't copied into agencies")
+	}
+	if !spacex.GetBool("launch_provider") || spacex.GetInt("founding_year") != 2002 || spacex.GetString("type_name") != "Commercial" {
+		t.Errorf("SpaceX agency = %v", spacex.FieldsData())
+	}
+	nasa = findRecord(t, app, "agencies", nasa.Id)
+	if !nasa.GetBool("launch_provider") || nasa.GetString("info_url") != "https://www.nasa.gov" || nasa.GetString("abbrev") != "NASA" {
+		t.Errorf("NASA agency = %v", nasa.FieldsData())
+	}
+
+	if got := findRecord(t, app, "events", crew10.Id).GetString("provider"); got != spacex.Id {
+		t.Errorf("Crew-10 provider = %q, want SpaceX %q", got, spacex.Id)
+	}
+	if got := findRecord(t, app, "events", artemis.Id).GetString("provider"); got != nasa.Id {
+		t.Errorf("Artemis II provider = %q, want NASA %q", got, nasa.Id)
+	}
+	if got := findRecord(t, app, "astronauts", koch.Id).GetString("agency"); got != nasa.Id {
+		t.Errorf("Koch agency = %q, want NASA %q", got, nasa.Id)
+	}
+	roscosmos, err := app.FindFirstRecordByData("agencies", "name", "Roscosmos")
+	if err != nil {
+		t.Fatal("no agency was created for Roscosmos")
+	}
+	if got := findRecord(t, app, "astronauts", kononenko.Id).GetString("agency"); got != roscosmos.Id {
+		t.Errorf("Kononenko agency = %q, want Roscosmos %q", got, roscosmos.Id)
+	}
+	payload := findRecord(t, app, "payloads", dragon.Id)
+	if payload.GetString("manufacturer") != spacex.Id || payload.GetString("operator") != nasa.Id {
+		t.Errorf("payload = %v, want SpaceX manufacturer and NASA operator", payload.FieldsData())
+	}
+
+	migrate(t, app, mergeLaunchProviders, false)
+
+	if target := relationTarget(t, app, "events", "provider"); target != "launch_providers" {
+		t.Errorf("events.provider relates to %s, want launch_providers", target)
+	}
+	provider, err := app.FindFirstRecordByData("launch_providers", "spacedevs_id", 121)
+	if err != nil {
+		t.Fatal("SpaceX wasn't split back out of agencies")
+	}
+	if provider.GetString("founding_year") != "2002" || provider.GetString("wiki_url") != "https://en.wikipedia.org/wiki/SpaceX" {
+		t.Errorf("SpaceX provider = %v", provider.FieldsData())
+	}
+	if got := findRecord(t, app, "events", crew10.Id).GetString("provider"); got != provider.Id {
+		t.Errorf("Crew-10 provider = %q, want %q", got, provider.Id)
+	}
+	if got := findRecord(t, app, "astronauts", koch.Id).GetString("agency"); got != nasa.GetString("name") {
+		t.Errorf("Koch agency = %q, want the NASA name", got)
+	}
+}
@@ -0,0 +1,224 @@
+package migrations
+
+import (
+	"strconv"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		pads, err := app.FindCollectionByNameOrId("pads")
+		if err != nil {
+			return err
+		}
+
+		// Launch locations, the level above pads and landing zones
+		locations := core.NewBaseCollection("locations")
+		locations.Fields.Add(
+			&core.NumberField{
+				Name:     "api_id",
+				Required: true,
+				OnlyInt:  true,
+			},
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+				Max:      255,
+			},
+			&core.TextField{
+				Name: "country_code",
+				Max:  10,
+			},
+			&core.TextField{
+				Name: "description",
+				Max:  5000,
+			},
+			&core.URLField{
+				Name: "map_image",
+			},
+			&core.TextField{
+				Name: "timezone",
+				Max:  100,
+			},
+			&core.NumberField{
+				Name: "latitude",
+				Min:  types.Pointer(-90.0),
+				Max:  types.Pointer(90.0),
+			},
+			&core.NumberField{
+				Name: "longitude",
+				Min:  types.Pointer(-180.0),
+				Max:  types.Pointer(180.0),
+			},
+			&core.NumberField{
+				Name:    "total_launch_count",
+				OnlyInt: true,
+			},
+			&core.NumberField{
+				Name:    "total_landing_count",
+				OnlyInt: true,
+			},
+		)
+		locations.AddIndex("idx_locations_api_id", true, "api_id", "")
+		if err := app.Save(locations); err != nil {
+			return err
+		}
+
+		landingZones := core.NewBaseCollection("landing_zones")
+		landingZones.Fields.Add(
+			&core.NumberField{
+				Name:     "api_id",
+				Required: true,
+				OnlyInt:  true,
+			},
+			&core.TextField{
+				Name:     "name",
+				Required: true,
+				Max:      255,
+			},
+			&core.TextField{
+				Name: "abbrev",
+				Max:  50,
+			},
+			&core.TextField{
+				Name: "description",
+				Max:  5000,
+			},
+			&core.NumberField{
+				Name:    "successful_landings",
+				OnlyInt: true,
+			},
+			&core.RelationField{
+				Name:         "location",
+				CollectionId: locations.Id,
+				MaxSelect:    1,
+			},
+		)
+		landingZones.AddIndex("idx_landing_zones_api_id", true, "api_id", "")
+		if err := app.Save(landingZones); err != nil {
+			return err
+		}
+
+		// Pads: numeric coordinates in place of the 2.2 strings
+		padRecords, err := app.FindAllRecords(pads)
+		if err != nil {
+			return err
+		}
+		latitudes := make(map[string]float64, len(padRecords))
+		longitudes := make(map[string]float64, len(padRecords))
+		for _, pad := range padRecords {
+			latitudes[pad.Id], _ = strconv.ParseFloat(pad.GetString("latitude"), 64)
+			longitudes[pad.Id], _ = strconv.ParseFloat(pad.GetString("longitude"), 64)
+		}
+
+		pads.Fields.RemoveByName("latitude")
+		pads.Fields.RemoveByName("longitude")
+		pads.Fields.Add(
+			&core.NumberField{
+				Name: "latitude",
+				Min:  types.Pointer(-90.0),
+				Max:  types.Pointer(90.0),
+			},
+			&core.NumberField{
+				Name: "longitude",
+				Min:  types.Pointer(-180.0),
+				Max:  types.Pointer(180.0),
+			},
+			&core.TextField{
+				Name: "timezone",
+				Max:  100,
+			},
+			&core.RelationField{
+				Name:         "location",
+				CollectionId: locations.Id,
+				MaxSelect:    1,
+			},
+		)
+		if err := app.Save(pads); err != nil {
+			return err
+		}
+
+		if err := setRecordValues(app, pads, "latitude", latitudes); err != nil {
+			return err
+		}
+		if err := setRecordValues(app, pads, "longitude", longitudes); err != nil {
+			return err
+		}
+
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+		events.Fields.Add(&core.RelationField{
+			Name:         "landing_zone",
+			CollectionId: landingZones.Id,
+			MaxSelect:    1,
+		})
+		return app.Save(events)
+	}, func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+		events.Fields.RemoveByName("landing_zone")
+		if err := app.Save(events); err != nil {
+			return err
+		}
+
+		pads, err := app.FindCollectionByNameOrId("pads")
+		if err != nil {
+			return err
+		}
+
+		// Coordinates go back to the strings the 2.2 sync stored
+		padRecords, err := app.FindAllRecords(pads)
+		if err != nil {
+			return err
+		}
+		latitudes := make(map[string]string, len(padRecords))
+		longitudes := make(map[string]string, len(padRecords))
+		for _, pad := range padRecords {
+			if lat, lon := pad.GetFloat("latitude"), pad.GetFloat("longitude"); lat != 0 || lon != 0 {
+				latitudes[pad.Id] = strconv.FormatFloat(lat, 'f', -1, 64)
+				longitudes[pad.Id] = strconv.FormatFloat(lon, 'f', -1, 64)
+			}
+		}
+
+		for _, name := range []string{"latitude", "longitude", "timezone", "location"} {
+			pads.Fields.RemoveByName(name)
+		}
+		pads.Fields.Add(
+			&core.TextField{
+				Name: "latitude",
+				Max:  50,
+			},
+			&core.TextField{
+				Name: "longitude",
+				Max:  50,
+			},
+		)
+		if err := app.Save(pads); err != nil {
+			return err
+		}
+		if err := setRecordValues(app, pads, "latitude", latitudes); err != nil {
+			return err
+		}
+		if err := setRecordValues(app, pads, "longitude", longitudes); err != nil {
+			return err
+		}
+
+		for _, name := range []string{"landing_zones", "locations"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
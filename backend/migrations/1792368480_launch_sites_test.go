@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func TestLaunchSitesKeepsPadCoordinates(t *testing.T) {
+	app := newTestApp(t)
+
+	saveCollection(t, app, "events", &core.TextField{Name: "title"})
+	saveCollection(t, app, "pads",
+		&core.NumberField{Name: "spacedevs_id", OnlyInt: true},
+		&core.TextField{Name: "name"},
+		&core.TextField{Name: "latitude", Max: 50},
+		&core.TextField{Name: "longitude", Max: 50},
+	)
+	lc39a := saveRecord(t, app, "pads", map[string]any{
+		"spacedevs_id": 87,
+		"name":         "Launch Complex 39A",
+		"latitude":     "28.60822681",
+		"longitude":    "-80.60428186",
+	})
+	unknown := saveRecord(t, app, "pads", map[string]any{"spacedevs_id": 1, "name": "Unknown Pad"})
+
+	migrate(t, app, "1792368480_launch_sites.go", true)
+
+	pad := findRecord(t, app, "pads", lc39a.Id)
+	if lat, lon := pad.GetFloat("latitude"), pad.GetFloat("longitude"); lat != 28.60822681 || lon != -80.60428186 {
+		t.Errorf("coordinates = %v, %v after the upgrade", lat, lon)
+	}
+	if target := relationTarget(t, app, "events", "landing_zone"); target != "landing_zones" {
+		t.Errorf("events.landing_zone relates to %s", target)
+	}
+
+	migrate(t, app, "1792368480_launch_sites.go", false)
+
+	pad = findRecord(t, app, "pads", lc39a.Id)
+	if lat, lon := pad.GetString("latitude"), pad.GetString("longitude"); lat != "28.60822681" || lon != "-80.60428186" {
+		t.Errorf("coordinates = %q, %q after rollback", lat, lon)
+	}
+	if lat := findRecord(t, app, "pads", unknown.Id).GetString("latitude"); lat != "" {
+		t.Errorf("pad without coordinates got latitude %q", lat)
+	}
+	for _, name := range []string{"locations", "landing_zones"} {
+		if _, err := app.FindCollectionByNameOrId(name); err == nil {
+			t.Errorf("%s still exists after rollback", name)
+		}
+	}
+}
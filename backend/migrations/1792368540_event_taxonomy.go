@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// eventTypes mirrors sync.EventTypes
+var eventTypes = []string{
+	"rocket_launch",
+	"press_conference",
+	"static_fire",
+	"landing",
+	"moon_landing",
+	"spacewalk",
+	"docking",
+	"test",
+	"other",
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		expeditions, err := app.FindCollectionByNameOrId("expeditions")
+		if err != nil {
+			return err
+		}
+
+		stations, err := app.FindCollectionByNameOrId("stations")
+		if err != nil {
+			return err
+		}
+
+		agencies, err := app.FindCollectionByNameOrId("agencies")
+		if err != nil {
+			return err
+		}
+
+		// Allow the full taxonomy when type is a select rather than free text
+		if field, ok := events.Fields.GetByName("type").(*core.SelectField); ok {
+			for _, value := range eventTypes {
+				if !slices.Contains(field.Values, value) {
+					field.Values = append(field.Values, value)
+				}
+			}
+		}
+
+		events.Fields.Add(
+			&core.TextField{
+				Name: "type_name",
+				Max:  100,
+			},
+			&core.TextField{
+				Name: "slug",
+				Max:  255,
+			},
+			&core.TextField{
+				Name: "date_precision",
+				Max:  50,
+			},
+			&core.TextField{
+				Name: "duration",
+				Max:  50,
+			},
+			&core.URLField{
+				Name: "news_url",
+			},
+			&core.RelationField{
+				Name:         "launches",
+				CollectionId: events.Id,
+				MaxSelect:    20,
+			},
+			&core.RelationField{
+				Name:         "expeditions",
+				CollectionId: expeditions.Id,
+				MaxSelect:    10,
+			},
+			&core.RelationField{
+				Name:         "stations",
+				CollectionId: stations.Id,
+				MaxSelect:    10,
+			},
+			&core.RelationField{
+				Name:         "agencies",
+				CollectionId: agencies.Id,
+				MaxSelect:    20,
+			},
+		)
+		events.AddIndex("idx_events_event_api_id", false, "event_api_id", "")
+		events.AddIndex("idx_events_type", false, "type", "")
+
+		return app.Save(events)
+	}, func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range []string{"type_name", "slug", "date_precision", "duration", "news_url", "launches", "expeditions", "stations", "agencies"} {
+			events.Fields.RemoveByName(name)
+		}
+		events.RemoveIndex("idx_events_event_api_id")
+		events.RemoveIndex("idx_events_type")
+
+		return app.Save(events)
+	})
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		// Launch Library updates, one record per SpaceDevs update
+		updates := core.NewBaseCollection("event_updates")
+		updates.ListRule = types.Pointer("")
+		updates.ViewRule = types.Pointer("")
+		updates.Fields.Add(
+			&core.NumberField{
+				Name:     "api_id",
+				Required: true,
+				OnlyInt:  true,
+			},
+			&core.RelationField{
+				Name:          "event",
+				CollectionId:  events.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.TextField{
+				Name: "author",
+				Max:  255,
+			},
+			&core.TextField{
+				Name: "comment",
+				Max:  5000,
+			},
+			&core.URLField{
+				Name: "info_url",
+			},
+			&core.URLField{
+				Name: "profile_image",
+			},
+			&core.DateField{
+				Name: "created_on",
+			},
+		)
+		updates.AddIndex("idx_event_updates_api_id", true, "api_id", "")
+		updates.AddIndex("idx_event_updates_created_on", false, "created_on", "")
+		updates.AddIndex("idx_event_updates_event", false, "event", "")
+
+		return app.Save(updates)
+	}, func(app core.App) error {
+		updates, err := app.FindCollectionByNameOrId("event_updates")
+		if err != nil {
+			return err
+		}
+		return app.Delete(updates)
+	})
+}
@@ -0,0 +1,218 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// notificationTriggers mirrors notify.Triggers
+var notificationTriggers = []string{
+	"t_minus_24h",
+	"t_minus_1h",
+	"t_minus_10m",
+	"webcast_live",
+	"status_change",
+	"launch_outcome",
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		agencies, err := app.FindCollectionByNameOrId("agencies")
+		if err != nil {
+			return err
+		}
+
+		pads, err := app.FindCollectionByNameOrId("pads")
+		if err != nil {
+			return err
+		}
+
+		rockets, err := app.FindCollectionByNameOrId("rockets")
+		if err != nil {
+			return err
+		}
+
+		programs, err := app.FindCollectionByNameOrId("programs")
+		if err != nil {
+			return err
+		}
+
+		// "Partial Failure" is the longest Launch Library status abbreviation
+		if field, ok := events.Fields.GetByName("status_abbrev").(*core.TextField); ok && field.Max < 20 {
+			field.Max = 20
+			if err := app.Save(events); err != nil {
+				return err
+			}
+		}
+
+		// What each user follows; the relation named by target_type holds the target
+		ownRule := types.Pointer("user = @request.auth.id")
+		subscriptions := core.NewBaseCollection("notification_subscriptions")
+		subscriptions.ListRule = ownRule
+		subscriptions.ViewRule = ownRule
+		subscriptions.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		subscriptions.UpdateRule = ownRule
+		subscriptions.DeleteRule = ownRule
+		subscriptions.Fields.Add(
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.SelectField{
+				Name:      "target_type",
+				Values:    []string{"provider", "pad", "rocket", "program", "launch"},
+				MaxSelect: 1,
+				Required:  true,
+			},
+			&core.RelationField{
+				Name:          "provider",
+				CollectionId:  agencies.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:          "pad",
+				CollectionId:  pads.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:          "rocket",
+				CollectionId:  rockets.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:          "program",
+				CollectionId:  programs.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:          "event",
+				CollectionId:  events.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.SelectField{
+				Name:      "triggers",
+				Values:    notificationTriggers,
+				MaxSelect: len(notificationTriggers),
+			},
+			&core.BoolField{
+				Name: "enabled",
+			},
+		)
+		subscriptions.AddIndex("idx_notification_subscriptions_user", false, "user", "")
+		if err := app.Save(subscriptions); err != nil {
+			return err
+		}
+
+		// One record per notification owed; dedupe_key makes each user/event/trigger unique
+		jobs := core.NewBaseCollection("notification_jobs")
+		jobs.ListRule = ownRule
+		jobs.ViewRule = ownRule
+		jobs.Fields.Add(
+			&core.TextField{
+				Name:     "dedupe_key",
+				Required: true,
+				Max:      255,
+			},
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:          "event",
+				CollectionId:  events.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:         "subscription",
+				CollectionId: subscriptions.Id,
+				MaxSelect:    1,
+			},
+			&core.SelectField{
+				Name:      "trigger",
+				Values:    notificationTriggers,
+				MaxSelect: 1,
+				Required:  true,
+			},
+			&core.JSONField{
+				Name: "detail",
+			},
+			&core.SelectField{
+				Name:      "status",
+				Values:    []string{"pending", "sent", "failed"},
+				MaxSelect: 1,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		jobs.AddIndex("idx_notification_jobs_dedupe_key", true, "dedupe_key", "")
+		jobs.AddIndex("idx_notification_jobs_status", false, "status", "")
+		if err := app.Save(jobs); err != nil {
+			return err
+		}
+
+		// What the engine last saw of each event, to detect status and webcast changes
+		states := core.NewBaseCollection("notification_event_states")
+		states.Fields.Add(
+			&core.RelationField{
+				Name:          "event",
+				CollectionId:  events.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.TextField{
+				Name: "status",
+				Max:  20,
+			},
+			&core.BoolField{
+				Name: "webcast_live",
+			},
+			&core.DateField{
+				Name: "net",
+			},
+		)
+		states.AddIndex("idx_notification_event_states_event", true, "event", "")
+		return app.Save(states)
+	}, func(app core.App) error {
+		for _, name := range []string{"notification_event_states", "notification_jobs", "notification_subscriptions"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// APNs device tokens, registered by the iOS app for the signed in user
+		ownRule := types.Pointer("user = @request.auth.id")
+		tokens := core.NewBaseCollection("device_tokens")
+		tokens.ListRule = ownRule
+		tokens.ViewRule = ownRule
+		tokens.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		tokens.UpdateRule = ownRule
+		tokens.DeleteRule = ownRule
+		tokens.Fields.Add(
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.TextField{
+				Name:     "token",
+				Required: true,
+				Max:      200,
+				Pattern:  "^[0-9a-fA-F]+$",
+			},
+			&core.SelectField{
+				Name:      "environment",
+				Values:    []string{"production", "development"},
+				MaxSelect: 1,
+				Required:  true,
+			},
+			&core.TextField{
+				Name: "bundle_id",
+				Max:  255,
+			},
+			&core.DateField{
+				Name: "last_registered",
+			},
+		)
+		tokens.AddIndex("idx_device_tokens_token", true, "token", "")
+		tokens.AddIndex("idx_device_tokens_user", false, "user", "")
+
+		return app.Save(tokens)
+	}, func(app core.App) error {
+		tokens, err := app.FindCollectionByNameOrId("device_tokens")
+		if err != nil {
+			return err
+		}
+		return app.Delete(tokens)
+	})
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Browser PushSubscriptions, saved by the dashboard for the signed in user
+		ownRule := types.Pointer("user = @request.auth.id")
+		subscriptions := core.NewBaseCollection("web_push_subscriptions")
+		subscriptions.ListRule = ownRule
+		subscriptions.ViewRule = ownRule
+		subscriptions.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		subscriptions.UpdateRule = ownRule
+		subscriptions.DeleteRule = ownRule
+		subscriptions.Fields.Add(
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.URLField{
+				Name:     "endpoint",
+				Required: true,
+			},
+			&core.TextField{
+				Name:     "p256dh",
+				Required: true,
+				Max:      200,
+			},
+			&core.TextField{
+				Name:     "auth",
+				Required: true,
+				Max:      100,
+			},
+			&core.DateField{
+				Name: "expires",
+			},
+		)
+		subscriptions.AddIndex("idx_web_push_subscriptions_endpoint", true, "endpoint", "")
+		subscriptions.AddIndex("idx_web_push_subscriptions_user", false, "user", "")
+
+		return app.Save(subscriptions)
+	}, func(app core.App) error {
+		subscriptions, err := app.FindCollectionByNameOrId("web_push_subscriptions")
+		if err != nil {
+			return err
+		}
+		return app.Delete(subscriptions)
+	})
+}
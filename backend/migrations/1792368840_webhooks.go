@@ -0,0 +1,151 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// webhookEvents mirrors notify.ChangeKinds
+var webhookEvents = []string{
+	"launch_created",
+	"net_changed",
+	"status_changed",
+	"webcast_live",
+	"launch_success",
+	"launch_failure",
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		agencies, err := app.FindCollectionByNameOrId("agencies")
+		if err != nil {
+			return err
+		}
+
+		// Outbound webhooks are managed by admins, so no API rules are set
+		webhooks := core.NewBaseCollection("webhooks")
+		webhooks.Fields.Add(
+			&core.TextField{
+				Name: "name",
+				Max:  255,
+			},
+			&core.URLField{
+				Name:     "url",
+				Required: true,
+			},
+			&core.SelectField{
+				Name:      "format",
+				Values:    []string{"json", "discord", "slack"},
+				MaxSelect: 1,
+				Required:  true,
+			},
+			&core.TextField{
+				Name:   "secret",
+				Max:    255,
+				Hidden: true,
+			},
+			&core.SelectField{
+				Name:      "events",
+				Values:    webhookEvents,
+				MaxSelect: len(webhookEvents),
+			},
+			&core.RelationField{
+				Name:         "providers",
+				CollectionId: agencies.Id,
+				MaxSelect:    100,
+			},
+			&core.BoolField{
+				Name: "enabled",
+			},
+			&core.NumberField{
+				Name:    "consecutive_failures",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "disabled_reason",
+				Max:  1000,
+			},
+		)
+		if err := app.Save(webhooks); err != nil {
+			return err
+		}
+
+		deliveries := core.NewBaseCollection("webhook_deliveries")
+		deliveries.Fields.Add(
+			&core.RelationField{
+				Name:          "webhook",
+				CollectionId:  webhooks.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.TextField{
+				Name:     "dedupe_key",
+				Required: true,
+				Max:      255,
+			},
+			&core.SelectField{
+				Name:      "kind",
+				Values:    webhookEvents,
+				MaxSelect: 1,
+				Required:  true,
+			},
+			&core.RelationField{
+				Name:          "event",
+				CollectionId:  events.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.JSONField{
+				Name: "body",
+			},
+			&core.SelectField{
+				Name:      "status",
+				Values:    []string{"pending", "delivered", "failed"},
+				MaxSelect: 1,
+			},
+			&core.NumberField{
+				Name:    "attempts",
+				OnlyInt: true,
+			},
+			&core.DateField{
+				Name: "next_attempt",
+			},
+			&core.NumberField{
+				Name:    "last_status",
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name: "last_error",
+				Max:  1000,
+			},
+			&core.DateField{
+				Name: "delivered_at",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		deliveries.AddIndex("idx_webhook_deliveries_dedupe_key", true, "dedupe_key", "")
+		deliveries.AddIndex("idx_webhook_deliveries_due", false, "status, next_attempt", "")
+
+		return app.Save(deliveries)
+	}, func(app core.App) error {
+		for _, name := range []string{"webhook_deliveries", "webhooks"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Weekly digest opt in, one per user. The unsubscribe token is set by the digest
+		// sender and can't be read or changed through the API.
+		ownRule := types.Pointer("user = @request.auth.id")
+		digests := core.NewBaseCollection("email_digests")
+		digests.ListRule = ownRule
+		digests.ViewRule = ownRule
+		digests.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.unsubscribe_token:isset = false")
+		digests.UpdateRule = types.Pointer("user = @request.auth.id && @request.body.user:isset = false && @request.body.unsubscribe_token:isset = false")
+		digests.DeleteRule = ownRule
+		digests.Fields.Add(
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.BoolField{
+				Name: "enabled",
+			},
+			&core.TextField{
+				Name: "timezone",
+				Max:  100,
+			},
+			&core.TextField{
+				Name:   "unsubscribe_token",
+				Max:    100,
+				Hidden: true,
+			},
+		)
+		digests.AddIndex("idx_email_digests_user", true, "user", "")
+		digests.AddIndex("idx_email_digests_unsubscribe_token", false, "unsubscribe_token", "")
+		if err := app.Save(digests); err != nil {
+			return err
+		}
+
+		// What was sent to whom, one record per user and ISO week
+		sends := core.NewBaseCollection("digest_sends")
+		sends.ListRule = ownRule
+		sends.ViewRule = ownRule
+		sends.Fields.Add(
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.TextField{
+				Name:     "dedupe_key",
+				Required: true,
+				Max:      100,
+			},
+			&core.TextField{
+				Name:     "period",
+				Required: true,
+				Max:      10,
+			},
+			&core.EmailField{
+				Name: "email",
+			},
+			&core.TextField{
+				Name: "subject",
+				Max:  500,
+			},
+			&core.JSONField{
+				Name: "events",
+			},
+			&core.SelectField{
+				Name:      "status",
+				Values:    []string{"sent", "failed"},
+				MaxSelect: 1,
+			},
+			&core.TextField{
+				Name: "error",
+				Max:  1000,
+			},
+			&core.TextField{
+				Name: "message_id",
+				Max:  255,
+			},
+			&core.DateField{
+				Name: "sent_at",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		sends.AddIndex("idx_digest_sends_dedupe_key", true, "dedupe_key", "")
+
+		return app.Save(sends)
+	}, func(app core.App) error {
+		for _, name := range []string{"digest_sends", "email_digests"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
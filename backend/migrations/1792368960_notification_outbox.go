@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		jobs, err := app.FindCollectionByNameOrId("notification_jobs")
+		if err != nil {
+			return err
+		}
+
+		// Jobs handed to the outbox are queued; sent and failed stay valid for older jobs
+		if field, ok := jobs.Fields.GetByName("status").(*core.SelectField); ok {
+			field.Values = []string{"pending", "queued", "sent", "failed"}
+		}
+		if err := app.Save(jobs); err != nil {
+			return err
+		}
+
+		// One delivery per job and channel, written by cmd/notify only
+		outbox := core.NewBaseCollection("notification_outbox")
+		outbox.Fields.Add(
+			&core.TextField{
+				Name:     "idempotency_key",
+				Required: true,
+				Max:      255,
+			},
+			&core.RelationField{
+				Name:          "job",
+				CollectionId:  jobs.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				CascadeDelete: true,
+			},
+			&core.TextField{
+				Name:     "channel",
+				Required: true,
+				Max:      50,
+			},
+			&core.SelectField{
+				Name:      "status",
+				Values:    []string{"queued", "sending", "delivered", "failed", "dead", "skipped"},
+				MaxSelect: 1,
+				Required:  true,
+			},
+			&core.NumberField{
+				Name:    "attempts",
+				OnlyInt: true,
+			},
+			&core.DateField{
+				Name: "next_attempt",
+			},
+			&core.DateField{
+				Name: "claimed_at",
+			},
+			&core.TextField{
+				Name: "last_error",
+				Max:  1000,
+			},
+			&core.DateField{
+				Name: "delivered_at",
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		outbox.AddIndex("idx_notification_outbox_idempotency_key", true, "idempotency_key", "")
+		outbox.AddIndex("idx_notification_outbox_due", false, "status, next_attempt", "")
+
+		return app.Save(outbox)
+	}, func(app core.App) error {
+		outbox, err := app.FindCollectionByNameOrId("notification_outbox")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(outbox); err != nil {
+			return err
+		}
+
+		jobs, err := app.FindCollectionByNameOrId("notification_jobs")
+		if err != nil {
+			return err
+		}
+		if field, ok := jobs.Fields.GetByName("status").(*core.SelectField); ok {
+			field.Values = []string{"pending", "sent", "failed"}
+		}
+		return app.Save(jobs)
+	})
+}
@@ -0,0 +1,120 @@
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// eventRevisionKinds mirrors sync.LaunchChangeKinds
+var eventRevisionKinds = []string{
+	"net_later",
+	"net_earlier",
+	"window_changed",
+	"hold",
+	"tbd",
+	"scrub",
+	"new_date",
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		events, err := app.FindCollectionByNameOrId("events")
+		if err != nil {
+			return err
+		}
+
+		// Schedule changes classified by the launch sync: the public revision history of
+		// each launch, and the schedule_change notifications' source
+		revisions := core.NewBaseCollection("event_revisions")
+		revisions.ListRule = types.Pointer("")
+		revisions.ViewRule = types.Pointer("")
+		revisions.Fields.Add(
+			&core.RelationField{
+				Name:          "event",
+				CollectionId:  events.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.SelectField{
+				Name:      "kind",
+				Values:    eventRevisionKinds,
+				MaxSelect: 1,
+				Required:  true,
+			},
+			&core.DateField{Name: "net_from"},
+			&core.DateField{Name: "net_to"},
+			&core.NumberField{
+				Name:    "delta_seconds",
+				OnlyInt: true,
+			},
+			&core.DateField{Name: "window_start_from"},
+			&core.DateField{Name: "window_start_to"},
+			&core.DateField{Name: "window_end_from"},
+			&core.DateField{Name: "window_end_to"},
+			&core.TextField{
+				Name: "status_from",
+				Max:  20,
+			},
+			&core.TextField{
+				Name: "status_to",
+				Max:  20,
+			},
+			&core.BoolField{
+				Name:   "notified",
+				Hidden: true,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				OnCreate: true,
+			},
+		)
+		revisions.AddIndex("idx_event_revisions_event", false, "event, created", "")
+		revisions.AddIndex("idx_event_revisions_notified", false, "notified", "")
+		if err := app.Save(revisions); err != nil {
+			return err
+		}
+
+		return setTrigger(app, "schedule_change", true)
+	}, func(app core.App) error {
+		if err := setTrigger(app, "schedule_change", false); err != nil {
+			return err
+		}
+		revisions, err := app.FindCollectionByNameOrId("event_revisions")
+		if err != nil {
+			return err
+		}
+		return app.Delete(revisions)
+	})
+}
+
+// setTrigger adds or removes a notification trigger from the trigger selects
+func setTrigger(app core.App, trigger string, add bool) error {
+	for _, name := range []string{"notification_subscriptions", "notification_jobs"} {
+		collection, err := app.FindCollectionByNameOrId(name)
+		if err != nil {
+			return err
+		}
+		field, ok := collection.Fields.GetByName("triggers").(*core.SelectField)
+		if !ok {
+			field, ok = collection.Fields.GetByName("trigger").(*core.SelectField)
+		}
+		if !ok {
+			continue
+		}
+		field.Values = slices.DeleteFunc(field.Values, func(v string) bool { return v == trigger })
+		if add {
+			field.Values = append(field.Values, trigger)
+		}
+		if field.MaxSelect > 1 {
+			field.MaxSelect = len(field.Values)
+		}
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
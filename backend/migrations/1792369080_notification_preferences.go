@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// How each user wants to be notified, at most one record per user
+		ownRule := types.Pointer("user = @request.auth.id")
+		prefs := core.NewBaseCollection("notification_preferences")
+		prefs.ListRule = ownRule
+		prefs.ViewRule = ownRule
+		prefs.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		prefs.UpdateRule = types.Pointer("user = @request.auth.id && @request.body.user:isset = false")
+		prefs.DeleteRule = ownRule
+		prefs.Fields.Add(
+			&core.RelationField{
+				Name:          "user",
+				CollectionId:  users.Id,
+				MaxSelect:     1,
+				Required:      true,
+				CascadeDelete: true,
+			},
+			&core.TextField{
+				Name: "timezone",
+				Max:  100,
+			},
+			&core.TextField{
+				Name:    "quiet_start",
+				Pattern: `^([01][0-9]|2[0-3]):[0-5][0-9]$`,
+			},
+			&core.TextField{
+				Name:    "quiet_end",
+				Pattern: `^([01][0-9]|2[0-3]):[0-5][0-9]$`,
+			},
+			&core.BoolField{
+				Name: "urgent_in_quiet_hours",
+			},
+			&core.SelectField{
+				Name:      "channels",
+				Values:    []string{"apns", "webpush"},
+				MaxSelect: 2,
+			},
+			&core.NumberField{
+				Name:    "min_lead_minutes",
+				OnlyInt: true,
+				Min:     types.Pointer(0.0),
+			},
+			&core.SelectField{
+				Name:      "delivery",
+				Values:    []string{"immediate", "digest"},
+				MaxSelect: 1,
+			},
+			// [{"provider": "<agency id>", "muted": false, "channels": ["apns"], "ignore_quiet_hours": true}]
+			&core.JSONField{
+				Name: "provider_overrides",
+			},
+		)
+		prefs.AddIndex("idx_notification_preferences_user", true, "user", "")
+		if err := app.Save(prefs); err != nil {
+			return err
+		}
+
+		jobs, err := app.FindCollectionByNameOrId("notification_jobs")
+		if err != nil {
+			return err
+		}
+		if field, ok := jobs.Fields.GetByName("status").(*core.SelectField); ok {
+			field.Values = []string{"pending", "queued", "suppressed", "sent", "failed"}
+		}
+		jobs.Fields.Add(&core.TextField{
+			Name: "suppressed_reason",
+			Max:  255,
+		})
+		return app.Save(jobs)
+	}, func(app core.App) error {
+		jobs, err := app.FindCollectionByNameOrId("notification_jobs")
+		if err != nil {
+			return err
+		}
+		if field, ok := jobs.Fields.GetByName("status").(*core.SelectField); ok {
+			field.Values = []string{"pending", "queued", "sent", "failed"}
+		}
+		jobs.Fields.RemoveByName("suppressed_reason")
+		if err := app.Save(jobs); err != nil {
+			return err
+		}
+
+		prefs, err := app.FindCollectionByNameOrId("notification_preferences")
+		if err != nil {
+			return err
+		}
+		return app.Delete(prefs)
+	})
+}
@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// localeField is the language notifications are written in, e.g. "es" or "pt-BR"
+func localeField() *core.TextField {
+	return &core.TextField{
+		Name:    "locale",
+		Max:     20,
+		Pattern: `^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`,
+	}
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Notification wording overriding the built in templates; superusers only
+		tmpl := core.NewBaseCollection("notification_templates")
+		tmpl.Fields.Add(
+			// apns, webpush, webhook, email, or default for all of them
+			&core.TextField{
+				Name:     "channel",
+				Required: true,
+				Max:      50,
+			},
+			// A notification trigger, webhook change kind, or digest
+			&core.TextField{
+				Name:     "trigger",
+				Required: true,
+				Max:      50,
+			},
+			// Empty for every language
+			localeField(),
+			&core.TextField{
+				Name: "title",
+			},
+			&core.TextField{
+				Name: "body",
+			},
+			&core.TextField{
+				Name: "html",
+			},
+		)
+		tmpl.AddIndex("idx_notification_templates_key", true, "channel, trigger, locale", "")
+		if err := app.Save(tmpl); err != nil {
+			return err
+		}
+
+		for _, name := range []string{"notification_preferences", "webhooks"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.Fields.Add(localeField())
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error {
+		for _, name := range []string{"notification_preferences", "webhooks"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.Fields.RemoveByName("locale")
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		tmpl, err := app.FindCollectionByNameOrId("notification_templates")
+		if err != nil {
+			return err
+		}
+		return app.Delete(tmpl)
+	})
+}
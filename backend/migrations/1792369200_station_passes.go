@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// stationNorad holds the NORAD catalog numbers of the crewed stations, by a word of
+// their name, for the stations synced before norad_id existed
+var stationNorad = map[string]int{
+	"international space station": 25544,
+	"tiangong":                    48274,
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		stations, err := app.FindCollectionByNameOrId("stations")
+		if err != nil {
+			return err
+		}
+		// The station's catalog number, which its element set is looked up by
+		stations.Fields.Add(&core.NumberField{
+			Name:    "norad_id",
+			OnlyInt: true,
+			Min:     types.Pointer(1.0),
+		})
+		if err := app.Save(stations); err != nil {
+			return err
+		}
+		records, err := app.FindAllRecords("stations")
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			for name, norad := range stationNorad {
+				if strings.Contains(strings.ToLower(record.GetString("name")), name) {
+					record.Set("norad_id", norad)
+					if err := app.Save(record); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		// Stations can be followed for their visible passes
+		subscriptions, err := app.FindCollectionByNameOrId("notification_subscriptions")
+		if err != nil {
+			return err
+		}
+		if field, ok := subscriptions.Fields.GetByName("target_type").(*core.SelectField); ok {
+			field.Values = append(slices.DeleteFunc(field.Values, func(v string) bool { return v == "station" }), "station")
+		}
+		subscriptions.Fields.Add(&core.RelationField{
+			Name:          "station",
+			CollectionId:  stations.Id,
+			MaxSelect:     1,
+			CascadeDelete: true,
+		})
+		if err := app.Save(subscriptions); err != nil {
+			return err
+		}
+		if err := setTrigger(app, "visible_pass", true); err != nil {
+			return err
+		}
+
+		// Where the user watches the sky from
+		prefs, err := app.FindCollectionByNameOrId("notification_preferences")
+		if err != nil {
+			return err
+		}
+		prefs.Fields.Add(
+			&core.NumberField{
+				Name: "latitude",
+				Min:  types.Pointer(-90.0),
+				Max:  types.Pointer(90.0),
+			},
+			&core.NumberField{
+				Name: "longitude",
+				Min:  types.Pointer(-180.0),
+				Max:  types.Pointer(180.0),
+			},
+			&core.NumberField{
+				Name: "elevation_m",
+			},
+		)
+		return app.Save(prefs)
+	}, func(app core.App) error {
+		prefs, err := app.FindCollectionByNameOrId("notification_preferences")
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"latitude", "longitude", "elevation_m"} {
+			prefs.Fields.RemoveByName(name)
+		}
+		if err := app.Save(prefs); err != nil {
+			return err
+		}
+
+		if err := setTrigger(app, "visible_pass", false); err != nil {
+			return err
+		}
+		subscriptions, err := app.FindCollectionByNameOrId("notification_subscriptions")
+		if err != nil {
+			return err
+		}
+		if field, ok := subscriptions.Fields.GetByName("target_type").(*core.SelectField); ok {
+			field.Values = slices.DeleteFunc(field.Values, func(v string) bool { return v == "station" })
+		}
+		subscriptions.Fields.RemoveByName("station")
+		if err := app.Save(subscriptions); err != nil {
+			return err
+		}
+
+		stations, err := app.FindCollectionByNameOrId("stations")
+		if err != nil {
+			return err
+		}
+		stations.Fields.RemoveByName("norad_id")
+		return app.Save(stations)
+	})
+}
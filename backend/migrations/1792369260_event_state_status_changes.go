@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		states, err := app.FindCollectionByNameOrId("notification_event_states")
+		if err != nil {
+			return err
+		}
+
+		// How many status changes the engine has seen, so a launch returning to an
+		// earlier status still notifies
+		states.Fields.Add(&core.NumberField{
+			Name:    "status_changes",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		return app.Save(states)
+	}, func(app core.App) error {
+		states, err := app.FindCollectionByNameOrId("notification_event_states")
+		if err != nil {
+			return err
+		}
+		states.Fields.RemoveByName("status_changes")
+		return app.Save(states)
+	})
+}
@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// newTestApp bootstraps an empty PocketBase with only the system collections
+func newTestApp(t *testing.T) core.App {
+	t.Helper()
+	app := core.NewBaseApp(core.BaseAppConfig{DataDir: t.TempDir()})
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { app.ResetBootstrapState() })
+	if err := app.RunSystemMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+// migrate runs the up or down step of the migration registered from file
+func migrate(t *testing.T, app core.App, file string, up bool) {
+	t.Helper()
+	for _, migration := range core.AppMigrations.Items() {
+		if migration.File != file {
+			continue
+		}
+		run := migration.Down
+		if up {
+			run = migration.Up
+		}
+		if err := app.RunInTransaction(run); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		return
+	}
+	t.Fatalf("no migration registered from %s", file)
+}
+
+func saveCollection(t *testing.T, app core.App, name string, fields ...core.Field) *core.Collection {
+	t.Helper()
+	collection := core.NewBaseCollection(name)
+	collection.Fields.Add(fields...)
+	if err := app.Save(collection); err != nil {
+		t.Fatalf("save %s: %v", name, err)
+	}
+	return collection
+}
+
+func saveRecord(t *testing.T, app core.App, collection string, values map[string]any) *core.Record {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(c)
+	record.Load(values)
+	if err := app.Save(record); err != nil {
+		t.Fatalf("save %s record: %v", collection, err)
+	}
+	return record
+}
+
+func findRecord(t *testing.T, app core.App, collection, id string) *core.Record {
+	t.Helper()
+	record, err := app.FindRecordById(collection, id)
+	if err != nil {
+		t.Fatalf("find %s %s: %v", collection, id, err)
+	}
+	return record
+}
+
+// relationTarget returns the collection a relation field points at
+func relationTarget(t *testing.T, app core.App, collection, field string) string {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relation, ok := c.Fields.GetByName(field).(*core.RelationField)
+	if !ok {
+		t.Fatalf("%s.%s is %T, want a relation", collection, field, c.Fields.GetByName(field))
+	}
+	target, err := app.FindCollectionByNameOrId(relation.CollectionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return target.Name
+}